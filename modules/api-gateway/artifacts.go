@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// artifact records one build output uploaded to object storage. The
+// bytes live in the store; workspaces and API responses only carry this
+// metadata, keeping worktrees and payloads small.
+type artifact struct {
+	ID        string `json:"id"`
+	Workspace string `json:"workspace"`
+	// Path is the artifact's original path relative to the worktree.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// URL is where the bytes landed in the object store.
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type artifactRequest struct {
+	// Path is the file to upload, relative to the worktree.
+	Path string `json:"path"`
+}
+
+// handleArtifacts routes the per-workspace artifact endpoints:
+// GET (list), POST (upload) and GET /{artifact-id} (download).
+func (s *server) handleArtifacts(w http.ResponseWriter, r *http.Request, id, rest string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		s.listArtifacts(w, ws)
+	case rest == "" && r.Method == http.MethodPost:
+		s.uploadArtifact(w, r, ws)
+	case rest != "" && r.Method == http.MethodGet:
+		s.downloadArtifact(w, r, ws, rest)
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+func (s *server) listArtifacts(w http.ResponseWriter, ws *registry.Workspace) {
+	s.artifactsMu.Lock()
+	out := append([]*artifact{}, s.artifacts[ws.ID]...)
+	s.artifactsMu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *server) uploadArtifact(w http.ResponseWriter, r *http.Request, ws *registry.Workspace) {
+	if s.artifactStore == "" {
+		writeError(w, http.StatusNotImplemented, "no artifact store configured (-artifact-store)")
+		return
+	}
+
+	var req artifactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.Path == "" {
+		writeError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	// The path must stay inside the worktree; artifacts are workspace
+	// outputs, not arbitrary host files.
+	cleaned := filepath.Clean(req.Path)
+	if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		writeError(w, http.StatusBadRequest, "path must be relative to the worktree")
+		return
+	}
+
+	source := filepath.Join(ws.Worktree, cleaned)
+	info, err := os.Stat(source)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if info.IsDir() {
+		writeError(w, http.StatusBadRequest, "path is a directory; upload files individually")
+		return
+	}
+
+	a := &artifact{
+		ID:        "art-" + newWorkspaceID()[3:],
+		Workspace: ws.ID,
+		Path:      cleaned,
+		Size:      info.Size(),
+		CreatedAt: time.Now().UTC(),
+	}
+	a.URL = s.artifactStore + "/" + ws.ID + "/" + a.ID + "/" + filepath.Base(cleaned)
+
+	if err := storeCopy(source, a.URL); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.artifactsMu.Lock()
+	s.artifacts[ws.ID] = append(s.artifacts[ws.ID], a)
+	s.artifactsMu.Unlock()
+
+	writeJSON(w, http.StatusCreated, a)
+}
+
+// captureArtifacts collects worktree files matching the globs and
+// uploads each to the artifact store — CI semantics: declare patterns
+// up front, collect whatever the run produced. Directories and glob
+// misses are skipped silently, like a CI artifact step with nothing to
+// pick up.
+func (s *server) captureArtifacts(ws *registry.Workspace, globs []string) ([]*artifact, error) {
+	if s.artifactStore == "" {
+		return nil, fmt.Errorf("no artifact store configured (-artifact-store)")
+	}
+
+	var captured []*artifact
+	for _, glob := range globs {
+		cleaned := filepath.Clean(glob)
+		if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+			return nil, fmt.Errorf("artifact glob %s must be relative to the worktree", glob)
+		}
+		matches, err := filepath.Glob(filepath.Join(ws.Worktree, cleaned))
+		if err != nil {
+			return nil, fmt.Errorf("artifact glob %s: %w", glob, err)
+		}
+		for _, source := range matches {
+			info, err := os.Stat(source)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(ws.Worktree, source)
+			if err != nil {
+				continue
+			}
+			a := &artifact{
+				ID:        "art-" + newWorkspaceID()[3:],
+				Workspace: ws.ID,
+				Path:      rel,
+				Size:      info.Size(),
+				CreatedAt: time.Now().UTC(),
+			}
+			a.URL = s.artifactStore + "/" + ws.ID + "/" + a.ID + "/" + filepath.Base(rel)
+			if err := storeCopy(source, a.URL); err != nil {
+				return captured, err
+			}
+			captured = append(captured, a)
+		}
+	}
+
+	s.artifactsMu.Lock()
+	s.artifacts[ws.ID] = append(s.artifacts[ws.ID], captured...)
+	s.artifactsMu.Unlock()
+	return captured, nil
+}
+
+func (s *server) downloadArtifact(w http.ResponseWriter, r *http.Request, ws *registry.Workspace, artifactID string) {
+	s.artifactsMu.Lock()
+	var found *artifact
+	for _, a := range s.artifacts[ws.ID] {
+		if a.ID == artifactID {
+			found = a
+			break
+		}
+	}
+	s.artifactsMu.Unlock()
+	if found == nil {
+		writeError(w, http.StatusNotFound, "artifact not found")
+		return
+	}
+
+	// Stage the bytes locally; the store CLIs copy to files, not pipes.
+	tmp, err := os.MkdirTemp("", "patina-artifact-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	local := filepath.Join(tmp, filepath.Base(found.Path))
+	if err := storeCopy(found.URL, local); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+filepath.Base(found.Path))
+	http.ServeFile(w, r, local)
+}
+
+// storeCopy copies between a local path and an object store URL through
+// the store's own CLI: aws for s3://, gsutil for gs://, mc for minio
+// aliases. The CLIs carry the credentials and retry logic; the gateway
+// stays out of the auth business.
+func storeCopy(src, dst string) error {
+	remote := dst
+	if !strings.Contains(remote, "://") {
+		remote = src
+	}
+
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(remote, "s3://"):
+		cmd = exec.Command("aws", "s3", "cp", src, dst)
+	case strings.HasPrefix(remote, "gs://"):
+		cmd = exec.Command("gsutil", "cp", src, dst)
+	default:
+		cmd = exec.Command("mc", "cp", src, dst)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("copy to %s: %s", dst, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// attachMessage is one client-to-server frame on an attach session:
+// typed input or a terminal resize.
+type attachMessage struct {
+	Type string `json:"type"` // "stdin" or "resize"
+	Data string `json:"data,omitempty"`
+	Cols int    `json:"cols,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+}
+
+// attachWorkspace upgrades the request to a WebSocket and bridges it to
+// a PTY-backed session in the workspace, carrying stdin, stdout and
+// resize messages. This is what `patina shell` and browser terminals
+// speak. Output flows as binary frames; input and resizes arrive as
+// JSON text frames.
+func (s *server) attachWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status != registry.StatusReady {
+		writeError(w, http.StatusConflict, "workspace is not ready (status: "+ws.Status+")")
+		return
+	}
+	s.registry.Touch(ws.ID)
+	if err := checkLock(ws, r.URL.Query().Get("holder")); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	ir, ok := s.executor.(executor.InteractiveRunner)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "executor cannot open interactive sessions")
+		return
+	}
+
+	command := []string{"sh"}
+	if c := r.URL.Query().Get("command"); c != "" {
+		command = strings.Fields(c)
+	}
+	if ws.NixFlake != "" {
+		command = provider.NixCommand(ws.NixFlake, command)
+	}
+	env, err := workspaceEnv(ws)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	session, err := ir.Interactive(r.Context(), executor.Request{
+		Image:    ws.Image,
+		Worktree: ws.Worktree,
+		Command:  command,
+		Pod:      provider.PodName(ws.Name),
+		Caches:   ws.Caches,
+		Mounts:   executorMounts(ws.Mounts),
+		Services: executorServices(ws.Services),
+		Env:      env,
+		User:     userConfig(ws).UserSpec(),
+		Engine:   ws.Engine,
+	})
+	if err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		session.Close()
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer conn.Close()
+	defer session.Close()
+
+	// Every session records to a cast file so reviewers can replay what
+	// happened; a recording failure degrades to an unrecorded session
+	// rather than blocking the attach.
+	recorder, err := newSessionRecorder(ws.Worktree, 80, 24)
+	if err != nil {
+		log.Printf("workspace %s: session recording failed: %v", ws.ID, err)
+	} else {
+		defer recorder.close()
+	}
+
+	// Terminal output to the client; ends when the session's command
+	// exits and the PTY drains.
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := session.Read(buf)
+			if n > 0 {
+				if recorder != nil {
+					recorder.output(buf[:n])
+				}
+				if err := conn.WriteMessage(wsBinary, buf[:n]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// Client input and resizes to the session.
+	for {
+		opcode, payload, err := conn.ReadMessage()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			return
+		}
+		if opcode == wsBinary {
+			if _, err := session.Write(payload); err != nil {
+				return
+			}
+			continue
+		}
+		var msg attachMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "stdin":
+			if _, err := session.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case "resize":
+			if err := session.Resize(msg.Cols, msg.Rows); err != nil {
+				log.Printf("workspace %s: resize failed: %v", ws.ID, err)
+			}
+			if recorder != nil {
+				recorder.resize(msg.Cols, msg.Rows)
+			}
+		}
+	}
+}
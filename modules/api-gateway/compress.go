@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipPool reuses compressors; one is needed for every response the
+// gateway serves to a gzip-capable client.
+var gzipPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// withGzip compresses responses for clients that accept it. Workspace
+// lists, diffs and run logs reach megabytes; sending them uncompressed
+// wastes most of the bytes. Event streams stay uncompressed: SSE relies
+// on flush-per-event delivery, which gzip's internal buffering would
+// hold back.
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter defers the compress-or-passthrough decision to the
+// first write, when the handler has set its Content-Type.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	passthrough bool
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+			w.passthrough = true
+		} else {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Del("Content-Length")
+			gz := gzipPool.Get().(*gzip.Writer)
+			gz.Reset(w.ResponseWriter)
+			w.gz = gz
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.gz.Write(p)
+}
+
+// Flush keeps the streaming handlers working behind the middleware:
+// they type-assert http.Flusher on whatever writer they get.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush() //nolint:errcheck
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the raw connection through for protocol upgrades; the
+// attach endpoint needs it for its WebSocket handshake.
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection cannot be hijacked")
+	}
+	return hj.Hijack()
+}
+
+// close finishes the compressed stream and returns the writer to the
+// pool.
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close() //nolint:errcheck
+		gzipPool.Put(w.gz)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsConfig controls cross-origin access to the gateway API.
+type corsConfig struct {
+	// origins are the allowed Origin values; "*" allows any. Empty
+	// disables CORS entirely, which is the default: the gateway serves
+	// CLI clients that never send an Origin.
+	origins []string
+	// headers are extra request headers allowed on cross-origin calls,
+	// on top of Content-Type and Authorization.
+	headers []string
+	// credentials permits cookies and authorization headers on
+	// cross-origin requests.
+	credentials bool
+}
+
+// enabled reports whether any origin is allowed.
+func (c corsConfig) enabled() bool { return len(c.origins) > 0 }
+
+// allows reports whether the origin may call the API.
+func (c corsConfig) allows(origin string) bool {
+	for _, allowed := range c.origins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// withCORS answers preflight requests and stamps cross-origin response
+// headers, so the dashboard and third-party web UIs can call the API
+// directly from browsers. Requests from origins outside the allow-list
+// pass through without CORS headers; the browser enforces the refusal.
+func withCORS(next http.Handler, cfg corsConfig) http.Handler {
+	if !cfg.enabled() {
+		return next
+	}
+	allowHeaders := strings.Join(append([]string{"Content-Type", "Authorization"}, cfg.headers...), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !cfg.allows(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Echo the specific origin rather than "*": wildcard responses
+		// are rejected by browsers when credentials are in play.
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if cfg.credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
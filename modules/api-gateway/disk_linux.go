@@ -0,0 +1,18 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// diskUsedPercent reports how full the filesystem holding path is.
+func diskUsedPercent(path string) (int, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, err
+	}
+	if fs.Blocks == 0 {
+		return 0, nil
+	}
+	used := fs.Blocks - fs.Bavail
+	return int(used * 100 / fs.Blocks), nil
+}
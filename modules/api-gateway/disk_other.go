@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// diskUsedPercent reports how full the filesystem holding path is.
+// Only Linux hosts report usage; elsewhere the eviction controller
+// disables itself rather than guessing.
+func diskUsedPercent(path string) (int, error) {
+	return 0, fmt.Errorf("disk usage reporting is not supported on this platform")
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// evictInterval is how often the eviction controller samples disk
+// pressure. Eviction frees space slowly (a paused pod's image stays
+// cached), so there is nothing to gain from sampling faster.
+const evictInterval = 30 * time.Second
+
+// runEvictionLoop watches disk usage under the repository (where
+// worktrees and the container runtime's storage typically live) and
+// pauses workspaces when it crosses the configured threshold, so
+// capacity comes back from idle workspaces instead of the next create
+// failing with a runtime disk error nobody can act on.
+//
+// At most one workspace is paused per sample: pausing is disruptive,
+// and the next sample sees whatever space the first eviction freed.
+func (s *server) runEvictionLoop(threshold int) {
+	ticker := time.NewTicker(evictInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		used, err := diskUsedPercent(s.git.RepoPath)
+		if err != nil {
+			log.Printf("eviction disabled: disk usage unavailable: %v", err)
+			return
+		}
+		if used < threshold {
+			continue
+		}
+		ws := s.evictionCandidate()
+		if ws == nil {
+			log.Printf("disk %d%% used (threshold %d%%) but no evictable workspace", used, threshold)
+			continue
+		}
+		log.Printf("disk %d%% used (threshold %d%%): pausing workspace %s (%s)",
+			used, threshold, ws.ID, ws.Name)
+		s.pauseEnvironment(ws)
+	}
+}
+
+// evictionCandidate picks the least-recently-active running workspace
+// that isn't protected. Creating, deleting and already-paused
+// workspaces are skipped: they hold no capacity eviction can reclaim.
+func (s *server) evictionCandidate() *registry.Workspace {
+	var candidates []*registry.Workspace
+	for _, ws := range s.registry.List(registry.Filter{}) {
+		if ws.Protected {
+			continue
+		}
+		if ws.Status != registry.StatusReady && ws.Status != registry.StatusUnhealthy {
+			continue
+		}
+		candidates = append(candidates, ws)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lastActive(candidates[i]).Before(lastActive(candidates[j]))
+	})
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
+
+// lastActive is the workspace's activity timestamp, falling back to
+// creation time for workspaces nothing has run in yet.
+func lastActive(ws *registry.Workspace) time.Time {
+	if ws.LastActive.IsZero() {
+		return ws.CreatedAt
+	}
+	return ws.LastActive
+}
+
+// pauseEnvironment tears down the workspace's runtime and marks it
+// paused. The worktree, branch and record all survive; resume rebuilds
+// the environment from them.
+func (s *server) pauseEnvironment(ws *registry.Workspace) {
+	if err := s.provider.Destroy(context.Background(), ws.Name); err != nil {
+		log.Printf("workspace %s: pause destroy failed: %v", ws.ID, err)
+		return
+	}
+	if err := s.registry.UpdateStatus(ws.ID, registry.StatusPaused); err != nil {
+		log.Printf("workspace %s: status update skipped: %v", ws.ID, err)
+	}
+}
+
+// pauseWorkspace handles POST /workspaces/{id}/pause: the operator-
+// driven version of what the eviction controller does under pressure.
+func (s *server) pauseWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status != registry.StatusReady && ws.Status != registry.StatusUnhealthy {
+		writeError(w, http.StatusConflict, "workspace is "+ws.Status)
+		return
+	}
+	s.pauseEnvironment(ws)
+	s.getWorkspace(w, id)
+}
+
+// resumeWorkspace handles POST /workspaces/{id}/resume: the paused
+// workspace's environment is recreated from its record, through the
+// same queue and setup path as creation. Clients poll status like they
+// do after create.
+func (s *server) resumeWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status != registry.StatusPaused {
+		writeError(w, http.StatusConflict, "workspace is "+ws.Status)
+		return
+	}
+	if err := s.registry.UpdateStatus(ws.ID, registry.StatusCreating); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	s.queue.enqueue(ws.ID, ws.Name)
+	go s.initializeContainer(ws, nil)
+
+	s.getWorkspace(w, id)
+}
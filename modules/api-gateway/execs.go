@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// inflightExec is one tracked execution. The ID goes back to the
+// caller in the X-Patina-Exec-Id response header and shows up in the
+// workspace's exec list, so any client can cancel it.
+type inflightExec struct {
+	ID          string    `json:"id"`
+	Command     []string  `json:"command"`
+	StartedAt   time.Time `json:"started_at"`
+	workspaceID string
+	cancel      context.CancelFunc
+}
+
+// execTracker tracks in-flight executions so a hung command can be
+// cancelled without deleting its workspace.
+type execTracker struct {
+	mu     sync.Mutex
+	active map[string]*inflightExec
+}
+
+func newExecTracker() *execTracker {
+	return &execTracker{active: make(map[string]*inflightExec)}
+}
+
+// begin registers an execution under a fresh ID and returns a context
+// whose cancellation kills the underlying process. The returned func
+// deregisters the execution; callers defer it.
+func (t *execTracker) begin(ctx context.Context, workspaceID string, command []string) (context.Context, string, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	id := newExecID()
+
+	t.mu.Lock()
+	t.active[id] = &inflightExec{
+		ID:          id,
+		Command:     command,
+		StartedAt:   time.Now().UTC(),
+		workspaceID: workspaceID,
+		cancel:      cancel,
+	}
+	t.mu.Unlock()
+
+	return ctx, id, func() {
+		t.mu.Lock()
+		delete(t.active, id)
+		t.mu.Unlock()
+		cancel()
+	}
+}
+
+// stop cancels the identified execution. Finished executions have
+// already deregistered, so stale IDs report not found.
+func (t *execTracker) stop(workspaceID, execID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.active[execID]
+	if !ok || entry.workspaceID != workspaceID {
+		return fmt.Errorf("exec %s: %w", execID, errdefs.ErrNotFound)
+	}
+	entry.cancel()
+	return nil
+}
+
+// list returns the workspace's in-flight executions, oldest first.
+func (t *execTracker) list(workspaceID string) []*inflightExec {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]*inflightExec, 0)
+	for _, entry := range t.active {
+		if entry.workspaceID == workspaceID {
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].StartedAt.Before(out[j].StartedAt)
+	})
+	return out
+}
+
+// listExecs handles GET /workspaces/{id}/exec: the in-flight
+// executions a client could cancel.
+func (s *server) listExecs(w http.ResponseWriter, id string) {
+	if _, ok := s.registry.Get(id); !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.execs.list(id))
+}
+
+// cancelExec handles DELETE /workspaces/{id}/exec/{execID}: the
+// execution's context is cancelled, which kills the runtime process.
+// The original exec call returns a cancellation error to its caller.
+func (s *server) cancelExec(w http.ResponseWriter, id, execID string) {
+	if err := s.execs.stop(id, execID); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "cancelling"})
+}
+
+// newExecID returns a short random execution ID.
+func newExecID() string {
+	buf := make([]byte, 6)
+	rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+	return "exec-" + hex.EncodeToString(buf)
+}
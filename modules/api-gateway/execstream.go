@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// streamExec runs the command while relaying its output as server-sent
+// events, one per chunk, tagged stdout or stderr. A final event carries
+// the exit code. Long-running commands (builds, test suites) otherwise
+// sit silent until the buffered response lands.
+func (s *server) streamExec(w http.ResponseWriter, r *http.Request, ws *registry.Workspace, req executor.Request, execID string) {
+	sr, ok := s.executor.(executor.StreamRunner)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "executor cannot stream output")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Stdout and stderr arrive from separate pipe goroutines; serialize
+	// the SSE frames.
+	var mu sync.Mutex
+	emit := func(event any) {
+		mu.Lock()
+		defer mu.Unlock()
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", payload)
+		flusher.Flush()
+	}
+	chunk := func(stream string) executor.StreamFunc {
+		return func(data []byte) {
+			emit(map[string]string{"stream": stream, "data": string(data)})
+		}
+	}
+
+	started := time.Now().UTC()
+	result, err := sr.ExecuteStream(r.Context(), req, chunk("stdout"), chunk("stderr"))
+	if !req.DryRun {
+		s.recordExecution(ws.ID, execID, req.User, req.Command, started, result, err)
+	}
+	if err != nil {
+		emit(map[string]string{"error": err.Error()})
+		return
+	}
+	s.budgets.Charge(ws.ID, result.Duration)
+
+	if ws.Checkpoint.Enabled && ws.Checkpoint.AfterExec {
+		s.checkpoint(ws)
+	}
+
+	emit(map[string]any{"exit_code": result.ExitCode, "duration": result.Duration.String()})
+}
+
+// streamExecNDJSON runs the command while relaying its output as
+// newline-delimited JSON records, selected with Accept:
+// application/x-ndjson. It carries the same chunks as the SSE mode but
+// parses with nothing more than a line reader, for clients without an
+// SSE or WebSocket stack.
+func (s *server) streamExecNDJSON(w http.ResponseWriter, r *http.Request, ws *registry.Workspace, req executor.Request, execID string) {
+	sr, ok := s.executor.(executor.StreamRunner)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "executor cannot stream output")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	emit := func(record any) {
+		mu.Lock()
+		defer mu.Unlock()
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "%s\n", payload)
+		flusher.Flush()
+	}
+	chunk := func(stream string) executor.StreamFunc {
+		return func(data []byte) {
+			emit(map[string]any{"stream": stream, "data": string(data), "ts": time.Now().UTC()})
+		}
+	}
+
+	started := time.Now().UTC()
+	result, err := sr.ExecuteStream(r.Context(), req, chunk("stdout"), chunk("stderr"))
+	if !req.DryRun {
+		s.recordExecution(ws.ID, execID, req.User, req.Command, started, result, err)
+	}
+	if err != nil {
+		emit(map[string]string{"error": err.Error()})
+		return
+	}
+	s.budgets.Charge(ws.ID, result.Duration)
+
+	if ws.Checkpoint.Enabled && ws.Checkpoint.AfterExec {
+		s.checkpoint(ws)
+	}
+
+	emit(map[string]any{"exit_code": result.ExitCode, "duration": result.Duration.String()})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/tar"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// uploadFile handles PUT /workspaces/{id}/files/{path}: the request
+// body is written into the running environment at path, binary-safe. A
+// body with Content-Type application/x-tar is instead extracted under
+// path, for multi-file copies. The optional mode query parameter sets
+// octal file permissions (default 0644).
+func (s *server) uploadFile(w http.ResponseWriter, r *http.Request, id, path string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status != registry.StatusReady {
+		writeError(w, http.StatusConflict, "workspace is not ready (status: "+ws.Status+")")
+		return
+	}
+	fw, ok := s.executor.(executor.FileWriter)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "executor cannot write files")
+		return
+	}
+	if path == "" {
+		writeError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+
+	mode := fs.FileMode(0o644)
+	if m := r.URL.Query().Get("mode"); m != "" {
+		parsed, err := strconv.ParseUint(m, 8, 32)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid mode: "+m)
+			return
+		}
+		mode = fs.FileMode(parsed)
+	}
+
+	req := executor.Request{
+		Worktree: ws.Worktree,
+		Pod:      provider.PodName(ws.Name),
+	}
+
+	if r.Header.Get("Content-Type") == "application/x-tar" {
+		written, err := s.extractTar(r, fw, req, path)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"written": written})
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read body: "+err.Error())
+		return
+	}
+	if err := fw.WriteFile(r.Context(), req, path, data, mode); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"written": []string{path}})
+}
+
+// downloadFile handles GET /workspaces/{id}/files/{path}: a file's
+// contents stream back raw; a directory streams back as a tarball. This
+// is how build artifacts and generated code leave a workspace without
+// being committed to git.
+func (s *server) downloadFile(w http.ResponseWriter, r *http.Request, id, path string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	fr, ok := s.executor.(executor.FileReader)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "executor cannot read files")
+		return
+	}
+	if path == "" {
+		writeError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	cleaned := filepath.Clean(path)
+	if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		writeError(w, http.StatusBadRequest, "path must be relative to the worktree")
+		return
+	}
+
+	// Directories tar up from the host-side worktree, which the
+	// environment shares through its mount.
+	if info, err := os.Stat(filepath.Join(ws.Worktree, cleaned)); err == nil && info.IsDir() {
+		s.streamDirTar(w, ws.Worktree, cleaned)
+		return
+	}
+
+	data, err := fr.ReadFile(r.Context(), executor.Request{
+		Worktree: ws.Worktree,
+		Pod:      provider.PodName(ws.Name),
+	}, cleaned)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data) //nolint:errcheck
+}
+
+// streamDirTar writes the directory as a tarball of its regular files,
+// with paths relative to the directory itself.
+func (s *server) streamDirTar(w http.ResponseWriter, worktree, dir string) {
+	w.Header().Set("Content-Type", "application/x-tar")
+	archive := tar.NewWriter(w)
+	defer archive.Close()
+
+	root := filepath.Join(worktree, dir)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error { //nolint:errcheck
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = archive.Write(data)
+		return err
+	})
+}
+
+// extractTar writes each regular file in the archive under dir,
+// preserving entry modes. Non-file entries (directories come implicitly
+// from file paths) are skipped.
+func (s *server) extractTar(r *http.Request, fw executor.FileWriter, req executor.Request, dir string) ([]string, error) {
+	var written []string
+	archive := tar.NewReader(r.Body)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return written, err
+		}
+		target := dir + "/" + header.Name
+		if err := fw.WriteFile(r.Context(), req, target, data, fs.FileMode(header.Mode)); err != nil {
+			return written, err
+		}
+		written = append(written, target)
+	}
+}
@@ -0,0 +1,1665 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+	"github.com/NicabarNimble/patina/modules/errdefs"
+	gitmanager "github.com/NicabarNimble/patina/modules/git-manager"
+)
+
+// server wires HTTP requests to the workspace modules.
+type server struct {
+	registry  *registry.Registry
+	provider  *provider.Provider
+	executor  executor.Runner
+	git       *gitmanager.Manager
+	templates *provider.Templates
+	// pool hands out pre-built environments for warm templates; nil when
+	// warming is disabled.
+	pool *provider.WarmPool
+	// standby is the rsync destination root for workspace replicas; empty
+	// when replication is disabled.
+	standby string
+	// budgets tracks per-workspace execution-time spend.
+	budgets *executor.BudgetTracker
+	// jobs tracks background processes; nil when the executor cannot
+	// stream output.
+	jobs *executor.Jobs
+	// links are the inter-workspace network links, keyed by link ID.
+	linksMu sync.Mutex
+	links   map[string]*link
+	// artifactStore is the object-store URL artifacts upload to; empty
+	// when artifacts are disabled.
+	artifactStore string
+	// artifacts is the per-workspace artifact metadata, keyed by
+	// workspace ID.
+	artifactsMu sync.Mutex
+	artifacts   map[string][]*artifact
+	// progress holds recent pipeline steps and live subscribers, keyed by
+	// workspace name (the label backends attach to their pipelines).
+	progressMu   sync.Mutex
+	progress     map[string][]progressEvent
+	progressSubs map[string]map[chan progressEvent]struct{}
+	// engines places environments across remote Dagger engines; nil runs
+	// everything on the local engine.
+	engines *provider.EngineScheduler
+	// cors controls cross-origin access for browser clients; disabled
+	// unless origins are configured.
+	cors corsConfig
+	// queue tracks in-flight creations for position reporting.
+	queue *createQueue
+	// execs tracks in-flight executions so they can be cancelled.
+	execs *execTracker
+	// history is the per-workspace execution audit log.
+	history *execHistory
+}
+
+// routes builds the gateway's HTTP mux.
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.HandleFunc("/schema/config", s.handleConfigSchema)
+	mux.HandleFunc("/workspaces", s.handleWorkspaces)
+	mux.HandleFunc("/workspaces/", s.handleWorkspace)
+	mux.HandleFunc("/admin/", s.handleAdmin)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/queue", s.handleQueue)
+	mux.HandleFunc("/engines", s.handleEngines)
+	mux.HandleFunc("/links", s.handleLinks)
+	mux.HandleFunc("/links/", s.handleLink)
+	// CORS sits outermost so preflights are answered before compression
+	// or handlers see the request.
+	return withCORS(withGzip(mux), s.cors)
+}
+
+// handleAdmin routes operator endpoints for runtime storage:
+// GET /admin/images, GET /admin/caches and POST /admin/prune. Backends
+// without an admin surface (e.g. kubernetes) report 501.
+func (s *server) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(r.URL.Path, "/admin/")
+
+	// State snapshots touch only the registry; handle them before
+	// requiring an admin surface on the container backend.
+	switch {
+	case action == "state" && r.Method == http.MethodGet:
+		s.exportState(w)
+		return
+	case action == "state" && r.Method == http.MethodPost:
+		s.importState(w, r)
+		return
+	case action == "stats" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.registry.Stats())
+		return
+	case action == "reconcile" && r.Method == http.MethodGet:
+		s.handleReconcile(w, false)
+		return
+	case action == "reconcile" && r.Method == http.MethodPost:
+		s.handleReconcile(w, true)
+		return
+	}
+
+	admin, ok := s.provider.Backend().(provider.AdminBackend)
+	if !ok {
+		writeError(w, http.StatusNotImplemented,
+			"backend "+s.provider.Backend().Name()+" has no admin surface")
+		return
+	}
+	switch {
+	case action == "images" && r.Method == http.MethodGet:
+		images, err := admin.Images(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, images)
+	case action == "caches" && r.Method == http.MethodGet:
+		volumes, err := admin.CacheVolumes(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, volumes)
+	case action == "prune" && r.Method == http.MethodPost:
+		report, err := admin.Prune(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"pruned": report})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleConfigSchema publishes the provider's Config JSON Schema, so
+// external tools (templates, .patina.yaml editors) can validate
+// environment configs before submission.
+func (s *server) handleConfigSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(provider.ConfigSchema()) //nolint:errcheck
+}
+
+// exportState handles GET /admin/state: the full workspace set as
+// JSON, for backups and migration between hosts.
+func (s *server) exportState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := s.registry.Export(w); err != nil {
+		log.Printf("state export: %v", err)
+	}
+}
+
+// importState handles POST /admin/state: merges an exported snapshot
+// into the live registry. Records land as-is — containers are not
+// recreated, so imported workspaces are resumed like paused ones.
+func (s *server) importState(w http.ResponseWriter, r *http.Request) {
+	added, err := s.registry.Import(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"imported": added})
+}
+
+// namespaceHeader scopes a request to one tenant's workspaces. Creates
+// stamp the namespace onto the workspace; listings and per-workspace
+// endpoints only see workspaces in it. Requests without the header are
+// unscoped.
+const namespaceHeader = "X-Patina-Namespace"
+
+// handleWorkspaces routes the collection endpoint: list and create.
+func (s *server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		filter, err := listFilter(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, s.registry.List(filter))
+	case http.MethodPost:
+		s.createWorkspace(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// listFilter builds a registry filter from the list query: repeatable
+// label=key=value pairs plus status and name-prefix, so clients like
+// "all ready workspaces for team=payments" filter server-side, and
+// sort/limit/offset so large fleets come back ordered and paged.
+func listFilter(r *http.Request) (registry.Filter, error) {
+	q := r.URL.Query()
+	filter := registry.Filter{
+		Status:     q.Get("status"),
+		NamePrefix: q.Get("name-prefix"),
+	}
+	filter.Namespace = r.Header.Get(namespaceHeader)
+	for _, pair := range q["label"] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[key] = value
+	}
+	switch sortBy := q.Get("sort"); sortBy {
+	case "", registry.SortCreatedAt, registry.SortName, registry.SortStatus:
+		filter.SortBy = sortBy
+	default:
+		return filter, fmt.Errorf("invalid sort %q (want created_at, name or status)", sortBy)
+	}
+	for param, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		raw := q.Get(param)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("invalid %s %q", param, raw)
+		}
+		*dst = n
+	}
+	return filter, nil
+}
+
+// handleWorkspace routes per-workspace endpoints:
+// GET/DELETE /workspaces/{id} and POST /workspaces/{id}/exec.
+// Workspaces can be addressed by ID or by name: /workspaces/by-name/{name}
+// resolves explicitly, and a bare segment that matches no ID falls back
+// to name lookup, since most callers know the name, not the generated ID.
+func (s *server) handleWorkspace(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/workspaces/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, "workspace ID required")
+		return
+	}
+
+	if id == "by-name" {
+		var name string
+		name, action, _ = strings.Cut(action, "/")
+		ws, ok := s.registry.GetByName(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return
+		}
+		id = ws.ID
+	} else if _, ok := s.registry.Get(id); !ok {
+		if ws, ok := s.registry.GetByName(id); ok {
+			id = ws.ID
+		}
+	}
+
+	// Tenant isolation: a request scoped to a namespace can't see
+	// workspaces outside it. Requests without the header are unscoped,
+	// which keeps single-tenant installations working untouched.
+	if ns := r.Header.Get(namespaceHeader); ns != "" {
+		if ws, ok := s.registry.Get(id); ok && ws.Namespace != ns {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return
+		}
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.getWorkspace(w, id)
+	case action == "" && r.Method == http.MethodDelete:
+		s.deleteWorkspace(w, r, id)
+	case action == "exec" && r.Method == http.MethodPost:
+		s.execInWorkspace(w, r, id)
+	case action == "exec" && r.Method == http.MethodGet:
+		s.listExecs(w, id)
+	case strings.HasPrefix(action, "exec/") && r.Method == http.MethodDelete:
+		s.cancelExec(w, id, strings.TrimPrefix(action, "exec/"))
+	case action == "executions" && r.Method == http.MethodGet:
+		s.listExecutions(w, r, id)
+	case action == "history" && r.Method == http.MethodGet:
+		s.workspaceHistory(w, id)
+	case action == "recordings" && r.Method == http.MethodGet:
+		s.listRecordings(w, id)
+	case strings.HasPrefix(action, "recordings/") && r.Method == http.MethodGet:
+		s.downloadRecording(w, r, id, strings.TrimPrefix(action, "recordings/"))
+	case action == "attach" && r.Method == http.MethodGet:
+		s.attachWorkspace(w, r, id)
+	case action == "push" && r.Method == http.MethodPost:
+		s.pushWorkspace(w, id)
+	case action == "pull" && r.Method == http.MethodPost:
+		s.pullWorkspace(w, r, id)
+	case action == "merge" && r.Method == http.MethodPost:
+		s.mergeWorkspace(w, r, id)
+	case action == "events" && r.Method == http.MethodGet:
+		s.streamEvents(w, r, id)
+	case action == "progress" && r.Method == http.MethodGet:
+		s.streamProgress(w, r, id)
+	case action == "rebase-plan" && r.Method == http.MethodGet:
+		s.planRebase(w, r, id)
+	case action == "rebase" && r.Method == http.MethodPost:
+		s.applyRebase(w, r, id)
+	case action == "git/diff" && r.Method == http.MethodGet:
+		s.diffWorkspace(w, r, id)
+	case action == "git/log" && r.Method == http.MethodGet:
+		s.logWorkspace(w, r, id)
+	case action == "git/status" && r.Method == http.MethodGet:
+		s.gitStatusWorkspace(w, id)
+	case action == "git/stash" && r.Method == http.MethodPost:
+		s.stashWorkspace(w, r, id)
+	case action == "git/stash/pop" && r.Method == http.MethodPost:
+		s.popStashWorkspace(w, id)
+	case action == "git/rebase" && r.Method == http.MethodPost:
+		s.rebaseOnto(w, r, id)
+	case action == "git/rebase/continue" && r.Method == http.MethodPost:
+		s.continueRebase(w, id)
+	case action == "git/rebase/abort" && r.Method == http.MethodPost:
+		s.abortRebase(w, id)
+	case action == "lock" && r.Method == http.MethodPost:
+		s.lockWorkspace(w, r, id)
+	case action == "lock" && r.Method == http.MethodDelete:
+		s.unlockWorkspace(w, r, id)
+	case action == "pause" && r.Method == http.MethodPost:
+		s.pauseWorkspace(w, id)
+	case action == "resume" && r.Method == http.MethodPost:
+		s.resumeWorkspace(w, id)
+	case action == "rebuild" && r.Method == http.MethodPost:
+		s.rebuildWorkspace(w, r, id)
+	case action == "replicate" && r.Method == http.MethodPost:
+		s.replicateWorkspace(w, id)
+	case action == "services" && r.Method == http.MethodGet:
+		s.listServices(w, id)
+	case strings.HasPrefix(action, "files/") && r.Method == http.MethodPut:
+		s.uploadFile(w, r, id, strings.TrimPrefix(action, "files/"))
+	case strings.HasPrefix(action, "files/") && r.Method == http.MethodGet:
+		s.downloadFile(w, r, id, strings.TrimPrefix(action, "files/"))
+	case action == "jobs" || strings.HasPrefix(action, "jobs/"):
+		s.handleJobs(w, r, id, strings.TrimPrefix(strings.TrimPrefix(action, "jobs"), "/"))
+	case action == "artifacts" || strings.HasPrefix(action, "artifacts/"):
+		s.handleArtifacts(w, r, id, strings.TrimPrefix(strings.TrimPrefix(action, "artifacts"), "/"))
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+type createRequest struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Template names an environment preset (e.g. "go-1.22") that
+	// supplies image, caches, env and setup defaults. Explicit fields in
+	// this request override the template.
+	Template string `json:"template,omitempty"`
+	// Session labels the development session this workspace belongs to;
+	// recorded as a Patina-Session trailer on workspace commits.
+	Session string `json:"session,omitempty"`
+	// Agent identifies the agent driving the workspace ("Name <email>");
+	// recorded as a Co-authored-by trailer on workspace commits.
+	Agent string `json:"agent,omitempty"`
+	// NixFlake builds the environment from the repo's flake.nix dev
+	// shell (usually "."), for teams standardizing on Nix instead of
+	// Dockerfiles. Execs run inside the dev shell.
+	NixFlake string `json:"nix_flake,omitempty"`
+	// Env sets environment variables in the workspace container.
+	Env map[string]string `json:"env,omitempty"`
+	// EnvFiles are .env-style host files whose variables are injected at
+	// exec time. Only the paths land in the workspace record, so
+	// secret-style values stay out of registry JSON.
+	EnvFiles []string `json:"env_files,omitempty"`
+	// Setup are shell commands run once after the environment is ready.
+	Setup []string `json:"setup,omitempty"`
+	// Packages declares system/toolchain packages to install, instead of
+	// writing the install commands by hand in Setup.
+	Packages provider.Packages `json:"packages,omitempty"`
+	// User, UID and GID run workspace commands as a non-root user,
+	// typically matching the host user so mounted files keep their
+	// ownership.
+	User string `json:"user,omitempty"`
+	UID  int    `json:"uid,omitempty"`
+	GID  int    `json:"gid,omitempty"`
+	// Ref pins the workspace to a commit SHA or tag instead of the
+	// current HEAD, for reproducing bugs at exact historical states.
+	Ref string `json:"ref,omitempty"`
+	// Detach checks Ref out detached instead of starting a new workspace
+	// branch at it. Detached workspaces cannot push.
+	Detach bool `json:"detach,omitempty"`
+	// Sparse restricts the worktree to these directories (git
+	// sparse-checkout), so monorepo workspaces only check out the paths
+	// they need. Cannot be combined with Ref.
+	Sparse []string `json:"sparse,omitempty"`
+	// FromWorkingCopy carries the main checkout's uncommitted changes
+	// over to the new workspace branch, handing in-progress local state
+	// to an isolated workspace.
+	FromWorkingCopy bool `json:"from_working_copy,omitempty"`
+	// Checkpoint enables automatic commits of workspace changes.
+	Checkpoint registry.CheckpointPolicy `json:"checkpoint,omitempty"`
+	// Caches maps named cache volumes to container mount paths.
+	Caches map[string]string `json:"caches,omitempty"`
+	// Mounts bind extra host directories into the workspace, each with a
+	// per-path mode: ro-mount, rw-mount or copy.
+	Mounts []registry.Mount `json:"mounts,omitempty"`
+	// Services are companion containers started with the workspace.
+	Services []registry.Service `json:"services,omitempty"`
+	// RegistryAuth holds per-registry credentials (host env/file
+	// references, never raw secrets) for pulling private images.
+	RegistryAuth []provider.RegistryAuth `json:"registry_auth,omitempty"`
+	// Proxy routes workspace traffic through a corporate proxy and
+	// installs its CA into the container trust store.
+	Proxy provider.Proxy `json:"proxy,omitempty"`
+	// Protected exempts the workspace from pressure-based eviction, for
+	// workspaces whose in-container state is too expensive to rebuild.
+	Protected bool `json:"protected,omitempty"`
+	// Labels tag the workspace with caller-defined metadata (team,
+	// ticket) that listings can filter on.
+	Labels map[string]string `json:"labels,omitempty"`
+	// TTLSeconds bounds the workspace's lifetime; the reaper tears it
+	// down once the TTL lapses (0 = never expires).
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// Standby keeps a paused replica of the workspace on the gateway's
+	// standby destination, so a host failure doesn't lose it.
+	Standby bool `json:"standby,omitempty"`
+	// BudgetSeconds caps the workspace's cumulative execution time per
+	// day, so autonomous agents can't burn unbounded compute.
+	BudgetSeconds int `json:"budget_seconds,omitempty"`
+	// Health probes the workspace periodically (command + interval +
+	// retries); a broken toolchain reports unhealthy instead of ready.
+	Health registry.HealthProbe `json:"health,omitempty"`
+}
+
+// providerServices maps workspace services onto the provider's type.
+// The modules keep independent types so neither imports the other.
+func providerServices(services []registry.Service) []provider.Service {
+	out := make([]provider.Service, len(services))
+	for i, svc := range services {
+		out[i] = provider.Service{Name: svc.Name, Image: svc.Image, Ports: svc.Ports, Env: svc.Env}
+	}
+	return out
+}
+
+// providerMounts maps workspace mounts onto the provider's type.
+func providerMounts(mounts []registry.Mount) []provider.Mount {
+	out := make([]provider.Mount, len(mounts))
+	for i, m := range mounts {
+		out[i] = provider.Mount{Source: m.Source, Target: m.Target, Mode: m.Mode}
+	}
+	return out
+}
+
+// registryMounts maps provider mounts back onto the workspace type,
+// so expanded mounts (e.g. a proxy CA bundle) land in the record.
+func registryMounts(mounts []provider.Mount) []registry.Mount {
+	out := make([]registry.Mount, len(mounts))
+	for i, m := range mounts {
+		out[i] = registry.Mount{Source: m.Source, Target: m.Target, Mode: m.Mode}
+	}
+	return out
+}
+
+// executorMounts maps workspace mounts onto the executor's type.
+func executorMounts(mounts []registry.Mount) []executor.Mount {
+	out := make([]executor.Mount, len(mounts))
+	for i, m := range mounts {
+		out[i] = executor.Mount{Source: m.Source, Target: m.Target, Mode: m.Mode}
+	}
+	return out
+}
+
+// providerHealth maps the workspace health probe onto the provider's type.
+func providerHealth(h registry.HealthProbe) provider.HealthProbe {
+	return provider.HealthProbe{Command: h.Command, IntervalSeconds: h.IntervalSeconds, Retries: h.Retries}
+}
+
+// executorServices maps workspace services onto the executor's type.
+func executorServices(services []registry.Service) []executor.Service {
+	out := make([]executor.Service, len(services))
+	for i, svc := range services {
+		out[i] = executor.Service{Name: svc.Name, Image: svc.Image, Ports: svc.Ports, Env: svc.Env}
+	}
+	return out
+}
+
+func (s *server) createWorkspace(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if req.Standby && s.standby == "" {
+		writeError(w, http.StatusBadRequest, "standby requested but no -standby-dest configured")
+		return
+	}
+	if req.Detach && req.Ref == "" {
+		writeError(w, http.StatusBadRequest, "detach requires a ref")
+		return
+	}
+	if len(req.Sparse) > 0 && req.Ref != "" {
+		writeError(w, http.StatusBadRequest, "sparse cannot be combined with ref")
+		return
+	}
+
+	cfg := provider.Config{
+		Image:    req.Image,
+		NixFlake: req.NixFlake,
+		Caches:   req.Caches,
+		Mounts:   providerMounts(req.Mounts),
+		Env:      req.Env,
+		EnvFiles: req.EnvFiles,
+		Setup:    req.Setup,
+		Packages: req.Packages,
+		Proxy:    req.Proxy,
+		Health:   providerHealth(req.Health),
+	}
+	if req.Template != "" {
+		tpl, err := s.templates.Resolve(req.Template)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		cfg = tpl.Apply(cfg)
+	}
+	// LFS repos need the client inside the container too, or in-container
+	// git operations regress tracked files to pointer stubs. Pick the
+	// installer matching the base image (an empty image defaults to
+	// Ubuntu below).
+	if s.git.UsesLFS() {
+		if strings.Contains(cfg.Image, "alpine") {
+			cfg.Packages.Apk = append(cfg.Packages.Apk, "git-lfs")
+		} else {
+			cfg.Packages.Apt = append(cfg.Packages.Apt, "git-lfs")
+		}
+	}
+	// Expand flake, package and proxy configs here so the workspace
+	// records the effective image, env, setup and mounts; Create's own
+	// expansion then no-ops.
+	cfg = provider.ApplyNixFlake(cfg)
+	cfg = provider.ApplyPackages(cfg)
+	cfg = provider.ApplyProxy(cfg)
+	if cfg.Image == "" {
+		cfg.Image = provider.DefaultImage
+	}
+
+	// A dry run validates the effective config (template applied) and
+	// stops: no worktree, no registration, no container.
+	if r.URL.Query().Get("dry_run") == "true" {
+		cfg.Name = req.Name
+		if errs := s.provider.Validate(r.Context(), cfg); len(errs) > 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"valid": false, "errors": errs})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"valid": true})
+		return
+	}
+
+	// Policy failures surface here as 403; the async create would only
+	// flip the workspace to error where nobody sees why.
+	if err := s.provider.CheckImage(cfg.Image); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	// Snapshot the main checkout before creating the worktree, so the
+	// workspace starts from exactly the state the developer handed off.
+	var snapshot string
+	if req.FromWorkingCopy {
+		sha, err := s.git.Snapshot()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		snapshot = sha
+	}
+
+	var worktree, branch string
+	var err error
+	if len(req.Sparse) > 0 {
+		worktree, branch, err = s.git.CreateWorktreeSparse(req.Name, req.Sparse)
+	} else {
+		worktree, branch, err = s.git.CreateWorktreeAt(req.Name, req.Ref, req.Detach)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if snapshot != "" {
+		if err := s.git.ApplySnapshot(req.Name, snapshot); err != nil {
+			if cleanupErr := s.git.RemoveWorktree(req.Name); cleanupErr != nil {
+				log.Printf("workspace %s: worktree cleanup failed: %v", req.Name, cleanupErr)
+			}
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	ws := &registry.Workspace{
+		ID:            newWorkspaceID(),
+		Name:          req.Name,
+		Repo:          s.git.RepoPath,
+		Branch:        branch,
+		Worktree:      worktree,
+		Image:         cfg.Image,
+		Status:        registry.StatusCreating,
+		Engine:        s.assignEngine(req.Name),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Checkpoint:    req.Checkpoint,
+		Caches:        cfg.Caches,
+		Mounts:        registryMounts(cfg.Mounts),
+		Services:      req.Services,
+		Template:      req.Template,
+		Session:       req.Session,
+		Agent:         req.Agent,
+		NixFlake:      req.NixFlake,
+		Env:           cfg.Env,
+		EnvFiles:      req.EnvFiles,
+		Setup:         cfg.Setup,
+		User:          req.User,
+		UID:           req.UID,
+		GID:           req.GID,
+		Protected:     req.Protected,
+		Labels:        req.Labels,
+		TTLSeconds:    req.TTLSeconds,
+		Namespace:     r.Header.Get(namespaceHeader),
+		Standby:       req.Standby,
+		BudgetSeconds: req.BudgetSeconds,
+		Health:        req.Health,
+	}
+	if err := s.registry.Register(ws); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	// Container warm-up runs in the background; clients poll status.
+	position := s.queue.enqueue(ws.ID, ws.Name)
+	go s.initializeContainer(ws, req.RegistryAuth)
+
+	if ws.Checkpoint.Enabled && ws.Checkpoint.IntervalSeconds > 0 {
+		go s.runCheckpointTimer(ws.ID)
+	}
+	if ws.Standby {
+		go s.runStandbyTimer(ws.ID)
+	}
+	if len(ws.Health.Command) > 0 {
+		go s.runHealthTimer(ws.ID)
+	}
+
+	// A creation queued behind the concurrency limit reports 202 with
+	// its position and estimated wait, so clients display progress
+	// instead of blind-retrying.
+	if s.queue.waiting(position) {
+		writeJSON(w, http.StatusAccepted, map[string]any{
+			"workspace":      ws,
+			"queue_position": position,
+			"estimated_wait": s.queue.estimate(position).String(),
+		})
+		return
+	}
+	writeJSON(w, http.StatusCreated, ws)
+}
+
+// initializeContainer warms the workspace's environment and flips its
+// status to ready (or error). Status writes go through the registry,
+// which owns the record and handles locking and UpdatedAt.
+func (s *server) initializeContainer(ws *registry.Workspace, auth []provider.RegistryAuth) {
+	start := time.Now()
+	defer func() { s.queue.finish(ws.ID, time.Since(start)) }()
+
+	// A pool hit means the runtime already holds this template's image;
+	// skip creation and go straight to setup. Lifecycle backends still
+	// need their per-workspace runtime, so they always create.
+	_, lifecycle := s.provider.Backend().(provider.LifecycleBackend)
+	if s.pool != nil && !lifecycle && ws.Template != "" {
+		if env := s.pool.Take(ws.Template); env != nil {
+			status := registry.StatusReady
+			if err := s.runSetup(ws); err != nil {
+				log.Printf("workspace %s: %v", ws.ID, err)
+				status = registry.StatusError
+			}
+			status = s.healthStatus(ws, status)
+			if err := s.registry.UpdateStatus(ws.ID, status); err != nil {
+				log.Printf("workspace %s: status update skipped: %v", ws.ID, err)
+			}
+			return
+		}
+	}
+
+	_, err := s.provider.Create(context.Background(), provider.Config{
+		Name:         ws.Name,
+		Image:        ws.Image,
+		Engine:       ws.Engine,
+		NixFlake:     ws.NixFlake,
+		Caches:       ws.Caches,
+		Mounts:       providerMounts(ws.Mounts),
+		Services:     providerServices(ws.Services),
+		RegistryAuth: auth,
+		Env:          ws.Env,
+		EnvFiles:     ws.EnvFiles,
+		Setup:        ws.Setup,
+		User:         ws.User,
+		UID:          ws.UID,
+		GID:          ws.GID,
+		Health:       providerHealth(ws.Health),
+	})
+	status := registry.StatusReady
+	if err != nil {
+		log.Printf("workspace %s: environment creation failed: %v", ws.ID, err)
+		status = registry.StatusError
+	} else if err := s.runSetup(ws); err != nil {
+		log.Printf("workspace %s: %v", ws.ID, err)
+		status = registry.StatusError
+	}
+	status = s.healthStatus(ws, status)
+	if err := s.registry.UpdateStatus(ws.ID, status); err != nil {
+		// Workspace was deleted while initializing; nothing to update.
+		log.Printf("workspace %s: status update skipped: %v", ws.ID, err)
+	}
+}
+
+// assignEngine places the workspace on a Dagger engine, or nowhere when
+// no engine fleet is configured.
+func (s *server) assignEngine(name string) string {
+	if s.engines == nil {
+		return ""
+	}
+	return s.engines.Assign(name)
+}
+
+// handleEngines reports per-engine placement counts, so operators can
+// see how the fleet is loaded.
+func (s *server) handleEngines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.engines == nil {
+		writeError(w, http.StatusNotImplemented, "no engine fleet configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.engines.Loads())
+}
+
+// userConfig rebuilds the provider's user config from the workspace
+// record, for the user helpers.
+func userConfig(ws *registry.Workspace) provider.Config {
+	return provider.Config{User: ws.User, UID: ws.UID, GID: ws.GID}
+}
+
+// workspaceEnv resolves the workspace's effective env: env-file values
+// re-read from the host (the record holds only paths) with the explicit
+// Env entries overlaid on top.
+func workspaceEnv(ws *registry.Workspace) (map[string]string, error) {
+	if len(ws.EnvFiles) == 0 {
+		return ws.Env, nil
+	}
+	env, err := provider.ReadEnvFiles(ws.EnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range ws.Env {
+		env[name] = value
+	}
+	return env, nil
+}
+
+// runSetup executes the workspace's setup commands in order. Commands
+// run through the shell so templates can use pipes and &&. Setup runs
+// as root so it can install tools and create the workspace user.
+func (s *server) runSetup(ws *registry.Workspace) error {
+	env, err := workspaceEnv(ws)
+	if err != nil {
+		return err
+	}
+	setup := ws.Setup
+	if ensure := userConfig(ws).EnsureUserCommand(); ensure != "" {
+		setup = append([]string{ensure}, setup...)
+	}
+	for _, command := range setup {
+		result, err := s.executor.Execute(context.Background(), executor.Request{
+			Image:    ws.Image,
+			Worktree: ws.Worktree,
+			Command:  []string{"sh", "-c", command},
+			Pod:      provider.PodName(ws.Name),
+			Caches:   ws.Caches,
+			Mounts:   executorMounts(ws.Mounts),
+			Services: executorServices(ws.Services),
+			Env:      env,
+			Engine:   ws.Engine,
+		})
+		if err != nil {
+			return fmt.Errorf("setup %q: %w", command, err)
+		}
+		if result.ExitCode != 0 {
+			return fmt.Errorf("setup %q: exit %d: %s", command, result.ExitCode, strings.TrimSpace(result.Stderr))
+		}
+	}
+	return nil
+}
+
+func (s *server) getWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, ws)
+}
+
+func (s *server) deleteWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		// Idempotent: deleting an already-gone workspace is a success.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := checkLock(ws, r.URL.Query().Get("holder")); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	if err := s.registry.UpdateStatus(id, registry.StatusDeleting); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	if err := s.git.RemoveWorktree(ws.Name); err != nil {
+		log.Printf("workspace %s: worktree cleanup failed: %v", ws.ID, err)
+	}
+	if err := s.provider.Destroy(r.Context(), ws.Name); err != nil {
+		log.Printf("workspace %s: environment destroy failed: %v", ws.ID, err)
+	}
+	if s.engines != nil {
+		s.engines.Release(ws.Name)
+	}
+	s.history.drop(ws.ID)
+	if err := s.registry.Deregister(id); err != nil {
+		if errdefs.IsNotFound(err) {
+			// A concurrent delete won the race; same outcome.
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+}
+
+// statusClientClosedRequest is nginx's non-standard code for a caller
+// that went away (or cancelled) before the response was ready. There is
+// no standard equivalent, and 500 wrongly blames the server.
+const statusClientClosedRequest = 499
+
+// statusForError maps module error categories onto HTTP statuses.
+func statusForError(err error) int {
+	switch {
+	case errdefs.IsNotFound(err):
+		return http.StatusNotFound
+	case errdefs.IsConflict(err):
+		return http.StatusConflict
+	case errdefs.IsForbidden(err):
+		return http.StatusForbidden
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusRequestTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type execRequest struct {
+	Command []string `json:"command"`
+	// Stdin is fed to the command's standard input, base64-encoded, so
+	// piped workflows like `psql < dump.sql` work over the API.
+	Stdin []byte `json:"stdin,omitempty"`
+	// StdinText feeds inline text to the command's standard input — a
+	// here-doc convenience over the base64 Stdin field for manifests and
+	// small scripts.
+	StdinText string `json:"stdin_text,omitempty"`
+	// StdinFile feeds a worktree-relative file to the command's standard
+	// input, for imports too large to inline in the request body.
+	StdinFile string `json:"stdin_file,omitempty"`
+	// Workdir overrides the default project working directory.
+	Workdir string `json:"workdir,omitempty"`
+	// Record persists the run transcript under .patina/runs/ and commits
+	// it to the workspace branch, so a PR carries a reviewable record of
+	// the commands that produced its changes.
+	Record bool `json:"record,omitempty"`
+	// User overrides the workspace user for this command ("root" for
+	// package installs, "uid:gid" or a name otherwise). Empty runs as
+	// the workspace's configured user.
+	User string `json:"user,omitempty"`
+	// Retry re-runs the command on matching non-zero exits, for flaky
+	// network-dependent work like package installs.
+	Retry executor.Retry `json:"retry,omitempty"`
+	// MaxOutputBytes caps how much stdout and stderr the response
+	// carries (each); zero applies the executor default, negative lifts
+	// the cap. Truncation is reported on the result.
+	MaxOutputBytes int `json:"max_output_bytes,omitempty"`
+	// Holder identifies the caller against the workspace's lock. Execs
+	// from anyone else bounce while a live lock is held.
+	Holder string `json:"holder,omitempty"`
+	// Combined additionally returns stdout and stderr as one
+	// time-ordered line stream on the result.
+	Combined bool `json:"combined,omitempty"`
+	// Confirmed acknowledges a command on the policy's confirm list.
+	Confirmed bool `json:"confirmed,omitempty"`
+	// Artifacts lists worktree-relative globs (e.g. target/debug/*)
+	// collected into the artifact store after the command completes.
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+func (s *server) execInWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status != registry.StatusReady {
+		writeError(w, http.StatusConflict, "workspace is not ready (status: "+ws.Status+")")
+		return
+	}
+	s.registry.Touch(ws.ID)
+
+	var req execRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if len(req.Command) == 0 {
+		writeError(w, http.StatusBadRequest, "command is required")
+		return
+	}
+	stdin, err := resolveStdin(ws, req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkLock(ws, req.Holder); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	// Budget failures report 429: the workspace isn't broken, it has
+	// spent its execution time for the window.
+	budget := time.Duration(ws.BudgetSeconds) * time.Second
+	if err := s.budgets.Check(ws.ID, budget); err != nil {
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	user := userConfig(ws).UserSpec()
+	if req.User != "" {
+		user = req.User
+	}
+
+	// Flake workspaces run commands inside the dev shell, where the
+	// flake's toolchain is on PATH.
+	command := req.Command
+	if ws.NixFlake != "" {
+		command = provider.NixCommand(ws.NixFlake, command)
+	}
+
+	env, err := workspaceEnv(ws)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Approved links surface as extra env vars pointing at the peer.
+	if linked := s.linkEnv(r.Context(), ws); len(linked) > 0 {
+		merged := make(map[string]string, len(env)+len(linked))
+		for k, v := range env {
+			merged[k] = v
+		}
+		for k, v := range linked {
+			merged[k] = v
+		}
+		env = merged
+	}
+
+	execReq := executor.Request{
+		Image:          ws.Image,
+		Worktree:       ws.Worktree,
+		Workdir:        req.Workdir,
+		Command:        command,
+		Stdin:          stdin,
+		Pod:            provider.PodName(ws.Name),
+		Caches:         ws.Caches,
+		Mounts:         executorMounts(ws.Mounts),
+		Services:       executorServices(ws.Services),
+		Env:            env,
+		User:           user,
+		Engine:         ws.Engine,
+		Retry:          req.Retry,
+		MaxOutputBytes: req.MaxOutputBytes,
+		Combined:       req.Combined,
+		Confirmed:      req.Confirmed,
+		// A dry run reports what would execute without running it; the
+		// executor still applies the command policy, so it doubles as a
+		// policy check.
+		DryRun: r.URL.Query().Get("dry_run") == "true",
+	}
+
+	// Track the execution so DELETE /exec/{execID} can cancel it;
+	// cancelling the context kills the runtime process.
+	ctx, execID, finish := s.execs.begin(r.Context(), ws.ID, req.Command)
+	defer finish()
+	w.Header().Set("X-Patina-Exec-Id", execID)
+	r = r.WithContext(ctx)
+
+	// Streaming clients get output as SSE chunks instead of one
+	// buffered response at the end; Accept: application/x-ndjson picks
+	// newline-delimited JSON for clients without an SSE parser.
+	if r.Header.Get("Accept") == "application/x-ndjson" {
+		s.streamExecNDJSON(w, r, ws, execReq, execID)
+		return
+	}
+	if r.URL.Query().Get("stream") == "true" {
+		s.streamExec(w, r, ws, execReq, execID)
+		return
+	}
+
+	started := time.Now().UTC()
+	result, err := s.executor.Execute(r.Context(), execReq)
+	if !execReq.DryRun {
+		s.recordExecution(ws.ID, execID, execReq.User, execReq.Command, started, result, err)
+	}
+	if err != nil {
+		// Policy denials surface as 403 via errdefs, not a blanket 500.
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	s.budgets.Charge(ws.ID, result.Duration)
+
+	if req.Record {
+		if err := s.recordRun(ws, req.Command, result); err != nil {
+			log.Printf("workspace %s: run transcript failed: %v", ws.ID, err)
+		}
+	}
+
+	if ws.Checkpoint.Enabled && ws.Checkpoint.AfterExec {
+		s.checkpoint(ws)
+	}
+
+	// Declared artifacts are collected after the run, CI-style, and
+	// returned alongside the result.
+	if len(req.Artifacts) > 0 {
+		captured, err := s.captureArtifacts(ws, req.Artifacts)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, struct {
+			*executor.Result
+			Artifacts []*artifact `json:"artifacts"`
+		}{result, captured})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// runTranscript is the persisted record of one execution.
+type runTranscript struct {
+	Command  []string  `json:"command"`
+	Stdout   string    `json:"stdout"`
+	Stderr   string    `json:"stderr"`
+	ExitCode int       `json:"exit_code"`
+	Duration string    `json:"duration"`
+	RanAt    time.Time `json:"ran_at"`
+}
+
+// recordRun writes the execution transcript under .patina/runs/ in the
+// worktree and commits it. The commit stages everything outstanding, so
+// the transcript lands alongside the changes the run produced.
+func (s *server) recordRun(ws *registry.Workspace, command []string, result *executor.Result) error {
+	ranAt := time.Now().UTC()
+	transcript, err := json.MarshalIndent(runTranscript{
+		Command:  command,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+		Duration: result.Duration.String(),
+		RanAt:    ranAt,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("render transcript: %w", err)
+	}
+
+	dir := filepath.Join(ws.Worktree, ".patina", "runs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create runs directory: %w", err)
+	}
+	name := ranAt.Format("20060102T150405.000Z") + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), transcript, 0o644); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+
+	return s.git.Commit(ws.Name, "run: "+strings.Join(command, " "), workspaceTrailers(ws)...)
+}
+
+// runCheckpointTimer commits outstanding workspace changes on the
+// policy's interval until the workspace is deregistered.
+func (s *server) runCheckpointTimer(id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(ws.Checkpoint.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ws, ok := s.registry.Get(id)
+		if !ok {
+			return
+		}
+		s.checkpoint(ws)
+	}
+}
+
+// checkpoint commits outstanding changes using the policy's template.
+func (s *server) checkpoint(ws *registry.Workspace) {
+	committed, err := s.git.Checkpoint(ws.Name, checkpointMessage(ws), workspaceTrailers(ws)...)
+	if err != nil {
+		log.Printf("workspace %s: checkpoint failed: %v", ws.ID, err)
+		return
+	}
+	if committed {
+		log.Printf("workspace %s: checkpoint committed", ws.ID)
+	}
+}
+
+// resolveStdin picks the exec request's stdin source: raw bytes, inline
+// text, or a worktree-relative file. At most one may be set.
+func resolveStdin(ws *registry.Workspace, req execRequest) ([]byte, error) {
+	sources := 0
+	for _, set := range []bool{len(req.Stdin) > 0, req.StdinText != "", req.StdinFile != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("stdin, stdin_text and stdin_file are mutually exclusive")
+	}
+
+	switch {
+	case len(req.Stdin) > 0:
+		return req.Stdin, nil
+	case req.StdinText != "":
+		return []byte(req.StdinText), nil
+	case req.StdinFile != "":
+		// Like artifacts, stdin files must stay inside the worktree.
+		cleaned := filepath.Clean(req.StdinFile)
+		if filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+			return nil, fmt.Errorf("stdin_file must be relative to the worktree")
+		}
+		data, err := os.ReadFile(filepath.Join(ws.Worktree, cleaned))
+		if err != nil {
+			return nil, fmt.Errorf("read stdin_file: %w", err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// workspaceTrailers builds the provenance trailers stamped on every
+// commit the gateway makes, so workspace-generated commits stay
+// attributable after they land in the main repository history.
+func workspaceTrailers(ws *registry.Workspace) []gitmanager.Trailer {
+	trailers := []gitmanager.Trailer{{Key: "Workspace-Id", Value: ws.ID}}
+	if ws.Session != "" {
+		trailers = append(trailers, gitmanager.Trailer{Key: "Patina-Session", Value: ws.Session})
+	}
+	if ws.Agent != "" {
+		trailers = append(trailers, gitmanager.Trailer{Key: "Co-authored-by", Value: ws.Agent})
+	}
+	return trailers
+}
+
+// checkpointMessage renders the policy's message template, substituting
+// {workspace} and {time}.
+func checkpointMessage(ws *registry.Workspace) string {
+	template := ws.Checkpoint.MessageTemplate
+	if template == "" {
+		template = "checkpoint: {workspace} at {time}"
+	}
+	msg := strings.ReplaceAll(template, "{workspace}", ws.Name)
+	return strings.ReplaceAll(msg, "{time}", time.Now().UTC().Format(time.RFC3339))
+}
+
+// workspaceHistory handles GET /workspaces/{id}/history: the registry's
+// audit trail of status transitions and metadata changes, oldest first.
+// Unlike the git-notes log this is queryable without repo access.
+func (s *server) workspaceHistory(w http.ResponseWriter, id string) {
+	events, err := s.registry.History(id)
+	if err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// streamEvents streams worktree change events for the workspace as
+// server-sent events. Each subscriber gets its own watcher; the stream
+// ends when the client disconnects or the worktree disappears.
+func (s *server) streamEvents(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	events, err := s.git.Watch(ws.Name, stop)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// listServices returns the resolved endpoints of the workspace's
+// sidecar services, so tooling outside the container can discover where
+// the workspace's database (or cache, or queue) answers.
+func (s *server) listServices(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	endpoints := s.provider.ServiceEndpoints(providerServices(ws.Services))
+	if endpoints == nil {
+		endpoints = []provider.ServiceEndpoint{}
+	}
+	writeJSON(w, http.StatusOK, endpoints)
+}
+
+// pushWorkspace pushes the workspace branch to origin and returns the
+// push details so clients can link to the pushed branch.
+func (s *server) pushWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	result, err := s.git.Push(ws.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// pullWorkspace handles POST /workspaces/{id}/pull: update the
+// workspace branch from its upstream, fast-forward by default or
+// rebasing local commits when the body asks for it.
+func (s *server) pullWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	var req struct {
+		Mode   string `json:"mode,omitempty"`
+		Remote string `json:"remote,omitempty"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	if err := s.git.Fetch(ws.Name, req.Remote); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := s.git.Pull(ws.Name, req.Mode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "unknown pull mode") {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// mergeWorkspace handles POST /workspaces/{id}/merge: land the
+// workspace branch on its base, so finished work doesn't need a
+// separate tool (or a human at the main checkout) to come home.
+func (s *server) mergeWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	var req struct {
+		Base string `json:"base,omitempty"`
+		Mode string `json:"mode,omitempty"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	result, err := s.git.MergeInto(ws.Name, req.Base, req.Mode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "unknown merge mode") {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// planRebase returns the workspace branch's commits since base as an
+// editable rebase plan.
+func (s *server) planRebase(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	// An omitted base means the repository's default branch, detected
+	// from origin/HEAD so master and trunk repos get the right base.
+	base := r.URL.Query().Get("base")
+	if base == "" {
+		detected, err := s.git.DefaultBranch()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		base = detected
+	}
+
+	steps, err := s.git.PlanRebase(ws.Name, base)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, steps)
+}
+
+type rebaseRequest struct {
+	// Base is the branch to rebase onto. Empty means the repository's
+	// default branch.
+	Base  string                  `json:"base"`
+	Steps []gitmanager.RebaseStep `json:"steps"`
+}
+
+// applyRebase executes an edited rebase plan against the workspace branch.
+func (s *server) applyRebase(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	var req rebaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.Base == "" {
+		detected, err := s.git.DefaultBranch()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		req.Base = detected
+	}
+	if len(req.Steps) == 0 {
+		writeError(w, http.StatusBadRequest, "steps are required")
+		return
+	}
+
+	if err := s.git.ApplyRebase(ws.Name, req.Base, req.Steps); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"rebased": id})
+}
+
+// diffWorkspace handles GET /workspaces/{id}/git/diff: the workspace's
+// changes since it diverged from base (?base=, default branch when
+// omitted) as a patch plus per-file counts, so reviewing a workspace
+// doesn't require exec'ing raw git inside it.
+func (s *server) diffWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	result, err := s.git.Diff(ws.Name, r.URL.Query().Get("base"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// logWorkspace handles GET /workspaces/{id}/git/log: the workspace
+// branch's commits, newest first. ?base= limits it to the workspace's
+// own work since divergence; ?limit= caps the count.
+func (s *server) logWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	opts := gitmanager.LogOptions{Base: r.URL.Query().Get("base")}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit "+strconv.Quote(raw))
+			return
+		}
+		opts.Limit = limit
+	}
+
+	commits, err := s.git.Log(ws.Name, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, commits)
+}
+
+// gitStatusWorkspace handles GET /workspaces/{id}/git/status: the
+// worktree's uncommitted changes plus its stash entries, so a client
+// can tell whether work would block a rebase and where set-aside work
+// went.
+func (s *server) gitStatusWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	status, err := s.git.Status(ws.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stashes, err := s.git.StashList(ws.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var changes []string
+	if status != "" {
+		changes = strings.Split(status, "\n")
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Dirty   bool                    `json:"dirty"`
+		Changes []string                `json:"changes,omitempty"`
+		Stashes []gitmanager.StashEntry `json:"stashes,omitempty"`
+	}{
+		Dirty:   len(changes) > 0,
+		Changes: changes,
+		Stashes: stashes,
+	})
+}
+
+// stashWorkspace handles POST /workspaces/{id}/git/stash: set the
+// worktree's uncommitted changes aside.
+func (s *server) stashWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	var req struct {
+		Message string `json:"message,omitempty"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	stashed, err := s.git.Stash(ws.Name, req.Message)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"stashed": stashed})
+}
+
+// popStashWorkspace handles POST /workspaces/{id}/git/stash/pop.
+func (s *server) popStashWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err := s.git.StashPop(ws.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"popped": id})
+}
+
+// rebaseOnto handles POST /workspaces/{id}/git/rebase: replay the
+// workspace branch onto a base ref. Unlike the plan-driven /rebase,
+// conflicts stop in place and come back in the response; the caller
+// resolves them and hits /git/rebase/continue, or /git/rebase/abort.
+func (s *server) rebaseOnto(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	var req struct {
+		Onto string `json:"onto,omitempty"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	result, err := s.git.Rebase(ws.Name, req.Onto)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if !result.Completed {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, result)
+}
+
+// continueRebase handles POST /workspaces/{id}/git/rebase/continue.
+func (s *server) continueRebase(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	result, err := s.git.RebaseContinue(ws.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if !result.Completed {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, result)
+}
+
+// abortRebase handles POST /workspaces/{id}/git/rebase/abort.
+func (s *server) abortRebase(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err := s.git.RebaseAbort(ws.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"aborted": id})
+}
+
+// newWorkspaceID returns a short random workspace ID.
+func newWorkspaceID() string {
+	buf := make([]byte, 4)
+	rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+	return "ws-" + hex.EncodeToString(buf)
+}
+
+// writeJSON writes a JSON response with the given status, streaming v
+// through an encoder so large list and log payloads never sit fully
+// marshaled in memory.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("encode response: %v", err)
+	}
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// healthStatus runs the workspace's health probe once, if one is
+// configured, downgrading ready to unhealthy when the toolchain check
+// fails. A workspace whose container resolved but whose compiler is
+// broken should not report ready.
+func (s *server) healthStatus(ws *registry.Workspace, status string) string {
+	if status != registry.StatusReady || len(ws.Health.Command) == 0 {
+		return status
+	}
+	if !s.probeHealth(ws) {
+		log.Printf("workspace %s: health probe failed on creation", ws.ID)
+		return registry.StatusUnhealthy
+	}
+	return status
+}
+
+// runHealthTimer re-runs the workspace's health probe on its interval
+// until the workspace is deregistered, flipping status between ready
+// and unhealthy. A single success recovers; it takes the probe's retry
+// count of consecutive failures to go unhealthy, so one flaky run
+// doesn't flap the status.
+func (s *server) runHealthTimer(id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok || len(ws.Health.Command) == 0 {
+		return
+	}
+	probe := providerHealth(ws.Health)
+	ticker := time.NewTicker(time.Duration(probe.Interval()) * time.Second)
+	defer ticker.Stop()
+
+	failures := 0
+	for range ticker.C {
+		ws, ok := s.registry.Get(id)
+		if !ok {
+			return
+		}
+		// Only probe running workspaces; creating, error and deleting
+		// states fail for reasons that aren't the toolchain's fault.
+		if ws.Status != registry.StatusReady && ws.Status != registry.StatusUnhealthy {
+			continue
+		}
+
+		if s.probeHealth(ws) {
+			failures = 0
+			if ws.Status == registry.StatusUnhealthy {
+				s.setHealthStatus(ws, registry.StatusReady)
+			}
+			continue
+		}
+		failures++
+		if failures >= probe.MaxRetries() && ws.Status == registry.StatusReady {
+			s.setHealthStatus(ws, registry.StatusUnhealthy)
+		}
+	}
+}
+
+// probeHealth runs the probe command once the way execs run: as the
+// workspace user, inside the flake's dev shell when one is configured.
+func (s *server) probeHealth(ws *registry.Workspace) bool {
+	command := ws.Health.Command
+	if ws.NixFlake != "" {
+		command = provider.NixCommand(ws.NixFlake, command)
+	}
+	env, err := workspaceEnv(ws)
+	if err != nil {
+		log.Printf("workspace %s: health probe env: %v", ws.ID, err)
+		return false
+	}
+	result, err := s.executor.Execute(context.Background(), executor.Request{
+		Image:    ws.Image,
+		Worktree: ws.Worktree,
+		Command:  command,
+		Pod:      provider.PodName(ws.Name),
+		Caches:   ws.Caches,
+		Mounts:   executorMounts(ws.Mounts),
+		Services: executorServices(ws.Services),
+		Env:      env,
+		User:     userConfig(ws).UserSpec(),
+		Engine:   ws.Engine,
+	})
+	if err != nil {
+		log.Printf("workspace %s: health probe error: %v", ws.ID, err)
+		return false
+	}
+	return result.ExitCode == 0
+}
+
+// setHealthStatus records a probe-driven status change.
+func (s *server) setHealthStatus(ws *registry.Workspace, status string) {
+	if err := s.registry.UpdateStatus(ws.ID, status); err != nil {
+		log.Printf("workspace %s: status update skipped: %v", ws.ID, err)
+		return
+	}
+	log.Printf("workspace %s: health probe marked %s", ws.ID, status)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+)
+
+// historyLimit bounds how many executions each workspace retains.
+// Oldest entries fall off first; the history is an audit aid, not an
+// unbounded log store.
+const historyLimit = 200
+
+// executionRecord is one audited execution: what ran, as whom, when,
+// and how it ended. OutputHash fingerprints the buffered (possibly
+// truncated) stdout and stderr so transcripts can be matched without
+// storing the output itself.
+type executionRecord struct {
+	ID         string    `json:"id"`
+	Command    []string  `json:"command"`
+	User       string    `json:"user,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	ExitCode   int       `json:"exit_code"`
+	Error      string    `json:"error,omitempty"`
+	Truncated  bool      `json:"truncated,omitempty"`
+	OutputHash string    `json:"output_hash,omitempty"`
+}
+
+// execHistory keeps the per-workspace execution audit log, keyed by
+// workspace ID. Every exec lands here regardless of the per-request
+// Record flag, which only controls worktree transcripts.
+type execHistory struct {
+	mu      sync.Mutex
+	records map[string][]executionRecord
+}
+
+func newExecHistory() *execHistory {
+	return &execHistory{records: make(map[string][]executionRecord)}
+}
+
+// add appends one record, trimming the oldest past the limit.
+func (h *execHistory) add(workspaceID string, rec executionRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := append(h.records[workspaceID], rec)
+	if len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+	h.records[workspaceID] = records
+}
+
+// list returns a copy of the workspace's records, oldest first.
+func (h *execHistory) list(workspaceID string) []executionRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := h.records[workspaceID]
+	out := make([]executionRecord, len(records))
+	copy(out, records)
+	return out
+}
+
+// drop forgets a workspace's history when the workspace goes away.
+func (h *execHistory) drop(workspaceID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.records, workspaceID)
+}
+
+// recordExecution adds one finished execution to the audit log. Either
+// result or err may be nil, matching the executor's return shapes.
+func (s *server) recordExecution(workspaceID, execID, user string, command []string, started time.Time, result *executor.Result, err error) {
+	rec := executionRecord{
+		ID:        execID,
+		Command:   command,
+		User:      user,
+		StartedAt: started,
+		EndedAt:   time.Now().UTC(),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if result != nil {
+		rec.ExitCode = result.ExitCode
+		rec.Truncated = result.Truncated
+		rec.OutputHash = outputHash(result)
+	}
+	s.history.add(workspaceID, rec)
+}
+
+// outputHash fingerprints the buffered output. Stdout and stderr hash
+// together with a separator so swapping content between streams changes
+// the hash.
+func outputHash(result *executor.Result) string {
+	if result.Stdout == "" && result.Stderr == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(result.Stdout + "\x00" + result.Stderr))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// listExecutions handles GET /workspaces/{id}/executions: the
+// workspace's audit log, oldest first. ?limit=N keeps only the most
+// recent N entries.
+func (s *server) listExecutions(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := s.registry.Get(id); !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	records := s.history.list(id)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		if limit < len(records) {
+			records = records[len(records)-limit:]
+		}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// jobRequest starts one background process in a workspace.
+type jobRequest struct {
+	Command []string `json:"command"`
+	// Workdir overrides the default project working directory.
+	Workdir string `json:"workdir,omitempty"`
+	// User overrides the workspace user for this job. Empty runs as the
+	// workspace's configured user.
+	User string `json:"user,omitempty"`
+	// Holder identifies the caller against the workspace's lock.
+	Holder string `json:"holder,omitempty"`
+	// Confirmed acknowledges a command on the policy's confirm list.
+	Confirmed bool `json:"confirmed,omitempty"`
+}
+
+// handleJobs routes background-job endpoints for a workspace:
+// POST /workspaces/{id}/jobs starts a process and returns its job ID
+// immediately; GET lists the workspace's jobs; GET {job} reports
+// status; GET {job}/logs returns retained output; DELETE {job} stops
+// it. Unlike exec, jobs host long-running processes (dev servers,
+// watchers) that shouldn't block until completion.
+func (s *server) handleJobs(w http.ResponseWriter, r *http.Request, id, rest string) {
+	if s.jobs == nil {
+		writeError(w, http.StatusNotImplemented, "executor cannot run background jobs")
+		return
+	}
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	switch {
+	case rest == "" && r.Method == http.MethodPost:
+		s.startJob(w, r, ws)
+	case rest == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.jobs.List(ws.ID))
+	case strings.HasSuffix(rest, "/logs") && r.Method == http.MethodGet:
+		logs, err := s.jobs.Logs(strings.TrimSuffix(rest, "/logs"))
+		if err != nil {
+			writeError(w, statusForError(err), err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(logs)) //nolint:errcheck
+	case r.Method == http.MethodGet:
+		job, err := s.jobs.Status(rest)
+		if err != nil {
+			writeError(w, statusForError(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case r.Method == http.MethodDelete:
+		if err := s.jobs.Stop(rest); err != nil {
+			writeError(w, statusForError(err), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "stopping"})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+// startJob launches a background process in the workspace and responds
+// with its job ID without waiting for anything to finish.
+func (s *server) startJob(w http.ResponseWriter, r *http.Request, ws *registry.Workspace) {
+	if ws.Status != registry.StatusReady {
+		writeError(w, http.StatusConflict, "workspace is not ready (status: "+ws.Status+")")
+		return
+	}
+	s.registry.Touch(ws.ID)
+
+	var req jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if len(req.Command) == 0 {
+		writeError(w, http.StatusBadRequest, "command is required")
+		return
+	}
+	if err := checkLock(ws, req.Holder); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	user := userConfig(ws).UserSpec()
+	if req.User != "" {
+		user = req.User
+	}
+	command := req.Command
+	if ws.NixFlake != "" {
+		command = provider.NixCommand(ws.NixFlake, command)
+	}
+	env, err := workspaceEnv(ws)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	id, err := s.jobs.Start(ws.ID, executor.Request{
+		Image:     ws.Image,
+		Worktree:  ws.Worktree,
+		Workdir:   req.Workdir,
+		Command:   command,
+		Pod:       provider.PodName(ws.Name),
+		Caches:    ws.Caches,
+		Mounts:    executorMounts(ws.Mounts),
+		Services:  executorServices(ws.Services),
+		Env:       env,
+		User:      user,
+		Engine:    ws.Engine,
+		Confirmed: req.Confirmed,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+}
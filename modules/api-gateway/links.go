@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// Link statuses. Links start pending and only carry traffic once an
+// operator approves them, since connecting isolated workspaces is a
+// deliberate hole in their isolation.
+const (
+	linkPending  = "pending"
+	linkApproved = "approved"
+)
+
+// link connects two workspaces on the backend's shared network, so a
+// frontend workspace can reach a backend workspace's dev server.
+type link struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Port      int       `json:"port"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type linkRequest struct {
+	// From and To are workspace IDs or names.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Port is the peer port the linking workspace dials.
+	Port int `json:"port"`
+}
+
+// handleLinks routes the collection endpoint: list and create.
+func (s *server) handleLinks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.linksMu.Lock()
+		out := make([]*link, 0, len(s.links))
+		for _, l := range s.links {
+			out = append(out, l)
+		}
+		s.linksMu.Unlock()
+		sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		s.createLink(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleLink routes per-link endpoints: GET/DELETE /links/{id} and
+// POST /links/{id}/approve.
+func (s *server) handleLink(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/links/"), "/")
+
+	s.linksMu.Lock()
+	l, ok := s.links[id]
+	s.linksMu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "link not found")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, l)
+	case action == "" && r.Method == http.MethodDelete:
+		s.linksMu.Lock()
+		delete(s.links, id)
+		s.linksMu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
+	case action == "approve" && r.Method == http.MethodPost:
+		s.linksMu.Lock()
+		l.Status = linkApproved
+		s.linksMu.Unlock()
+		writeJSON(w, http.StatusOK, l)
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+func (s *server) createLink(w http.ResponseWriter, r *http.Request) {
+	// Links need a live peer to dial; one-shot pipeline backends have
+	// none, so the capability check happens up front.
+	if _, ok := s.provider.Backend().(provider.LinkBackend); !ok {
+		writeError(w, http.StatusNotImplemented,
+			"backend "+s.provider.Backend().Name()+" cannot link workspaces")
+		return
+	}
+
+	var req linkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	from, ok := s.resolveWorkspace(req.From)
+	if !ok {
+		writeError(w, http.StatusNotFound, "from workspace not found")
+		return
+	}
+	to, ok := s.resolveWorkspace(req.To)
+	if !ok {
+		writeError(w, http.StatusNotFound, "to workspace not found")
+		return
+	}
+	if from.ID == to.ID {
+		writeError(w, http.StatusBadRequest, "cannot link a workspace to itself")
+		return
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		writeError(w, http.StatusBadRequest, "port is required (1-65535)")
+		return
+	}
+
+	l := &link{
+		ID:        "link-" + newWorkspaceID()[3:],
+		From:      from.ID,
+		To:        to.ID,
+		Port:      req.Port,
+		Status:    linkPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.linksMu.Lock()
+	s.links[l.ID] = l
+	s.linksMu.Unlock()
+
+	writeJSON(w, http.StatusCreated, l)
+}
+
+// resolveWorkspace looks a workspace up by ID, falling back to name.
+func (s *server) resolveWorkspace(ref string) (*registry.Workspace, bool) {
+	if ws, ok := s.registry.Get(ref); ok {
+		return ws, true
+	}
+	return s.registry.GetByName(ref)
+}
+
+// linkEnv resolves the approved links involving the workspace to env
+// vars (LINK_<PEER>_HOST/LINK_<PEER>_PORT), so linked code discovers its
+// peer the same way it discovers sidecar services.
+func (s *server) linkEnv(ctx context.Context, ws *registry.Workspace) map[string]string {
+	lb, ok := s.provider.Backend().(provider.LinkBackend)
+	if !ok {
+		return nil
+	}
+
+	s.linksMu.Lock()
+	var approved []*link
+	for _, l := range s.links {
+		if l.Status == linkApproved && (l.From == ws.ID || l.To == ws.ID) {
+			approved = append(approved, l)
+		}
+	}
+	s.linksMu.Unlock()
+
+	env := make(map[string]string)
+	for _, l := range approved {
+		peerID := l.From
+		if peerID == ws.ID {
+			peerID = l.To
+		}
+		peer, ok := s.registry.Get(peerID)
+		if !ok {
+			continue
+		}
+		address, err := lb.RuntimeAddress(ctx, peer.Name)
+		if err != nil {
+			log.Printf("link %s: resolve %s: %v", l.ID, peer.Name, err)
+			continue
+		}
+		prefix := "LINK_" + strings.ToUpper(strings.ReplaceAll(peer.Name, "-", "_"))
+		env[prefix+"_HOST"] = address
+		env[prefix+"_PORT"] = strconv.Itoa(l.Port)
+	}
+	return env
+}
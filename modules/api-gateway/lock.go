@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// lockRequest identifies who is taking the workspace lock and for how
+// long. A zero TTL applies the registry default.
+type lockRequest struct {
+	Holder     string `json:"holder"`
+	TTLSeconds int    `json:"ttl_seconds,omitempty"`
+}
+
+// lockWorkspace handles POST /workspaces/{id}/lock: an agent mid-way
+// through a multi-step operation takes the lock so other clients'
+// execs and deletes bounce until it finishes or the lock expires.
+func (s *server) lockWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	var req lockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.Holder == "" {
+		writeError(w, http.StatusBadRequest, "holder is required")
+		return
+	}
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if err := s.registry.Lock(id, req.Holder, ttl); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	s.getWorkspace(w, id)
+}
+
+// unlockWorkspace handles DELETE /workspaces/{id}/lock?holder=: only
+// the holder may release a live lock.
+func (s *server) unlockWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.registry.Unlock(id, r.URL.Query().Get("holder")); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+	s.getWorkspace(w, id)
+}
+
+// checkLock rejects the caller when someone else holds a live lock on
+// the workspace. Callers pass the holder they act as; the holder
+// itself (and everyone, once the lock expires) passes through.
+func checkLock(ws *registry.Workspace, holder string) error {
+	if ws.Lock.Live() && ws.Lock.Holder != holder {
+		return fmt.Errorf("workspace locked by %s until %s: %w",
+			ws.Lock.Holder, ws.Lock.ExpiresAt.Format(time.RFC3339), errdefs.ErrForbidden)
+	}
+	return nil
+}
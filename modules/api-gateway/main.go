@@ -0,0 +1,240 @@
+// The api-gateway coordinates the workspace modules over HTTP.
+//
+// It is the system layer on top of four tools: environment-registry
+// (tracking), environment-provider (containers), code-executor (commands)
+// and git-manager (worktrees). The gateway itself holds no workspace
+// state - it only wires requests to the right module.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	executor "github.com/NicabarNimble/patina/modules/code-executor"
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+	gitmanager "github.com/NicabarNimble/patina/modules/git-manager"
+	"github.com/NicabarNimble/patina/modules/version"
+)
+
+func main() {
+	port := flag.Int("port", 8091, "port to listen on")
+	repo := flag.String("repo", ".", "path to the source repository")
+	pidFile := flag.String("pid-file", "", "write PID to this file (default <repo>/.patina/agent.pid)")
+	stateFile := flag.String("state-file", "", "persist workspace records to this JSON file so they survive restarts (empty = in-memory)")
+	stateRedis := flag.String("state-redis", "", "Redis address (host:port) to share workspace records across gateway replicas (overrides -state-file)")
+	backendName := flag.String("backend", "dagger", "container backend: dagger, docker or kubernetes")
+	namespace := flag.String("k8s-namespace", provider.DefaultNamespace, "Kubernetes namespace for workspace pods")
+	maxConcurrent := flag.Int("max-concurrent", 4, "max simultaneous container operations (0 = unlimited)")
+	templatesDir := flag.String("templates", "", "directory of user environment templates (<name>.json)")
+	allowImages := flag.String("allow-images", "", "comma-separated image patterns to allow (empty = all)")
+	denyImages := flag.String("deny-images", "", "comma-separated image patterns to deny")
+	allowCommands := flag.String("allow-commands", "", "comma-separated command regexes to allow (empty = all)")
+	denyCommands := flag.String("deny-commands", "", "comma-separated command regexes to deny")
+	confirmCommands := flag.String("confirm-commands", "", "comma-separated command regexes that require confirmed execs")
+	corsOrigins := flag.String("cors-origins", "", "comma-separated origins allowed to call the API from browsers (* = any, empty = disabled)")
+	corsHeaders := flag.String("cors-headers", "", "comma-separated extra request headers allowed on cross-origin calls")
+	corsCredentials := flag.Bool("cors-credentials", false, "allow credentials on cross-origin calls")
+	warmTemplates := flag.String("warm-templates", "", "comma-separated templates to keep pre-built")
+	warmSize := flag.Int("warm-pool-size", 2, "pre-built environments kept per warm template")
+	daggerEngines := flag.String("dagger-engines", "", "comma-separated remote Dagger engine endpoints to place environments on (empty = local engine)")
+	evictPercent := flag.Int("evict-disk-percent", 0, "disk usage percentage that triggers workspace eviction (0 = disabled)")
+	standbyDest := flag.String("standby-dest", "", "rsync destination for standby replicas (e.g. host2:/var/lib/patina/standby)")
+	artifactStore := flag.String("artifact-store", "", "object-store URL for workspace artifacts (s3://, gs:// or an mc alias)")
+	baseBranch := flag.String("base-branch", "", "base branch for rebase operations (default: detect from origin/HEAD)")
+	initSubmodules := flag.Bool("init-submodules", false, "populate git submodules in new worktrees")
+	flag.Parse()
+
+	backend, runner, err := selectBackend(*backendName, *namespace)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repoPath, err := filepath.Abs(*repo)
+	if err != nil {
+		log.Fatalf("resolve repo path: %v", err)
+	}
+
+	pidPath := *pidFile
+	if pidPath == "" {
+		pidPath = filepath.Join(repoPath, ".patina", "agent.pid")
+	}
+	if err := writePIDFile(pidPath); err != nil {
+		log.Fatalf("write PID file: %v", err)
+	}
+	defer os.Remove(pidPath)
+
+	prov := provider.NewWithBackend(backend)
+	prov.SetConcurrencyLimit(*maxConcurrent)
+	prov.SetImagePolicy(provider.ImagePolicy{
+		Allow: splitPatterns(*allowImages),
+		Deny:  splitPatterns(*denyImages),
+	})
+	if ex, ok := runner.(*executor.Executor); ok {
+		ex.SetConcurrencyLimit(*maxConcurrent)
+	}
+	// Both executors take a command policy; type-assert on the shared
+	// setter so the flag applies regardless of backend.
+	if pe, ok := runner.(interface{ SetCommandPolicy(executor.CommandPolicy) }); ok {
+		pe.SetCommandPolicy(executor.CommandPolicy{
+			Allow:   splitPatterns(*allowCommands),
+			Deny:    splitPatterns(*denyCommands),
+			Confirm: splitPatterns(*confirmCommands),
+		})
+	}
+
+	// Durable deployments persist workspace records; the containers
+	// themselves are not restored, but records and worktrees survive so
+	// workspaces can be resumed instead of recreated.
+	reg := registry.New()
+	switch {
+	case *stateRedis != "":
+		store := registry.NewRedisStore(*stateRedis)
+		reg, err = registry.NewWithStore(store)
+		if err != nil {
+			log.Fatalf("registry state: %v", err)
+		}
+		// Other replicas write the same key; reload on their changes so
+		// this instance serves their workspaces too.
+		go watchRegistry(reg, store)
+	case *stateFile != "":
+		reg, err = registry.NewWithStore(registry.NewFileStore(*stateFile))
+		if err != nil {
+			log.Fatalf("registry state: %v", err)
+		}
+	}
+
+	templates := provider.NewTemplates(*templatesDir)
+
+	git := gitmanager.New(repoPath)
+	git.BaseBranch = *baseBranch
+	git.InitSubmodules = *initSubmodules
+
+	srv := &server{
+		registry:      reg,
+		provider:      prov,
+		executor:      runner,
+		git:           git,
+		templates:     templates,
+		standby:       *standbyDest,
+		budgets:       executor.NewBudgetTracker(0),
+		links:         make(map[string]*link),
+		artifactStore: strings.TrimSuffix(*artifactStore, "/"),
+		artifacts:     make(map[string][]*artifact),
+		progress:      make(map[string][]progressEvent),
+		progressSubs:  make(map[string]map[chan progressEvent]struct{}),
+		queue:         newCreateQueue(*maxConcurrent),
+		execs:         newExecTracker(),
+		history:       newExecHistory(),
+		cors: corsConfig{
+			origins:     splitPatterns(*corsOrigins),
+			headers:     splitPatterns(*corsHeaders),
+			credentials: *corsCredentials,
+		},
+	}
+	// With a fleet configured, new environments go to the least-loaded
+	// engine; their execs follow the placement.
+	if engines := splitPatterns(*daggerEngines); len(engines) > 0 {
+		srv.engines = provider.NewEngineScheduler(engines)
+	}
+	// Background jobs need streamed output for their log buffers; both
+	// shipped executors provide it.
+	if sr, ok := runner.(executor.StreamRunner); ok {
+		srv.jobs = executor.NewJobs(sr)
+	}
+
+	// Pipeline progress flows from the backend and executor into the
+	// gateway's per-workspace buffers. The executor labels events with
+	// the pod name; strip the prefix so creates and execs land under the
+	// same workspace key.
+	if pb, ok := backend.(provider.ProgressBackend); ok {
+		pb.SetProgressSink(srv.recordProgress)
+	}
+	if ex, ok := runner.(*executor.Executor); ok {
+		ex.SetProgressSink(func(pod, step string) {
+			srv.recordProgress(strings.TrimPrefix(pod, provider.PodName("")), step)
+		})
+	}
+
+	// Under disk pressure the controller pauses idle workspaces instead
+	// of letting the next create fail on a full runtime.
+	if *evictPercent > 0 {
+		go srv.runEvictionLoop(*evictPercent)
+	}
+
+	// Workspaces created with a TTL clean themselves up when it lapses.
+	go srv.runReaperLoop()
+
+	if warm := splitPatterns(*warmTemplates); len(warm) > 0 {
+		srv.pool = provider.NewWarmPool(prov, templates, warm, *warmSize)
+		srv.pool.Start()
+		defer srv.pool.Stop()
+	}
+
+	log.Printf("workspace agent %s (%s) listening on :%d (repo: %s, backend: %s)",
+		version.Version, version.Commit, *port, repoPath, backend.Name())
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), srv.routes()); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+}
+
+// watchRegistry reloads shared registry state whenever another replica
+// rewrites it, re-subscribing when the connection drops so a Redis
+// restart degrades to a brief gap, not a permanently stale view.
+func watchRegistry(reg *registry.Registry, store *registry.RedisStore) {
+	for {
+		ticks, err := store.Watch(make(chan struct{}))
+		if err != nil {
+			log.Printf("registry watch: %v (retrying)", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for range ticks {
+			if err := reg.Reload(); err != nil {
+				log.Printf("registry reload: %v", err)
+			}
+		}
+	}
+}
+
+// selectBackend maps the -backend flag to a container runtime and the
+// executor that matches it.
+func selectBackend(name, namespace string) (provider.Backend, executor.Runner, error) {
+	switch name {
+	case "dagger":
+		return provider.NewDaggerBackend(), executor.New(), nil
+	case "docker":
+		return provider.NewDockerBackend(), executor.New(), nil
+	case "kubernetes":
+		return provider.NewKubeBackend(namespace), executor.NewKube(namespace), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown backend %q (want dagger, docker or kubernetes)", name)
+	}
+}
+
+// splitPatterns parses a comma-separated flag into patterns, dropping
+// empty entries so an unset flag means no patterns.
+func splitPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// writePIDFile records our PID so the CLI can manage the agent process.
+func writePIDFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0o644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// handleMetrics serves registry gauges in the Prometheus text format.
+// The format is three lines per family; emitting it by hand keeps the
+// scrape surface without pulling a client library into the tree.
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	stats := s.registry.Stats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP patina_workspaces_total Registered workspaces.")
+	fmt.Fprintln(w, "# TYPE patina_workspaces_total gauge")
+	fmt.Fprintf(w, "patina_workspaces_total %d\n", stats.Total)
+
+	fmt.Fprintln(w, "# HELP patina_workspaces Workspaces by lifecycle status.")
+	fmt.Fprintln(w, "# TYPE patina_workspaces gauge")
+	writeLabelledGauge(w, "patina_workspaces", "status", stats.ByStatus)
+
+	fmt.Fprintln(w, "# HELP patina_namespace_workspaces Workspaces by tenant namespace.")
+	fmt.Fprintln(w, "# TYPE patina_namespace_workspaces gauge")
+	writeLabelledGauge(w, "patina_namespace_workspaces", "namespace", stats.ByNamespace)
+
+	fmt.Fprintln(w, "# HELP patina_workspace_ages Workspaces by age band since creation.")
+	fmt.Fprintln(w, "# TYPE patina_workspace_ages gauge")
+	for _, band := range []struct {
+		name  string
+		count int
+	}{
+		{"under_hour", stats.Ages.UnderHour},
+		{"under_day", stats.Ages.UnderDay},
+		{"under_week", stats.Ages.UnderWeek},
+		{"older", stats.Ages.Older},
+	} {
+		fmt.Fprintf(w, "patina_workspace_ages{band=%q} %d\n", band.name, band.count)
+	}
+}
+
+// writeLabelledGauge emits one gauge line per map entry, sorted so
+// scrapes are stable between calls.
+func writeLabelledGauge(w http.ResponseWriter, name, label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, key, counts[key])
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// progressBuffer bounds how many pipeline steps are kept per workspace.
+// Old steps roll off; the buffer exists so a client connecting mid-create
+// sees recent history, not as a permanent log.
+const progressBuffer = 200
+
+// progressEvent is one pipeline step reported by the backend or the
+// executor, e.g. an image pull layer or a build stage.
+type progressEvent struct {
+	Step string    `json:"step"`
+	Time time.Time `json:"time"`
+}
+
+// recordProgress is the sink wired into the dagger backend and executor
+// at startup: name is the workspace the pipeline belongs to, step one
+// progress line. Events fan out to live subscribers and into the
+// workspace's replay buffer.
+func (s *server) recordProgress(name, step string) {
+	event := progressEvent{Step: step, Time: time.Now().UTC()}
+
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	events := append(s.progress[name], event)
+	if len(events) > progressBuffer {
+		events = events[len(events)-progressBuffer:]
+	}
+	s.progress[name] = events
+
+	for ch := range s.progressSubs[name] {
+		// A subscriber that can't keep up misses the event rather than
+		// stalling the pipeline; the buffer covers the gap on reconnect.
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// subscribeProgress registers a live event channel for the workspace and
+// returns the buffered history alongside it. The returned func
+// unsubscribes.
+func (s *server) subscribeProgress(name string) ([]progressEvent, chan progressEvent, func()) {
+	ch := make(chan progressEvent, 16)
+
+	s.progressMu.Lock()
+	history := append([]progressEvent{}, s.progress[name]...)
+	if s.progressSubs[name] == nil {
+		s.progressSubs[name] = make(map[chan progressEvent]struct{})
+	}
+	s.progressSubs[name][ch] = struct{}{}
+	s.progressMu.Unlock()
+
+	return history, ch, func() {
+		s.progressMu.Lock()
+		delete(s.progressSubs[name], ch)
+		s.progressMu.Unlock()
+	}
+}
+
+// streamProgress streams the workspace's pipeline progress as
+// server-sent events: buffered history first, then live steps until the
+// client disconnects. This is how "pulling image" gets to a user instead
+// of a silent multi-minute create.
+func (s *server) streamProgress(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	history, events, unsubscribe := s.subscribeProgress(ws.Name)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range history {
+		writeProgressEvent(w, event)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			writeProgressEvent(w, event)
+			flusher.Flush()
+		}
+	}
+}
+
+// writeProgressEvent renders one event in SSE framing.
+func writeProgressEvent(w http.ResponseWriter, event progressEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", payload)
+}
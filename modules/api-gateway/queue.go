@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCreateEstimate seeds wait estimates before any create has
+// completed in this gateway's lifetime.
+const defaultCreateEstimate = time.Minute
+
+// maxQueueSamples bounds the create-duration history used for wait
+// estimates; older creates stop reflecting current image-cache state.
+const maxQueueSamples = 20
+
+// createQueue tracks in-flight workspace creations and recent create
+// durations. Creations beyond the provider's concurrency limit wait on
+// its semaphore; the queue gives clients a position and an estimated
+// wait for that, instead of a status that silently stays "creating".
+type createQueue struct {
+	// limit mirrors the provider's create concurrency; 0 means
+	// unlimited, in which case nothing ever waits.
+	limit int
+
+	mu        sync.Mutex
+	pending   []queueEntry
+	durations []time.Duration
+}
+
+type queueEntry struct {
+	ID   string
+	Name string
+}
+
+// queueStatus is one queue line in GET /queue responses.
+type queueStatus struct {
+	WorkspaceID string `json:"workspace_id"`
+	Name        string `json:"name"`
+	Position    int    `json:"position"`
+	// State is "running" when the creation holds a concurrency slot,
+	// "waiting" when it is queued behind one.
+	State string `json:"state"`
+	// EstimatedWait is how long the creation is expected to wait before
+	// starting, based on recent create durations. Empty when running.
+	EstimatedWait string `json:"estimated_wait,omitempty"`
+}
+
+func newCreateQueue(limit int) *createQueue {
+	return &createQueue{limit: limit}
+}
+
+// enqueue records a creation and returns its 1-based queue position.
+func (q *createQueue) enqueue(id, name string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, queueEntry{ID: id, Name: name})
+	return len(q.pending)
+}
+
+// finish removes a completed creation and records how long it took.
+func (q *createQueue) finish(id string, d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, e := range q.pending {
+		if e.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	q.durations = append(q.durations, d)
+	if len(q.durations) > maxQueueSamples {
+		q.durations = q.durations[len(q.durations)-maxQueueSamples:]
+	}
+}
+
+// waiting reports whether the given position is queued behind the
+// concurrency limit rather than running.
+func (q *createQueue) waiting(position int) bool {
+	return q.limit > 0 && position > q.limit
+}
+
+// estimate predicts how long the given position waits before its
+// creation starts: the average recent create duration times the number
+// of full rounds ahead of it.
+func (q *createQueue) estimate(position int) time.Duration {
+	if !q.waiting(position) {
+		return 0
+	}
+	q.mu.Lock()
+	avg := defaultCreateEstimate
+	if len(q.durations) > 0 {
+		var total time.Duration
+		for _, d := range q.durations {
+			total += d
+		}
+		avg = total / time.Duration(len(q.durations))
+	}
+	q.mu.Unlock()
+
+	rounds := (position - q.limit + q.limit - 1) / q.limit
+	return avg * time.Duration(rounds)
+}
+
+// snapshot returns the queue's current lines, front first.
+func (q *createQueue) snapshot() []queueStatus {
+	q.mu.Lock()
+	entries := make([]queueEntry, len(q.pending))
+	copy(entries, q.pending)
+	q.mu.Unlock()
+
+	out := make([]queueStatus, len(entries))
+	for i, e := range entries {
+		position := i + 1
+		status := queueStatus{
+			WorkspaceID: e.ID,
+			Name:        e.Name,
+			Position:    position,
+			State:       "running",
+		}
+		if q.waiting(position) {
+			status.State = "waiting"
+			status.EstimatedWait = q.estimate(position).String()
+		}
+		out[i] = status
+	}
+	return out
+}
+
+// handleQueue reports pending workspace creations, so clients can show
+// progress instead of blind-retrying.
+func (s *server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.queue.snapshot())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// reapInterval is how often the reaper sweeps for expired workspaces.
+// TTLs are lifetime bounds, not precision timers; a sweep per minute is
+// plenty.
+const reapInterval = time.Minute
+
+// runReaperLoop tears down workspaces whose TTL has lapsed, so
+// short-lived environments (CI runs, demo workspaces, agent scratch
+// space) clean themselves up instead of accumulating until someone
+// notices the disk is full.
+func (s *server) runReaperLoop() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.sweepExpired()
+	}
+}
+
+// sweepExpired reaps every workspace past its TTL. Workspaces already
+// being created or deleted are left for their own paths to finish
+// first; they are picked up on a later sweep.
+func (s *server) sweepExpired() {
+	now := time.Now().UTC()
+	for _, ws := range s.registry.List(registry.Filter{}) {
+		if !ws.Expired(now) {
+			continue
+		}
+		if ws.Status == registry.StatusCreating || ws.Status == registry.StatusDeleting {
+			continue
+		}
+		log.Printf("workspace %s (%s): TTL of %ds expired, reaping", ws.ID, ws.Name, ws.TTLSeconds)
+		s.reapWorkspace(ws)
+	}
+}
+
+// reapWorkspace runs the same cleanup as a DELETE: container, worktree,
+// engine placement, bookkeeping, record. Cleanup failures are logged
+// and the record still goes away — a leaked container is recoverable,
+// a workspace that can never expire is not.
+func (s *server) reapWorkspace(ws *registry.Workspace) {
+	if err := s.registry.UpdateStatus(ws.ID, registry.StatusDeleting); err != nil {
+		log.Printf("workspace %s: reap status update skipped: %v", ws.ID, err)
+	}
+	if err := s.provider.Destroy(context.Background(), ws.Name); err != nil {
+		log.Printf("workspace %s: reap destroy failed: %v", ws.ID, err)
+	}
+	if err := s.git.RemoveWorktree(ws.Name); err != nil {
+		log.Printf("workspace %s: reap worktree cleanup failed: %v", ws.ID, err)
+	}
+	if s.engines != nil {
+		s.engines.Release(ws.Name)
+	}
+	s.history.drop(ws.ID)
+	if err := s.registry.Deregister(ws.ID); err != nil {
+		log.Printf("workspace %s: reap deregister failed: %v", ws.ID, err)
+	}
+}
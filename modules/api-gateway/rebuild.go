@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// rebuildRequest carries config changes for an existing workspace.
+// Fields left empty keep the workspace's current values, so a rebuild
+// can bump just the image without restating everything else.
+type rebuildRequest struct {
+	Image    string `json:"image,omitempty"`
+	Template string `json:"template,omitempty"`
+	NixFlake string `json:"nix_flake,omitempty"`
+	// Env, EnvFiles and Setup replace the workspace's values when non-nil.
+	Env      map[string]string `json:"env,omitempty"`
+	EnvFiles []string          `json:"env_files,omitempty"`
+	Setup    []string          `json:"setup,omitempty"`
+	// Packages declares packages to install in the rebuilt environment.
+	Packages provider.Packages `json:"packages,omitempty"`
+}
+
+// rebuildWorkspace recreates the workspace's container with an updated
+// config while keeping its worktree, branch and identity - the
+// alternative today is delete-and-recreate, which loses all of that.
+func (s *server) rebuildWorkspace(w http.ResponseWriter, r *http.Request, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if ws.Status == registry.StatusCreating || ws.Status == registry.StatusDeleting {
+		writeError(w, http.StatusConflict, "workspace is "+ws.Status)
+		return
+	}
+
+	var req rebuildRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	// Start from the workspace's current config; the request overrides.
+	if req.Image != "" {
+		ws.Image = req.Image
+	}
+	if req.Template != "" {
+		ws.Template = req.Template
+	}
+	if req.NixFlake != "" {
+		ws.NixFlake = req.NixFlake
+	}
+	if req.Env != nil {
+		ws.Env = req.Env
+	}
+	if req.EnvFiles != nil {
+		ws.EnvFiles = req.EnvFiles
+	}
+	if req.Setup != nil {
+		ws.Setup = req.Setup
+	}
+
+	cfg := provider.Config{
+		Name:     ws.Name,
+		Image:    ws.Image,
+		Engine:   ws.Engine,
+		NixFlake: ws.NixFlake,
+		Caches:   ws.Caches,
+		Mounts:   providerMounts(ws.Mounts),
+		Services: providerServices(ws.Services),
+		Env:      ws.Env,
+		EnvFiles: ws.EnvFiles,
+		Setup:    ws.Setup,
+		Packages: req.Packages,
+		User:     ws.User,
+		UID:      ws.UID,
+		GID:      ws.GID,
+		Health:   providerHealth(ws.Health),
+	}
+	if req.Template != "" {
+		tpl, err := s.templates.Resolve(req.Template)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		cfg = tpl.Apply(cfg)
+	}
+	// Expansion is idempotent: setup commands recorded at create time
+	// aren't prepended twice.
+	cfg = provider.ApplyNixFlake(cfg)
+	cfg = provider.ApplyPackages(cfg)
+	if cfg.Image == "" {
+		cfg.Image = provider.DefaultImage
+	}
+	if err := s.provider.CheckImage(cfg.Image); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	oldImage := ws.Image
+	ws.Image = cfg.Image
+	ws.Env = cfg.Env
+	ws.Setup = cfg.Setup
+	ws.Status = registry.StatusCreating
+	if err := s.registry.Update(ws, ws.Version); err != nil {
+		writeError(w, statusForError(err), err.Error())
+		return
+	}
+
+	// The rebuild runs in the background like creation; clients poll.
+	go s.rebuildContainer(ws, oldImage, cfg)
+
+	writeJSON(w, http.StatusAccepted, ws)
+}
+
+// rebuildContainer tears down and recreates the workspace's environment,
+// re-runs setup and flips the status, mirroring initializeContainer.
+func (s *server) rebuildContainer(ws *registry.Workspace, oldImage string, cfg provider.Config) {
+	_, err := s.provider.Rebuild(context.Background(),
+		&provider.Environment{ID: ws.ID, Image: oldImage}, cfg)
+	status := registry.StatusReady
+	if err != nil {
+		log.Printf("workspace %s: rebuild failed: %v", ws.ID, err)
+		status = registry.StatusError
+	} else if err := s.runSetup(ws); err != nil {
+		log.Printf("workspace %s: %v", ws.ID, err)
+		status = registry.StatusError
+	}
+	status = s.healthStatus(ws, status)
+	if err := s.registry.UpdateStatus(ws.ID, status); err != nil {
+		log.Printf("workspace %s: status update skipped: %v", ws.ID, err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// reconcileReport says where the registry and the filesystem disagree.
+// Both lists are empty when they agree.
+type reconcileReport struct {
+	// Ghosts are registry entries whose worktree no longer exists on
+	// disk — typically leftovers of a crash mid-delete.
+	Ghosts []string `json:"ghosts"`
+	// Orphans are worktrees on disk that no registry entry claims —
+	// typically leftovers of a crash mid-create, or a lost state file.
+	Orphans []string `json:"orphans"`
+	// Deregistered and Adopted count what repair changed; both stay 0
+	// on a report-only run.
+	Deregistered int `json:"deregistered,omitempty"`
+	Adopted      int `json:"adopted,omitempty"`
+}
+
+// handleReconcile serves /admin/reconcile: GET reports drift between
+// the registry and the worktrees on disk, POST repairs it. Repair
+// deregisters ghosts and adopts orphans as paused workspaces, so both
+// become manageable through the normal API again.
+func (s *server) handleReconcile(w http.ResponseWriter, repair bool) {
+	report, err := s.reconcile(repair)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// reconcile diffs registry entries against worktrees on disk.
+// Workspaces still being created are left alone: their worktree may
+// legitimately not exist yet.
+func (s *server) reconcile(repair bool) (*reconcileReport, error) {
+	onDisk, err := s.git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &reconcileReport{}
+	claimed := make(map[string]bool)
+	for _, ws := range s.registry.List(registry.Filter{}) {
+		claimed[ws.Name] = true
+		if ws.Status == registry.StatusCreating || ws.Worktree == "" {
+			continue
+		}
+		if _, err := os.Stat(ws.Worktree); err == nil {
+			continue
+		}
+		report.Ghosts = append(report.Ghosts, ws.ID)
+		if repair {
+			if err := s.registry.Deregister(ws.ID); err != nil {
+				log.Printf("reconcile: deregister ghost %s: %v", ws.ID, err)
+				continue
+			}
+			report.Deregistered++
+		}
+	}
+
+	for _, name := range onDisk {
+		if claimed[name] {
+			continue
+		}
+		report.Orphans = append(report.Orphans, name)
+		if repair {
+			if err := s.adoptWorktree(name); err != nil {
+				log.Printf("reconcile: adopt orphan %s: %v", name, err)
+				continue
+			}
+			report.Adopted++
+		}
+	}
+	return report, nil
+}
+
+// adoptWorktree registers a paused record for an unclaimed worktree.
+// The original environment config is gone with the record, so adoption
+// assumes the conventional branch and default image; resume rebuilds a
+// container around the surviving files, which are the part that
+// couldn't be recreated.
+func (s *server) adoptWorktree(name string) error {
+	now := time.Now().UTC()
+	return s.registry.Register(&registry.Workspace{
+		ID:        newWorkspaceID(),
+		Name:      name,
+		Repo:      s.git.RepoPath,
+		Branch:    "workspace/" + name,
+		Worktree:  filepath.Join(s.git.WorktreeRoot, name),
+		Image:     provider.DefaultImage,
+		Status:    registry.StatusPaused,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
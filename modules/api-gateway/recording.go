@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordingsDir is where attach sessions record, relative to the
+// worktree. Like run transcripts, recordings live with the workspace so
+// they survive gateway restarts and travel with checkpoints.
+const recordingsDir = ".patina/sessions"
+
+// sessionRecorder writes one attach session's terminal stream as an
+// asciinema v2 cast: a JSON header line, then one [elapsed, type, data]
+// event per output chunk or resize. Reviewers replay the file with any
+// asciinema-compatible player to see exactly what happened.
+type sessionRecorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+}
+
+// newSessionRecorder opens a timestamped cast file in the workspace's
+// recordings directory and writes the header.
+func newSessionRecorder(worktree string, cols, rows int) (*sessionRecorder, error) {
+	dir := filepath.Join(worktree, recordingsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create recordings directory: %w", err)
+	}
+	start := time.Now().UTC()
+	name := start.Format("20060102T150405.000Z") + ".cast"
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+
+	header, err := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     cols,
+		"height":    rows,
+		"timestamp": start.Unix(),
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(header, '\n')); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+	return &sessionRecorder{file: file, start: start}, nil
+}
+
+// output records one chunk of terminal output.
+func (rec *sessionRecorder) output(p []byte) {
+	rec.event("o", string(p))
+}
+
+// resize records a terminal resize as a cast "r" event.
+func (rec *sessionRecorder) resize(cols, rows int) {
+	rec.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+func (rec *sessionRecorder) event(kind, data string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	line, err := json.Marshal([]any{time.Since(rec.start).Seconds(), kind, data})
+	if err != nil {
+		return
+	}
+	rec.file.Write(append(line, '\n')) //nolint:errcheck // best-effort recording
+}
+
+func (rec *sessionRecorder) close() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.file.Close()
+}
+
+// recordingInfo is one stored session recording.
+type recordingInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// listRecordings handles GET /workspaces/{id}/recordings: the
+// workspace's stored session casts, oldest first.
+func (s *server) listRecordings(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Join(ws.Worktree, recordingsDir))
+	if err != nil && !os.IsNotExist(err) {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	recordings := make([]recordingInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".cast") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recordingInfo{
+			Name:      entry.Name(),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime().UTC(),
+		})
+	}
+	writeJSON(w, http.StatusOK, recordings)
+}
+
+// downloadRecording handles GET /workspaces/{id}/recordings/{name}:
+// the raw cast file, ready for an asciinema player.
+func (s *server) downloadRecording(w http.ResponseWriter, r *http.Request, id, name string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	// Recording names are flat timestamps; anything with a separator is
+	// a traversal attempt.
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".cast") {
+		writeError(w, http.StatusBadRequest, "invalid recording name")
+		return
+	}
+	path := filepath.Join(ws.Worktree, recordingsDir, name)
+	if _, err := os.Stat(path); err != nil {
+		writeError(w, http.StatusNotFound, "recording not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	provider "github.com/NicabarNimble/patina/modules/environment-provider"
+	registry "github.com/NicabarNimble/patina/modules/environment-registry"
+)
+
+// standbyInterval is how often standby workspaces replicate.
+const standbyInterval = 5 * time.Minute
+
+// runStandbyTimer replicates the workspace to the standby destination on
+// a fixed interval until the workspace is deregistered. Replication is
+// best-effort: a failed round logs and retries next tick, since the
+// primary copy is still intact.
+func (s *server) runStandbyTimer(id string) {
+	ticker := time.NewTicker(standbyInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ws, ok := s.registry.Get(id)
+		if !ok {
+			return
+		}
+		if err := s.replicate(ws); err != nil {
+			log.Printf("workspace %s: standby replication failed: %v", ws.ID, err)
+		}
+	}
+}
+
+// replicate pushes a paused standby copy of the workspace to the
+// configured destination: the worktree via rsync, plus the environment
+// image as a tarball so a second host can resume without rebuilding.
+func (s *server) replicate(ws *registry.Workspace) error {
+	if s.standby == "" {
+		return fmt.Errorf("no standby destination configured (-standby-dest)")
+	}
+	dest := s.standby + "/" + ws.Name
+
+	// Trailing slashes make rsync mirror directory contents, not nest
+	// the source directory under the destination.
+	if err := runRsync(ws.Worktree+"/", dest+"/worktree/"); err != nil {
+		return fmt.Errorf("replicate worktree: %w", err)
+	}
+
+	// The image export needs a backend that can save tarballs; without
+	// one the worktree copy alone still preserves the work.
+	if _, ok := s.provider.Backend().(provider.ExportBackend); !ok {
+		log.Printf("workspace %s: backend %s cannot export images; replicating worktree only",
+			ws.ID, s.provider.Backend().Name())
+		return nil
+	}
+
+	tmp, err := os.MkdirTemp("", "patina-standby-")
+	if err != nil {
+		return fmt.Errorf("replicate image: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	tarball := filepath.Join(tmp, "image.tar")
+	env := &provider.Environment{ID: ws.ID, Image: ws.Image}
+	if err := s.provider.Export(context.Background(), env, tarball); err != nil {
+		return fmt.Errorf("replicate image: %w", err)
+	}
+	if err := runRsync(tarball, dest+"/image.tar"); err != nil {
+		return fmt.Errorf("replicate image: %w", err)
+	}
+	return nil
+}
+
+// runRsync mirrors src onto dst, creating missing destination
+// directories. rsync handles both local paths and host:path remotes, so
+// the standby destination can live on a second machine over SSH.
+func runRsync(src, dst string) error {
+	cmd := exec.Command("rsync", "--archive", "--delete", "--mkpath", src, dst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rsync %s: %s", dst, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// replicateWorkspace triggers an immediate standby replication, so
+// operators can sync before planned maintenance instead of waiting for
+// the timer.
+func (s *server) replicateWorkspace(w http.ResponseWriter, id string) {
+	ws, ok := s.registry.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if !ws.Standby {
+		writeError(w, http.StatusConflict, "workspace has no standby replication enabled")
+		return
+	}
+
+	if err := s.replicate(ws); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"replicated": id})
+}
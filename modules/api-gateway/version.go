@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/NicabarNimble/patina/modules/version"
+)
+
+// handleVersion reports the server build and the versions of the tools
+// it shells out to, so clients and SDKs can negotiate features and bug
+// reports carry exact versions.
+func (s *server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"version": version.Version,
+		"commit":  version.Commit,
+		"go":      runtime.Version(),
+		"backend": s.provider.Backend().Name(),
+		"dagger":  toolVersion("dagger", "version"),
+		"git":     toolVersion("git", "--version"),
+	})
+}
+
+// toolVersion returns the first line a tool's version command prints,
+// or "unavailable" when the tool is missing.
+func toolVersion(bin string, args ...string) string {
+	out, err := exec.Command(bin, args...).Output()
+	if err != nil {
+		return "unavailable"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line
+}
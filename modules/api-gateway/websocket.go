@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// wsGUID is the handshake constant from RFC 6455.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxFrameLength caps the payload size ReadMessage accepts. Attach
+// frames carry PTY keystrokes, pastes and resizes, so a few KB is
+// generous; without a cap the claimed length in a crafted frame header
+// dictates the allocation.
+const wsMaxFrameLength = 32 << 10
+
+// WebSocket frame opcodes.
+const (
+	wsText   = 0x1
+	wsBinary = 0x2
+	wsClose  = 0x8
+	wsPing   = 0x9
+	wsPong   = 0xa
+)
+
+// wsConn is a minimal server-side WebSocket connection: handshake,
+// unfragmented frames, ping/pong and close. It covers what the attach
+// endpoint needs without pulling a dependency into the tree.
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	// writeMu serializes whole frames: the attach output goroutine
+	// writes concurrently with the read loop's pongs and the deferred
+	// close, and interleaved header/payload bytes corrupt the stream.
+	writeMu sync.Mutex
+}
+
+// upgradeWebSocket hijacks the HTTP connection and completes the
+// WebSocket handshake.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return nil, fmt.Errorf("not a websocket upgrade request")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("connection cannot be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// ReadMessage returns the next data frame's opcode and payload,
+// answering pings transparently. A close frame (or EOF) surfaces as
+// io.EOF.
+func (c *wsConn) ReadMessage() (byte, []byte, error) {
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(c.rw, header); err != nil {
+			return 0, nil, io.EOF
+		}
+		opcode := header[0] & 0x0f
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7f)
+
+		switch length {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(c.rw, ext); err != nil {
+				return 0, nil, io.EOF
+			}
+			length = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(c.rw, ext); err != nil {
+				return 0, nil, io.EOF
+			}
+			length = binary.BigEndian.Uint64(ext)
+		}
+
+		if length > wsMaxFrameLength {
+			c.closeWith(1009, "message too big")
+			return 0, nil, io.EOF
+		}
+
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+				return 0, nil, io.EOF
+			}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return 0, nil, io.EOF
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch opcode {
+		case wsClose:
+			return 0, nil, io.EOF
+		case wsPing:
+			c.WriteMessage(wsPong, payload) //nolint:errcheck
+		case wsPong:
+			// Ignore.
+		default:
+			return opcode, payload, nil
+		}
+	}
+}
+
+// WriteMessage sends one unfragmented, unmasked frame (servers don't
+// mask).
+func (c *wsConn) WriteMessage(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) < 1<<16:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// Close sends a close frame and tears down the connection.
+func (c *wsConn) Close() error {
+	c.WriteMessage(wsClose, nil) //nolint:errcheck
+	return c.conn.Close()
+}
+
+// closeWith sends a close frame carrying a status code and reason, then
+// tears down the connection.
+func (c *wsConn) closeWith(code uint16, reason string) {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	copy(payload[2:], reason)
+	c.WriteMessage(wsClose, payload) //nolint:errcheck
+	c.conn.Close()
+}
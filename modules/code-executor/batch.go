@@ -0,0 +1,47 @@
+package executor
+
+import "context"
+
+// BatchRunner is a Runner that executes command sequences against one
+// container configuration, for provisioning flows that would otherwise
+// pay container-resolution cost per command.
+type BatchRunner interface {
+	Runner
+	ExecuteBatch(ctx context.Context, req Request, commands [][]string) ([]*Result, error)
+}
+
+// ExecuteBatch runs the commands in order against the request's
+// container configuration, short-circuiting after the first command
+// that fails to start or exits non-zero. The engine caches the resolved
+// container across steps, so only the first pays the image pull.
+// Returns one result per step that ran; the request's Command field is
+// ignored.
+func (e *Executor) ExecuteBatch(ctx context.Context, req Request, commands [][]string) ([]*Result, error) {
+	return runBatch(ctx, e, req, commands)
+}
+
+// ExecuteBatch runs the commands in order inside the workspace pod,
+// short-circuiting after the first failure. Returns one result per step
+// that ran; the request's Command field is ignored.
+func (e *Kube) ExecuteBatch(ctx context.Context, req Request, commands [][]string) ([]*Result, error) {
+	return runBatch(ctx, e, req, commands)
+}
+
+// runBatch is the shared batch loop: each step reuses the request with
+// its own command, and a non-zero exit stops the sequence.
+func runBatch(ctx context.Context, r Runner, req Request, commands [][]string) ([]*Result, error) {
+	results := make([]*Result, 0, len(commands))
+	for _, command := range commands {
+		step := req
+		step.Command = command
+		result, err := r.Execute(ctx, step)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		if result.ExitCode != 0 {
+			break
+		}
+	}
+	return results, nil
+}
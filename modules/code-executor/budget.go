@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded marks executions rejected because the workspace has
+// spent its execution-time budget. Callers detect it with errors.Is.
+var ErrBudgetExceeded = errors.New("execution budget exceeded")
+
+// DefaultBudgetWindow is the period after which budget spend resets.
+const DefaultBudgetWindow = 24 * time.Hour
+
+// BudgetTracker tracks cumulative execution time per workspace, so
+// autonomous agents driving a workspace can't burn unbounded compute.
+// Spend resets at the end of each window.
+type BudgetTracker struct {
+	window time.Duration
+	mu     sync.Mutex
+	spent  map[string]*budgetWindow
+	// now is swappable for tests.
+	now func() time.Time
+}
+
+// budgetWindow is one key's spend in its current window.
+type budgetWindow struct {
+	started time.Time
+	spent   time.Duration
+}
+
+// NewBudgetTracker creates a tracker with the given reset window.
+// window <= 0 uses DefaultBudgetWindow.
+func NewBudgetTracker(window time.Duration) *BudgetTracker {
+	if window <= 0 {
+		window = DefaultBudgetWindow
+	}
+	return &BudgetTracker{
+		window: window,
+		spent:  make(map[string]*budgetWindow),
+		now:    time.Now,
+	}
+}
+
+// Check returns ErrBudgetExceeded when the key's spend in the current
+// window has reached limit. A limit <= 0 means unbudgeted.
+func (t *BudgetTracker) Check(key string, limit time.Duration) error {
+	if limit <= 0 {
+		return nil
+	}
+	if spent := t.Spent(key); spent >= limit {
+		return fmt.Errorf("%s spent %s of its %s budget: %w", key, spent.Round(time.Second), limit, ErrBudgetExceeded)
+	}
+	return nil
+}
+
+// Charge records execution time against the key.
+func (t *BudgetTracker) Charge(key string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current(key).spent += d
+}
+
+// Spent reports the time charged against the key in the current window.
+func (t *BudgetTracker) Spent(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current(key).spent
+}
+
+// current returns the key's window, starting a fresh one when the old
+// window has elapsed. Callers must hold the lock.
+func (t *BudgetTracker) current(key string) *budgetWindow {
+	w, ok := t.spent[key]
+	if !ok || t.now().Sub(w.started) >= t.window {
+		w = &budgetWindow{started: t.now()}
+		t.spent[key] = w
+	}
+	return w
+}
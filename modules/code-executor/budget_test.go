@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudgetTracker(t *testing.T) {
+	b := NewBudgetTracker(0)
+
+	// Unbudgeted keys always pass.
+	if err := b.Check("ws-1", 0); err != nil {
+		t.Errorf("unbudgeted check failed: %v", err)
+	}
+
+	b.Charge("ws-1", 30*time.Minute)
+	if err := b.Check("ws-1", time.Hour); err != nil {
+		t.Errorf("under-budget check failed: %v", err)
+	}
+
+	b.Charge("ws-1", 30*time.Minute)
+	err := b.Check("ws-1", time.Hour)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded", err)
+	}
+
+	// Other keys are unaffected.
+	if err := b.Check("ws-2", time.Hour); err != nil {
+		t.Errorf("unrelated key check failed: %v", err)
+	}
+}
+
+func TestBudgetTrackerWindowReset(t *testing.T) {
+	b := NewBudgetTracker(24 * time.Hour)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.Charge("ws-1", 2*time.Hour)
+	if !errors.Is(b.Check("ws-1", time.Hour), ErrBudgetExceeded) {
+		t.Error("expected budget exceeded before window rolls over")
+	}
+
+	// A day later the spend resets.
+	now = now.Add(24 * time.Hour)
+	if err := b.Check("ws-1", time.Hour); err != nil {
+		t.Errorf("check after window reset failed: %v", err)
+	}
+	if spent := b.Spent("ws-1"); spent != 0 {
+		t.Errorf("spent after reset = %s, want 0", spent)
+	}
+}
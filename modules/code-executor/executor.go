@@ -0,0 +1,474 @@
+// Package executor runs commands inside workspace containers.
+//
+// Each execution is a one-shot Dagger pipeline: container from the
+// workspace image, worktree mounted at the workdir, command run, output
+// captured. No state survives between executions except files written to
+// the mounted worktree.
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single execution.
+const DefaultTimeout = 5 * time.Minute
+
+// runnerHostEnv is the environment variable the dagger CLI reads to
+// target a remote engine. Mirrors the provider's constant; the modules
+// stay independent.
+const runnerHostEnv = "_EXPERIMENTAL_DAGGER_RUNNER_HOST"
+
+// DefaultWorkdir is where the project is mounted and where commands run
+// unless the request overrides it. Keeping execs in the project
+// directory means `ls` and relative paths behave the way users expect.
+const DefaultWorkdir = "/workspace/project"
+
+// Request describes one command execution.
+type Request struct {
+	// Image is the container image to run in.
+	Image string `json:"image"`
+	// Worktree is the host path mounted into the container.
+	Worktree string `json:"worktree"`
+	// Workdir is the mount point and working directory. Defaults to
+	// DefaultWorkdir.
+	Workdir string `json:"workdir"`
+	// Command is the argv to execute.
+	Command []string `json:"command"`
+	// Stdin is fed to the command's standard input, so piped workflows
+	// like `psql < dump.sql` work. Carried base64-encoded in JSON.
+	Stdin []byte `json:"stdin,omitempty"`
+	// Timeout bounds the execution. Defaults to DefaultTimeout.
+	Timeout time.Duration `json:"timeout"`
+	// Pod is the workspace pod to exec in. Only used by the Kubernetes
+	// executor; pipeline executors ignore it.
+	Pod string `json:"pod,omitempty"`
+	// Caches maps named cache volumes to mount paths, mirroring the
+	// workspace's provider config so package caches persist across
+	// executions.
+	Caches map[string]string `json:"caches,omitempty"`
+	// Mounts bind extra host directories into the pipeline, mirroring the
+	// workspace's provider config. Pod executors ignore them: the pod
+	// already holds its volumes.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Services are companion containers bound to the execution, mirroring
+	// the workspace's provider config. Pod executors ignore them: the pod
+	// already runs its sidecars.
+	Services []Service `json:"services,omitempty"`
+	// Env sets environment variables for the command, mirroring the
+	// workspace's provider config plus any per-exec additions (e.g.
+	// link peers).
+	Env map[string]string `json:"env,omitempty"`
+	// User runs the command as this user ("uid:gid" or a name). Empty
+	// runs as the image default. Pod executors ignore it: the pod's
+	// security context already applies.
+	User string `json:"user,omitempty"`
+	// Engine is the Dagger engine endpoint the pipeline runs against,
+	// matching the engine the workspace's environment was placed on.
+	// Empty uses the local engine; pod executors ignore it.
+	Engine string `json:"engine,omitempty"`
+	// Retry re-runs the command on matching non-zero exits, for flaky
+	// network-dependent work. Zero value disables retries.
+	Retry Retry `json:"retry,omitempty"`
+	// MaxOutputBytes caps how much stdout and stderr the result buffers
+	// (each). Zero applies DefaultMaxOutputBytes; negative is unlimited.
+	// Streaming callbacks always receive the full output.
+	MaxOutputBytes int `json:"max_output_bytes,omitempty"`
+	// Combined additionally captures stdout and stderr as one
+	// time-ordered line stream in Result.Output, preserving the
+	// interleaving the separate buffers lose.
+	Combined bool `json:"combined,omitempty"`
+	// Confirmed acknowledges a command the policy's confirm list would
+	// otherwise hold back.
+	Confirmed bool `json:"confirmed,omitempty"`
+	// DryRun checks the request against the command policy and returns
+	// the runtime invocation that would run (in Stdout) without
+	// executing anything.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Mount binds one host directory into the execution pipeline. Mode
+// "copy" copies the directory in; "ro-mount" and "rw-mount" mount it
+// live. Empty mode means rw-mount.
+type Mount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Mode   string `json:"mode,omitempty"`
+}
+
+// Service is a companion container bound into the execution pipeline.
+type Service struct {
+	// Name is the alias the command reaches the service under.
+	Name string `json:"name"`
+	// Image is the service container image.
+	Image string `json:"image"`
+	// Ports are the TCP ports the service exposes; the first is
+	// advertised as <NAME>_PORT.
+	Ports []int `json:"ports"`
+	// Env sets environment variables on the service container.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// envPrefix returns the service's env var prefix: upper-cased name with
+// dashes mapped to underscores.
+func (s Service) envPrefix() string {
+	return strings.ToUpper(strings.ReplaceAll(s.Name, "-", "_"))
+}
+
+// Result is the outcome of one execution.
+type Result struct {
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	// Truncated reports that stdout or stderr hit the request's output
+	// cap; the byte counts below carry the sizes actually produced.
+	Truncated   bool  `json:"truncated,omitempty"`
+	StdoutBytes int64 `json:"stdout_bytes,omitempty"`
+	StderrBytes int64 `json:"stderr_bytes,omitempty"`
+	// Output is the combined stdout/stderr stream in arrival order, one
+	// entry per line, populated when the request sets Combined.
+	Output []OutputLine `json:"output,omitempty"`
+	// Usage is the execution's measured resource consumption; nil when
+	// the platform or backend cannot report it.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage is the resource consumption of one execution, for callers
+// enforcing budgets or hunting runaway test suites.
+type Usage struct {
+	// CPUTime is user plus system CPU time.
+	CPUTime time.Duration `json:"cpu_time"`
+	// PeakMemoryBytes is the peak resident set size.
+	PeakMemoryBytes int64 `json:"peak_memory_bytes"`
+	// BytesWritten is the filesystem write volume.
+	BytesWritten int64 `json:"bytes_written"`
+}
+
+// Runner executes workspace commands. Implementations differ by
+// container backend: one-shot Dagger pipelines or pod exec.
+type Runner interface {
+	Execute(ctx context.Context, req Request) (*Result, error)
+}
+
+// StreamFunc receives one chunk of command output as it is produced.
+// The chunk is only valid for the duration of the call; copy it if it
+// outlives the callback.
+type StreamFunc func(chunk []byte)
+
+// StreamRunner is a Runner that can deliver output incrementally, for
+// clients showing live command output instead of waiting for the full
+// buffered result.
+type StreamRunner interface {
+	Runner
+	ExecuteStream(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error)
+}
+
+// streamWriter adapts a StreamFunc to io.Writer.
+type streamWriter struct{ fn StreamFunc }
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	w.fn(p)
+	return len(p), nil
+}
+
+// Executor runs commands through the dagger CLI.
+type Executor struct {
+	// Bin is the dagger binary to invoke. Defaults to "dagger".
+	Bin string
+	// sem bounds simultaneous pipeline evaluations; nil means unlimited.
+	sem chan struct{}
+	// sink receives pipeline progress lines; nil disables reporting.
+	sink func(label, step string)
+	// policy restricts which commands may execute.
+	policy CommandPolicy
+	// middleware wraps every execution, first-registered outermost.
+	middleware []Middleware
+}
+
+// New creates an executor using the dagger CLI from PATH.
+func New() *Executor {
+	return &Executor{Bin: "dagger"}
+}
+
+// SetConcurrencyLimit bounds simultaneous pipeline evaluations, matching
+// the provider's limit so load spikes don't destabilize the engine.
+// n <= 0 removes the limit.
+func (e *Executor) SetConcurrencyLimit(n int) {
+	if n <= 0 {
+		e.sem = nil
+		return
+	}
+	e.sem = make(chan struct{}, n)
+}
+
+// SetCommandPolicy restricts which commands this executor will run.
+func (e *Executor) SetCommandPolicy(policy CommandPolicy) {
+	e.policy = policy
+}
+
+// SetProgressSink registers a callback for pipeline progress lines,
+// labeled with the request's pod name. With a sink set, executions run
+// with plain progress so long pulls report steps instead of silence.
+// A nil sink disables reporting.
+func (e *Executor) SetProgressSink(sink func(label, step string)) {
+	e.sink = sink
+}
+
+// acquire takes a semaphore slot, honoring context cancellation. The
+// returned func releases the slot.
+func (e *Executor) acquire(ctx context.Context) (func(), error) {
+	if e.sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case e.sem <- struct{}{}:
+		return func() { <-e.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Use appends a middleware to the execution chain. Every Execute and
+// ExecuteStream call flows through it; register before serving
+// requests, not concurrently with them.
+func (e *Executor) Use(m Middleware) {
+	e.middleware = append(e.middleware, m)
+}
+
+// Execute runs the request and captures its output.
+func (e *Executor) Execute(ctx context.Context, req Request) (*Result, error) {
+	return chainMiddleware(e.middleware, func(ctx context.Context, req Request) (*Result, error) {
+		return e.execute(ctx, req, nil, nil)
+	})(ctx, req)
+}
+
+// ExecuteStream runs the request, delivering output through the
+// callbacks as the command produces it. The returned Result still
+// carries the full buffered output and exit code.
+func (e *Executor) ExecuteStream(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	return chainMiddleware(e.middleware, func(ctx context.Context, req Request) (*Result, error) {
+		return e.execute(ctx, req, onStdout, onStderr)
+	})(ctx, req)
+}
+
+// execute runs the request, re-running it per the retry policy.
+func (e *Executor) execute(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	result, err := e.executeOnce(ctx, req, onStdout, onStderr)
+	for attempt := 1; req.Retry.shouldRetry(attempt, result, err); attempt++ {
+		if !req.Retry.wait(ctx, attempt) {
+			break
+		}
+		result, err = e.executeOnce(ctx, req, onStdout, onStderr)
+	}
+	return result, err
+}
+
+func (e *Executor) executeOnce(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	if req.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if len(req.Command) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultTimeout
+	}
+	if err := e.policy.Check(req.Command); err != nil {
+		return nil, err
+	}
+	if err := e.policy.ConfirmCheck(req.Command, req.Confirmed); err != nil {
+		return nil, err
+	}
+
+	args := pipelineArgs(req)
+	if e.sink != nil {
+		args = append([]string{"--progress=plain"}, args...)
+	}
+	if req.DryRun {
+		return &Result{Stdout: e.Bin + " " + strings.Join(args, " ")}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	release, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cmd := exec.CommandContext(ctx, e.Bin, args...)
+	if req.Engine != "" {
+		cmd.Env = append(os.Environ(), runnerHostEnv+"="+req.Engine)
+	}
+	if len(req.Stdin) > 0 {
+		cmd.Stdin = bytes.NewReader(req.Stdin)
+	}
+
+	var combined *combinedLog
+	if req.Combined {
+		combined = newCombinedLog(req.MaxOutputBytes)
+		onStdout = chainStream(onStdout, func(p []byte) { combined.add("stdout", p) })
+		onStderr = chainStream(onStderr, func(p []byte) { combined.add("stderr", p) })
+	}
+
+	stdout := newCapBuffer(req.MaxOutputBytes)
+	stderr := newCapBuffer(req.MaxOutputBytes)
+	cmd.Stdout = io.Writer(stdout)
+	if onStdout != nil {
+		cmd.Stdout = io.MultiWriter(stdout, streamWriter{onStdout})
+	}
+
+	start := time.Now()
+	err = e.runPipeline(cmd, stderr, req.Pod, onStderr)
+	result := &Result{
+		Stdout:      stdout.String(),
+		Stderr:      stderr.String(),
+		Duration:    time.Since(start),
+		Truncated:   stdout.truncated() || stderr.truncated(),
+		StdoutBytes: stdout.total,
+		StderrBytes: stderr.total,
+	}
+	if combined != nil {
+		result.Output = combined.snapshot()
+	}
+	result.Usage = collectUsage(cmd)
+
+	if err != nil {
+		// Surface the context's own sentinel so callers can tell a
+		// cancelled or timed-out request from a genuine execution failure.
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, fmt.Errorf("execution timed out after %s: %w", req.Timeout, context.DeadlineExceeded)
+		}
+		if ctx.Err() == context.Canceled {
+			return result, fmt.Errorf("execution cancelled: %w", context.Canceled)
+		}
+		// with-exec runs with --expect=any, so the CLI tolerates non-zero
+		// commands and exits with their status. A failing command is a
+		// normal result, not an execution error.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+			return result, nil
+		}
+		return result, fmt.Errorf("run pipeline: %w", err)
+	}
+	return result, nil
+}
+
+// runPipeline runs the pipeline command, accumulating stderr. With a
+// progress sink set, stderr is also streamed line by line to the sink,
+// labeled with the request's pod name; a non-nil onStderr additionally
+// receives the output as it is produced.
+func (e *Executor) runPipeline(cmd *exec.Cmd, stderr *capBuffer, label string, onStderr StreamFunc) error {
+	if e.sink == nil {
+		cmd.Stderr = io.Writer(stderr)
+		if onStderr != nil {
+			cmd.Stderr = io.MultiWriter(stderr, streamWriter{onStderr})
+		}
+		return cmd.Run()
+	}
+
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		stderr.WriteString(line + "\n")
+		e.sink(label, line)
+		if onStderr != nil {
+			onStderr([]byte(line + "\n"))
+		}
+	}
+	return cmd.Wait()
+}
+
+// pipelineArgs builds the dagger pipeline for a request:
+// from image, mount worktree, set workdir, exec, print stdout.
+func pipelineArgs(req Request) []string {
+	args := basePipelineArgs(req)
+	// --expect=any: the pipeline succeeds regardless of the command's
+	// exit status, which Execute reads from the CLI's own exit code.
+	args = append(args, "with-exec", "--expect=any", "--args="+strings.Join(req.Command, ","))
+	args = append(args, "stdout")
+	return args
+}
+
+// basePipelineArgs builds the container setup shared by one-shot execs
+// and interactive sessions: from image, mounts, services, env, workdir,
+// user.
+func basePipelineArgs(req Request) []string {
+	args := []string{"core", "container", "from", "--address=" + req.Image}
+	if req.Worktree != "" {
+		args = append(args, "with-directory", "--path="+req.Workdir, "--directory="+req.Worktree)
+	}
+	// Sorted for deterministic pipelines (and stable tests).
+	for _, name := range sortedKeys(req.Caches) {
+		args = append(args, "with-mounted-cache", "--path="+req.Caches[name], "--cache="+name)
+	}
+	for _, m := range req.Mounts {
+		switch m.Mode {
+		case "copy":
+			args = append(args, "with-directory", "--path="+m.Target, "--directory="+m.Source)
+		case "ro-mount":
+			args = append(args, "with-mounted-directory", "--path="+m.Target, "--directory="+m.Source, "--readonly")
+		default: // rw-mount
+			args = append(args, "with-mounted-directory", "--path="+m.Target, "--directory="+m.Source)
+		}
+	}
+	for _, svc := range req.Services {
+		binding := []string{"with-service-binding", "--alias=" + svc.Name, "--address=" + svc.Image}
+		for _, port := range svc.Ports {
+			binding = append(binding, "--port="+strconv.Itoa(port))
+		}
+		for _, name := range sortedKeys(svc.Env) {
+			binding = append(binding, "--env="+name+"="+svc.Env[name])
+		}
+		args = append(args, binding...)
+		// Hostname/port env vars keep workloads portable between backends.
+		args = append(args, "with-env-variable", "--name="+svc.envPrefix()+"_HOST", "--value="+svc.Name)
+		if len(svc.Ports) > 0 {
+			args = append(args, "with-env-variable", "--name="+svc.envPrefix()+"_PORT", "--value="+strconv.Itoa(svc.Ports[0]))
+		}
+	}
+	for _, name := range sortedKeys(req.Env) {
+		args = append(args, "with-env-variable", "--name="+name, "--value="+req.Env[name])
+	}
+	args = append(args, "with-workdir", "--path="+req.Workdir)
+	if req.User != "" {
+		args = append(args, "with-user", "--name="+req.User)
+	}
+	return args
+}
+
+// sortedKeys returns the map's keys in lexical order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
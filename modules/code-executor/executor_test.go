@@ -0,0 +1,412 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPipelineArgs(t *testing.T) {
+	args := pipelineArgs(Request{
+		Image:    "golang:1.21",
+		Worktree: "/repo/.patina/worktrees/demo",
+		Workdir:  DefaultWorkdir,
+		Command:  []string{"go", "test", "./..."},
+	})
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"from --address=golang:1.21",
+		"with-directory --path=" + DefaultWorkdir,
+		"--directory=/repo/.patina/worktrees/demo",
+		"with-workdir --path=" + DefaultWorkdir,
+		"with-exec --expect=any --args=go,test,./...",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+}
+
+func TestPipelineArgsCacheMounts(t *testing.T) {
+	args := pipelineArgs(Request{
+		Image:   "rust:1.79",
+		Command: []string{"cargo", "build"},
+		Caches: map[string]string{
+			"cargo-registry": "/usr/local/cargo/registry",
+			"cargo-target":   "/workspace/project/target",
+		},
+	})
+
+	joined := strings.Join(args, " ")
+	// Mounts are sorted by cache name, so registry precedes target.
+	want := "with-mounted-cache --path=/usr/local/cargo/registry --cache=cargo-registry " +
+		"with-mounted-cache --path=/workspace/project/target --cache=cargo-target"
+	if !strings.Contains(joined, want) {
+		t.Errorf("args missing sorted cache mounts %q: %s", want, joined)
+	}
+}
+
+func TestPipelineArgsUser(t *testing.T) {
+	args := pipelineArgs(Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "build"},
+		User:    "1000:1000",
+	})
+	if !strings.Contains(strings.Join(args, " "), "with-user --name=1000:1000") {
+		t.Errorf("args missing user: %v", args)
+	}
+}
+
+func TestPipelineArgsServiceBindings(t *testing.T) {
+	args := pipelineArgs(Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "test", "./..."},
+		Services: []Service{{
+			Name:  "test-db",
+			Image: "postgres:16",
+			Ports: []int{5432},
+			Env:   map[string]string{"POSTGRES_PASSWORD": "test"},
+		}},
+	})
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"with-service-binding --alias=test-db --address=postgres:16 --port=5432 --env=POSTGRES_PASSWORD=test",
+		"with-env-variable --name=TEST_DB_HOST --value=test-db",
+		"with-env-variable --name=TEST_DB_PORT --value=5432",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	e := New()
+	e.SetConcurrencyLimit(1)
+
+	release, err := e.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// Second acquire should block until released; use a cancelled
+	// context to observe the block without hanging the test.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.acquire(ctx); err == nil {
+		t.Error("expected acquire to fail on cancelled context while slot is held")
+	}
+
+	release()
+	release2, err := e.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestConcurrencyUnlimited(t *testing.T) {
+	e := New()
+	e.SetConcurrencyLimit(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// No limit: even a cancelled context acquires immediately.
+	if _, err := e.acquire(ctx); err != nil {
+		t.Errorf("unlimited acquire failed: %v", err)
+	}
+}
+
+func TestRunPipelineProgress(t *testing.T) {
+	e := New()
+	var labels, steps []string
+	e.SetProgressSink(func(label, step string) {
+		labels = append(labels, label)
+		steps = append(steps, step)
+	})
+
+	stderr := newCapBuffer(0)
+	cmd := exec.Command("sh", "-c", "echo 'pull image' >&2; echo 'run exec' >&2")
+	if err := e.runPipeline(cmd, stderr, "patina-demo", nil); err != nil {
+		t.Fatalf("runPipeline: %v", err)
+	}
+
+	if len(steps) != 2 || steps[0] != "pull image" || steps[1] != "run exec" {
+		t.Errorf("steps = %v, want [pull image, run exec]", steps)
+	}
+	for _, label := range labels {
+		if label != "patina-demo" {
+			t.Errorf("label = %q, want patina-demo", label)
+		}
+	}
+	// Stderr still accumulates for the result, sink or not.
+	if !strings.Contains(stderr.String(), "pull image") {
+		t.Errorf("stderr = %q, want progress lines retained", stderr.String())
+	}
+}
+
+func TestPipelineArgsMounts(t *testing.T) {
+	args := pipelineArgs(Request{
+		Image:   "golang:1.21",
+		Workdir: DefaultWorkdir,
+		Command: []string{"make"},
+		Mounts: []Mount{
+			{Source: "/data/fixtures", Target: "/fixtures", Mode: "ro-mount"},
+			{Source: "/data/scratch", Target: "/scratch"},
+			{Source: "/data/seed", Target: "/seed", Mode: "copy"},
+		},
+	})
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"with-mounted-directory --path=/fixtures --directory=/data/fixtures --readonly",
+		"with-mounted-directory --path=/scratch --directory=/data/scratch",
+		"with-directory --path=/seed --directory=/data/seed",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q in %q", want, joined)
+		}
+	}
+}
+
+// fakeBin writes an executable stand-in for the pipeline CLI that
+// exits with the given code.
+func fakeBin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-cli")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecuteCapturesExitCode(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo partial; exit 7")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "test"},
+	})
+	if err != nil {
+		t.Fatalf("non-zero exit returned an error: %v", err)
+	}
+	if result.ExitCode != 7 {
+		t.Errorf("ExitCode = %d, want 7", result.ExitCode)
+	}
+	if !strings.Contains(result.Stdout, "partial") {
+		t.Errorf("Stdout = %q, want output retained on failure", result.Stdout)
+	}
+}
+
+func TestExecuteReportsRunFailures(t *testing.T) {
+	e := New()
+	e.Bin = "/no/such/binary"
+
+	_, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "test"},
+	})
+	if err == nil {
+		t.Fatal("missing binary did not error")
+	}
+}
+
+func TestExecuteStream(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo out-chunk; echo err-chunk >&2; exit 3")
+
+	var stdout, stderr strings.Builder
+	result, err := e.ExecuteStream(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"make"},
+	},
+		func(chunk []byte) { stdout.Write(chunk) },
+		func(chunk []byte) { stderr.Write(chunk) },
+	)
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "out-chunk") {
+		t.Errorf("streamed stdout = %q, want out-chunk", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "err-chunk") {
+		t.Errorf("streamed stderr = %q, want err-chunk", stderr.String())
+	}
+	// The result still carries the buffered output and the real code.
+	if !strings.Contains(result.Stdout, "out-chunk") || result.ExitCode != 3 {
+		t.Errorf("result = %+v, want buffered output and exit code 3", result)
+	}
+}
+
+func TestExecuteStdin(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "exec cat")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"psql"},
+		Stdin:   []byte("select 1;\n"),
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Stdout != "select 1;\n" {
+		t.Errorf("stdout = %q, want stdin piped through", result.Stdout)
+	}
+}
+
+func TestExecuteBatchShortCircuits(t *testing.T) {
+	e := New()
+	// Fail the step whose command mentions "fail"; succeed otherwise.
+	e.Bin = fakeBin(t, `case "$*" in *--args=make,fail*) echo broken; exit 2;; esac; echo ok`)
+
+	results, err := e.ExecuteBatch(context.Background(), Request{
+		Image: "golang:1.21",
+	}, [][]string{
+		{"make", "deps"},
+		{"make", "fail"},
+		{"make", "never-runs"},
+	})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (short-circuit after failure)", len(results))
+	}
+	if results[0].ExitCode != 0 || !strings.Contains(results[0].Stdout, "ok") {
+		t.Errorf("first step = %+v, want success", results[0])
+	}
+	if results[1].ExitCode != 2 {
+		t.Errorf("second step exit = %d, want 2", results[1].ExitCode)
+	}
+}
+
+func TestExecuteTargetsRemoteEngine(t *testing.T) {
+	e := New()
+	// The fake CLI reports the runner host the real one would honor.
+	e.Bin = fakeBin(t, `echo "$_EXPERIMENTAL_DAGGER_RUNNER_HOST"`)
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"true"},
+		Engine:  "tcp://engine-a:8371",
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := strings.TrimSpace(result.Stdout); got != "tcp://engine-a:8371" {
+		t.Errorf("runner host = %q, want tcp://engine-a:8371", got)
+	}
+}
+
+func TestExecuteTruncatesOutputAtCap(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "head -c 10000 /dev/zero | tr '\\0' x")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:          "alpine:3.20",
+		Command:        []string{"cat", "big.log"},
+		MaxOutputBytes: 1000,
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(result.Stdout) != 1000 {
+		t.Errorf("buffered stdout = %d bytes, want 1000", len(result.Stdout))
+	}
+	if !result.Truncated {
+		t.Error("Truncated = false, want true")
+	}
+	if result.StdoutBytes != 10000 {
+		t.Errorf("StdoutBytes = %d, want the full 10000", result.StdoutBytes)
+	}
+}
+
+func TestExecuteOutputUnderCap(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo small")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"echo", "small"},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Truncated {
+		t.Error("Truncated = true for output under the default cap")
+	}
+	if result.StdoutBytes != int64(len(result.Stdout)) {
+		t.Errorf("StdoutBytes = %d, want %d", result.StdoutBytes, len(result.Stdout))
+	}
+}
+
+func TestCombinedOutputOrdering(t *testing.T) {
+	e := New()
+	// Sleeps separate the writes so arrival order is deterministic
+	// across the two pipes.
+	e.Bin = fakeBin(t, "echo building; sleep 0.1; echo 'error: boom' >&2; sleep 0.1; echo done")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:    "alpine:3.20",
+		Command:  []string{"make"},
+		Combined: true,
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if len(result.Output) != 3 {
+		t.Fatalf("combined output = %d lines, want 3: %+v", len(result.Output), result.Output)
+	}
+	want := []struct{ stream, text string }{
+		{"stdout", "building"},
+		{"stderr", "error: boom"},
+		{"stdout", "done"},
+	}
+	for i, line := range result.Output {
+		if line.Stream != want[i].stream || line.Text != want[i].text {
+			t.Errorf("line %d = %s %q, want %s %q", i, line.Stream, line.Text, want[i].stream, want[i].text)
+		}
+		if line.Time.IsZero() {
+			t.Errorf("line %d has no timestamp", i)
+		}
+	}
+	// The separate buffers still fill alongside the combined stream.
+	if !strings.Contains(result.Stdout, "building") || !strings.Contains(result.Stderr, "boom") {
+		t.Errorf("separate buffers missing output: stdout=%q stderr=%q", result.Stdout, result.Stderr)
+	}
+}
+
+func TestExecuteReportsUsage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rusage accounting is linux-only")
+	}
+	e := New()
+	e.Bin = fakeBin(t, "echo done")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"true"},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Usage == nil {
+		t.Fatal("usage = nil, want rusage-backed report")
+	}
+	if result.Usage.PeakMemoryBytes <= 0 {
+		t.Errorf("peak memory = %d, want > 0", result.Usage.PeakMemoryBytes)
+	}
+	if result.Usage.CPUTime < 0 {
+		t.Errorf("cpu time = %s, want >= 0", result.Usage.CPUTime)
+	}
+}
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FileWriter copies content into a running environment directly,
+// instead of round-tripping `echo ... > file` through exec, which
+// breaks on binary content.
+type FileWriter interface {
+	WriteFile(ctx context.Context, req Request, path string, data []byte, mode fs.FileMode) error
+}
+
+// WriteFile places data at the worktree-relative path. The worktree is
+// live-mounted into pipeline containers, so a host-side write is
+// immediately visible in the environment.
+func (e *Executor) WriteFile(_ context.Context, req Request, path string, data []byte, mode fs.FileMode) error {
+	if req.Worktree == "" {
+		return fmt.Errorf("worktree is required")
+	}
+	cleaned, err := insidePath(path)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(req.Worktree, cleaned)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create parent directories: %w", err)
+	}
+	if err := os.WriteFile(target, data, mode.Perm()); err != nil {
+		return fmt.Errorf("write %s: %w", cleaned, err)
+	}
+	return nil
+}
+
+// WriteFile places data at the workdir-relative path inside the pod,
+// streaming through cat so binary content survives.
+func (e *Kube) WriteFile(ctx context.Context, req Request, path string, data []byte, mode fs.FileMode) error {
+	if req.Pod == "" {
+		return fmt.Errorf("pod is required")
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	cleaned, err := insidePath(path)
+	if err != nil {
+		return err
+	}
+	target := req.Workdir + "/" + filepath.ToSlash(cleaned)
+
+	script := fmt.Sprintf("mkdir -p %s && cat > %s && chmod %o %s",
+		shellQuote(filepath.ToSlash(filepath.Dir(target))), shellQuote(target), mode.Perm(), shellQuote(target))
+	cmd := exec.CommandContext(ctx, e.Bin,
+		"exec", "-i", "-n", e.Namespace, req.Pod, "--", "sh", "-c", script)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("write %s in pod %s: %s", cleaned, req.Pod, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// FileReader pulls content out of a running environment, so build
+// artifacts and generated code can leave a workspace without being
+// committed to git.
+type FileReader interface {
+	ReadFile(ctx context.Context, req Request, path string) ([]byte, error)
+}
+
+// ReadFile returns the worktree-relative file's contents. The worktree
+// is live-mounted into pipeline containers, so the host copy is the
+// environment's copy.
+func (e *Executor) ReadFile(_ context.Context, req Request, path string) ([]byte, error) {
+	if req.Worktree == "" {
+		return nil, fmt.Errorf("worktree is required")
+	}
+	cleaned, err := insidePath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(req.Worktree, cleaned))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", cleaned, err)
+	}
+	return data, nil
+}
+
+// ReadFile returns the workdir-relative file's contents from inside the
+// pod, streaming through cat so binary content survives.
+func (e *Kube) ReadFile(ctx context.Context, req Request, path string) ([]byte, error) {
+	if req.Pod == "" {
+		return nil, fmt.Errorf("pod is required")
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	cleaned, err := insidePath(path)
+	if err != nil {
+		return nil, err
+	}
+	target := req.Workdir + "/" + filepath.ToSlash(cleaned)
+
+	cmd := exec.CommandContext(ctx, e.Bin,
+		"exec", "-n", e.Namespace, req.Pod, "--", "sh", "-c", "cat "+shellQuote(target))
+	var stdout bytes.Buffer
+	var stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("read %s in pod %s: %s", cleaned, req.Pod, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// insidePath validates that path stays inside the environment root and
+// returns it cleaned.
+func insidePath(path string) (string, error) {
+	cleaned := filepath.Clean(path)
+	if cleaned == "." || filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("path %s must be relative to the environment root", path)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	worktree := t.TempDir()
+	e := New()
+
+	// NUL bytes would not survive an echo-through-exec round trip.
+	data := []byte("binary\x00content")
+	err := e.WriteFile(context.Background(), Request{Worktree: worktree},
+		"scripts/run.sh", data, 0o755)
+	if err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(worktree, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("content = %q, want %q", got, data)
+	}
+	info, err := os.Stat(filepath.Join(worktree, "scripts", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("mode = %o, want 0755", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileRejectsEscape(t *testing.T) {
+	e := New()
+	for _, path := range []string{"../outside", "/etc/passwd", "a/../../b", "."} {
+		err := e.WriteFile(context.Background(), Request{Worktree: t.TempDir()},
+			path, []byte("x"), 0o644)
+		if err == nil {
+			t.Errorf("path %q: expected rejection", path)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	worktree := t.TempDir()
+	data := []byte("artifact\x00bytes")
+	if err := os.MkdirAll(filepath.Join(worktree, "dist"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, "dist", "app.bin"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	got, err := e.ReadFile(context.Background(), Request{Worktree: worktree}, "dist/app.bin")
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("content = %q, want %q", got, data)
+	}
+}
+
+func TestReadFileRejectsEscape(t *testing.T) {
+	e := New()
+	for _, path := range []string{"../outside", "/etc/passwd"} {
+		if _, err := e.ReadFile(context.Background(), Request{Worktree: t.TempDir()}, path); err == nil {
+			t.Errorf("path %q: expected rejection", path)
+		}
+	}
+}
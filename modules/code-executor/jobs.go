@@ -0,0 +1,208 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// Job statuses, in lifecycle order.
+const (
+	JobRunning = "running"
+	JobExited  = "exited"
+	JobStopped = "stopped"
+)
+
+// maxJobLogBytes bounds how much output a job retains. When exceeded,
+// the oldest half is dropped so a chatty dev server keeps a useful tail
+// without growing without bound.
+const maxJobLogBytes = 1 << 20
+
+// DefaultJobTimeout bounds a background job when the request doesn't
+// set one. Jobs host long-running processes (dev servers, watchers),
+// so the one-shot exec default would kill them mid-flight.
+const DefaultJobTimeout = 24 * time.Hour
+
+// Job is a point-in-time snapshot of one background execution.
+type Job struct {
+	ID string `json:"id"`
+	// Label groups jobs by owner; the gateway uses workspace IDs.
+	Label     string    `json:"label,omitempty"`
+	Command   []string  `json:"command"`
+	Status    string    `json:"status"`
+	ExitCode  int       `json:"exit_code"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// Error reports why the job failed to run at all (bad image, policy
+	// denial); a non-zero exit of a command that did run is not an error.
+	Error string `json:"error,omitempty"`
+}
+
+// job is the tracked state behind a Job snapshot.
+type job struct {
+	mu      sync.Mutex
+	snap    Job
+	logs    []byte
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// append accumulates job output, keeping the tail once the cap is hit.
+func (b *job) append(chunk []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.logs = append(b.logs, chunk...)
+	if len(b.logs) > maxJobLogBytes {
+		b.logs = append(b.logs[:0], b.logs[len(b.logs)-maxJobLogBytes/2:]...)
+	}
+}
+
+// Jobs runs commands in the background and tracks them by ID, for
+// long-running processes (dev servers, watchers) that a blocking exec
+// can't host.
+type Jobs struct {
+	runner StreamRunner
+	mu     sync.Mutex
+	jobs   map[string]*job
+}
+
+// NewJobs creates a job manager executing through the given runner.
+func NewJobs(runner StreamRunner) *Jobs {
+	return &Jobs{runner: runner, jobs: make(map[string]*job)}
+}
+
+// Start launches the request in the background and returns its job ID
+// immediately. The job runs detached from the caller's context; end it
+// with Stop. Request validation (including the command policy) happens
+// asynchronously, so a rejected command surfaces on the job's Error
+// field rather than here.
+func (j *Jobs) Start(label string, req Request) (string, error) {
+	if len(req.Command) == 0 {
+		return "", fmt.Errorf("command is required")
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultJobTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	id := newJobID()
+	jb := &job{
+		cancel: cancel,
+		snap: Job{
+			ID:        id,
+			Label:     label,
+			Command:   req.Command,
+			Status:    JobRunning,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+	j.mu.Lock()
+	j.jobs[id] = jb
+	j.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, err := j.runner.ExecuteStream(ctx, req, jb.append, jb.append)
+
+		jb.mu.Lock()
+		defer jb.mu.Unlock()
+		jb.snap.EndedAt = time.Now().UTC()
+		switch {
+		case jb.stopped:
+			jb.snap.Status = JobStopped
+		case err != nil:
+			jb.snap.Status = JobExited
+			jb.snap.ExitCode = -1
+			jb.snap.Error = err.Error()
+		default:
+			jb.snap.Status = JobExited
+			jb.snap.ExitCode = result.ExitCode
+		}
+	}()
+	return id, nil
+}
+
+// Status returns a snapshot of the job.
+func (j *Jobs) Status(id string) (Job, error) {
+	jb, err := j.get(id)
+	if err != nil {
+		return Job{}, err
+	}
+	jb.mu.Lock()
+	defer jb.mu.Unlock()
+	return jb.snap, nil
+}
+
+// Logs returns the job's retained output (stdout and stderr
+// interleaved), truncated to the most recent maxJobLogBytes.
+func (j *Jobs) Logs(id string) (string, error) {
+	jb, err := j.get(id)
+	if err != nil {
+		return "", err
+	}
+	jb.mu.Lock()
+	defer jb.mu.Unlock()
+	return string(jb.logs), nil
+}
+
+// Stop terminates a running job. Stopping an already-finished job is a
+// no-op.
+func (j *Jobs) Stop(id string) error {
+	jb, err := j.get(id)
+	if err != nil {
+		return err
+	}
+	jb.mu.Lock()
+	if jb.snap.Status == JobRunning {
+		jb.stopped = true
+	}
+	jb.mu.Unlock()
+	jb.cancel()
+	return nil
+}
+
+// List returns snapshots of the label's jobs, oldest first. An empty
+// label lists everything.
+func (j *Jobs) List(label string) []Job {
+	j.mu.Lock()
+	tracked := make([]*job, 0, len(j.jobs))
+	for _, jb := range j.jobs {
+		tracked = append(tracked, jb)
+	}
+	j.mu.Unlock()
+
+	out := make([]Job, 0, len(tracked))
+	for _, jb := range tracked {
+		jb.mu.Lock()
+		snap := jb.snap
+		jb.mu.Unlock()
+		if label == "" || snap.Label == label {
+			out = append(out, snap)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].StartedAt.Before(out[b].StartedAt) })
+	return out
+}
+
+// get looks up tracked job state by ID.
+func (j *Jobs) get(id string) (*job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jb, ok := j.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("job %s: %w", id, errdefs.ErrNotFound)
+	}
+	return jb, nil
+}
+
+func newJobID() string {
+	buf := make([]byte, 6)
+	rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+	return "job-" + hex.EncodeToString(buf)
+}
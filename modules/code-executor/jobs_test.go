@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// waitForJob polls until the job leaves the running state.
+func waitForJob(t *testing.T, jobs *Jobs, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.Status(id)
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+		if job.Status != JobRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for job to finish")
+	return Job{}
+}
+
+func TestJobCapturesExitAndLogs(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo job-output; exit 3")
+	jobs := NewJobs(e)
+
+	id, err := jobs.Start("ws-1", Request{
+		Image:   "golang:1.21",
+		Command: []string{"npm", "run", "dev"},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	job := waitForJob(t, jobs, id)
+	if job.Status != JobExited {
+		t.Errorf("status = %q, want %q", job.Status, JobExited)
+	}
+	if job.ExitCode != 3 {
+		t.Errorf("exit code = %d, want 3", job.ExitCode)
+	}
+	logs, err := jobs.Logs(id)
+	if err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if !strings.Contains(logs, "job-output") {
+		t.Errorf("logs missing command output: %q", logs)
+	}
+}
+
+func TestJobStop(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "sleep 30")
+	jobs := NewJobs(e)
+
+	id, err := jobs.Start("ws-1", Request{
+		Image:   "golang:1.21",
+		Command: []string{"sleep", "30"},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := jobs.Stop(id); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	job := waitForJob(t, jobs, id)
+	if job.Status != JobStopped {
+		t.Errorf("status = %q, want %q", job.Status, JobStopped)
+	}
+}
+
+func TestJobListFiltersByLabel(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "exit 0")
+	jobs := NewJobs(e)
+
+	for _, label := range []string{"ws-1", "ws-1", "ws-2"} {
+		if _, err := jobs.Start(label, Request{
+			Image:   "golang:1.21",
+			Command: []string{"true"},
+		}); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+	}
+
+	if got := len(jobs.List("ws-1")); got != 2 {
+		t.Errorf("List(ws-1) = %d jobs, want 2", got)
+	}
+	if got := len(jobs.List("")); got != 3 {
+		t.Errorf("List(\"\") = %d jobs, want 3", got)
+	}
+}
+
+func TestJobNotFound(t *testing.T) {
+	jobs := NewJobs(New())
+	if _, err := jobs.Status("job-missing"); !errdefs.IsNotFound(err) {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+}
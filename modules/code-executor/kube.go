@@ -0,0 +1,194 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Kube runs commands in workspace pods through the Kubernetes exec
+// subresource (via kubectl). It pairs with the provider's Kubernetes
+// backend, where each workspace is a long-running pod.
+type Kube struct {
+	// Bin is the kubectl binary to invoke. Defaults to "kubectl".
+	Bin string
+	// Namespace is where workspace pods live.
+	Namespace string
+	// policy restricts which commands may execute.
+	policy CommandPolicy
+	// middleware wraps every execution, first-registered outermost.
+	middleware []Middleware
+}
+
+// Use appends a middleware to the execution chain. Every Execute and
+// ExecuteStream call flows through it; register before serving
+// requests, not concurrently with them.
+func (e *Kube) Use(m Middleware) {
+	e.middleware = append(e.middleware, m)
+}
+
+// SetCommandPolicy restricts which commands this executor will run.
+func (e *Kube) SetCommandPolicy(policy CommandPolicy) {
+	e.policy = policy
+}
+
+// NewKube creates a Kubernetes executor using kubectl from PATH.
+func NewKube(namespace string) *Kube {
+	return &Kube{Bin: "kubectl", Namespace: namespace}
+}
+
+// Execute runs the request inside the workspace pod.
+func (e *Kube) Execute(ctx context.Context, req Request) (*Result, error) {
+	return chainMiddleware(e.middleware, func(ctx context.Context, req Request) (*Result, error) {
+		return e.execute(ctx, req, nil, nil)
+	})(ctx, req)
+}
+
+// ExecuteStream runs the request, delivering output through the
+// callbacks as the command produces it.
+func (e *Kube) ExecuteStream(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	return chainMiddleware(e.middleware, func(ctx context.Context, req Request) (*Result, error) {
+		return e.execute(ctx, req, onStdout, onStderr)
+	})(ctx, req)
+}
+
+// execute runs the request, re-running it per the retry policy.
+func (e *Kube) execute(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	result, err := e.executeOnce(ctx, req, onStdout, onStderr)
+	for attempt := 1; req.Retry.shouldRetry(attempt, result, err); attempt++ {
+		if !req.Retry.wait(ctx, attempt) {
+			break
+		}
+		result, err = e.executeOnce(ctx, req, onStdout, onStderr)
+	}
+	return result, err
+}
+
+func (e *Kube) executeOnce(ctx context.Context, req Request, onStdout, onStderr StreamFunc) (*Result, error) {
+	if req.Pod == "" {
+		return nil, fmt.Errorf("pod is required")
+	}
+	if len(req.Command) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultTimeout
+	}
+	if err := e.policy.Check(req.Command); err != nil {
+		return nil, err
+	}
+	if err := e.policy.ConfirmCheck(req.Command, req.Confirmed); err != nil {
+		return nil, err
+	}
+
+	args := kubeExecArgs(req, e.Namespace)
+	if req.DryRun {
+		return &Result{Stdout: e.Bin + " " + strings.Join(args, " ")}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.Bin, args...)
+	if len(req.Stdin) > 0 {
+		cmd.Stdin = bytes.NewReader(req.Stdin)
+	}
+
+	var combined *combinedLog
+	if req.Combined {
+		combined = newCombinedLog(req.MaxOutputBytes)
+		onStdout = chainStream(onStdout, func(p []byte) { combined.add("stdout", p) })
+		onStderr = chainStream(onStderr, func(p []byte) { combined.add("stderr", p) })
+	}
+
+	stdout := newCapBuffer(req.MaxOutputBytes)
+	stderr := newCapBuffer(req.MaxOutputBytes)
+	cmd.Stdout = io.Writer(stdout)
+	cmd.Stderr = io.Writer(stderr)
+	if onStdout != nil {
+		cmd.Stdout = io.MultiWriter(stdout, streamWriter{onStdout})
+	}
+	if onStderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, streamWriter{onStderr})
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Stdout:      stdout.String(),
+		Stderr:      stderr.String(),
+		Duration:    time.Since(start),
+		Truncated:   stdout.truncated() || stderr.truncated(),
+		StdoutBytes: stdout.total,
+		StderrBytes: stderr.total,
+	}
+	if combined != nil {
+		result.Output = combined.snapshot()
+	}
+	result.Usage = collectUsage(cmd)
+
+	if err != nil {
+		// Surface the context's own sentinel so callers can tell a
+		// cancelled or timed-out request from a genuine execution failure.
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, fmt.Errorf("execution timed out after %s: %w", req.Timeout, context.DeadlineExceeded)
+		}
+		if ctx.Err() == context.Canceled {
+			return result, fmt.Errorf("execution cancelled: %w", context.Canceled)
+		}
+		// kubectl exec propagates the command's exit status; a failing
+		// command is a normal result, not an execution error.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+			return result, nil
+		}
+		return result, fmt.Errorf("exec in pod %s: %w", req.Pod, err)
+	}
+	return result, nil
+}
+
+// kubeExecArgs builds the kubectl exec invocation. kubectl has no
+// working-directory flag, so the command runs through sh -c with a cd.
+// Request env vars run through env(1): the pod's containers carry the
+// workspace env already, but per-exec vars (e.g. link peers) only exist
+// in the request.
+func kubeExecArgs(req Request, namespace string) []string {
+	command := shellJoin(req.Command)
+	if len(req.Env) > 0 {
+		pairs := make([]string, 0, len(req.Env))
+		for _, name := range sortedKeys(req.Env) {
+			pairs = append(pairs, name+"="+shellQuote(req.Env[name]))
+		}
+		command = "env " + strings.Join(pairs, " ") + " " + command
+	}
+	script := "cd " + shellQuote(req.Workdir) + " && " + command
+	args := []string{"exec"}
+	if len(req.Stdin) > 0 {
+		// -i wires kubectl's stdin through to the command.
+		args = append(args, "-i")
+	}
+	return append(args, "-n", namespace, req.Pod, "--", "sh", "-c", script)
+}
+
+// shellJoin quotes each argument for safe use in a sh -c script.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote single-quotes a string for the shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
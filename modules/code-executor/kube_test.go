@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKubeExecArgs(t *testing.T) {
+	req := Request{
+		Pod:     "patina-demo",
+		Workdir: "/src",
+		Command: []string{"echo", "hello world"},
+	}
+
+	args := kubeExecArgs(req, "patina")
+	joined := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"exec -n patina patina-demo --",
+		"sh -c",
+		"cd '/src' && 'echo' 'hello world'",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+}
+
+func TestKubeExecArgsEnv(t *testing.T) {
+	req := Request{
+		Pod:     "patina-demo",
+		Command: []string{"curl", "$LINK_BACKEND_HOST"},
+		Env:     map[string]string{"LINK_BACKEND_HOST": "10.1.2.3"},
+	}
+
+	joined := strings.Join(kubeExecArgs(req, "patina"), " ")
+	if !strings.Contains(joined, "env LINK_BACKEND_HOST='10.1.2.3' 'curl'") {
+		t.Errorf("args missing env injection: %s", joined)
+	}
+}
+
+func TestShellJoinQuoting(t *testing.T) {
+	got := shellJoin([]string{"echo", "it's"})
+	want := `'echo' 'it'\''s'`
+	if got != want {
+		t.Errorf("shellJoin = %s, want %s", got, want)
+	}
+}
+
+func TestKubeCapturesExitCode(t *testing.T) {
+	e := NewKube("patina")
+	e.Bin = fakeBin(t, "exit 42")
+
+	result, err := e.Execute(context.Background(), Request{
+		Pod:     "patina-demo",
+		Command: []string{"go", "test"},
+	})
+	if err != nil {
+		t.Fatalf("non-zero exit returned an error: %v", err)
+	}
+	if result.ExitCode != 42 {
+		t.Errorf("ExitCode = %d, want 42", result.ExitCode)
+	}
+}
+
+func TestKubeExecArgsStdin(t *testing.T) {
+	req := Request{
+		Pod:     "patina-demo",
+		Command: []string{"psql"},
+		Stdin:   []byte("select 1;\n"),
+	}
+
+	joined := strings.Join(kubeExecArgs(req, "patina"), " ")
+	if !strings.Contains(joined, "exec -i -n patina patina-demo --") {
+		t.Errorf("args missing -i for stdin: %s", joined)
+	}
+}
@@ -0,0 +1,23 @@
+package executor
+
+import "context"
+
+// ExecFunc is one execution step as middleware sees it: the request
+// going down, the result coming back.
+type ExecFunc func(ctx context.Context, req Request) (*Result, error)
+
+// Middleware wraps an ExecFunc with a cross-cutting concern — logging,
+// metrics, secret scrubbing of output — layered once on the executor
+// instead of at every call site. A middleware may rewrite the request
+// before calling next, the result after, or skip next entirely.
+type Middleware func(next ExecFunc) ExecFunc
+
+// chainMiddleware applies the chain around fn. The first-registered
+// middleware is outermost: it sees the request first and the result
+// last.
+func chainMiddleware(chain []Middleware, fn ExecFunc) ExecFunc {
+	for i := len(chain) - 1; i >= 0; i-- {
+		fn = chain[i](fn)
+	}
+	return fn
+}
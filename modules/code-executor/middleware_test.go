@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo ran")
+
+	var order []string
+	note := func(name string) Middleware {
+		return func(next ExecFunc) ExecFunc {
+			return func(ctx context.Context, req Request) (*Result, error) {
+				order = append(order, name+" before")
+				result, err := next(ctx, req)
+				order = append(order, name+" after")
+				return result, err
+			}
+		}
+	}
+	e.Use(note("outer"))
+	e.Use(note("inner"))
+
+	if _, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"true"},
+	}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	want := "outer before, inner before, inner after, outer after"
+	if got := strings.Join(order, ", "); got != want {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareScrubsOutput(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo token=hunter2")
+
+	e.Use(func(next ExecFunc) ExecFunc {
+		return func(ctx context.Context, req Request) (*Result, error) {
+			result, err := next(ctx, req)
+			if result != nil {
+				result.Stdout = strings.ReplaceAll(result.Stdout, "hunter2", "[redacted]")
+			}
+			return result, err
+		}
+	})
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"env"},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := strings.TrimSpace(result.Stdout); got != "token=[redacted]" {
+		t.Errorf("stdout = %q, want scrubbed token", got)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	e := New()
+	// The fake CLI would fail loudly; the middleware must stop the call
+	// before it runs.
+	e.Bin = fakeBin(t, "echo should not run >&2; exit 1")
+
+	e.Use(func(next ExecFunc) ExecFunc {
+		return func(ctx context.Context, req Request) (*Result, error) {
+			return nil, fmt.Errorf("denied by middleware")
+		}
+	})
+
+	if _, err := e.Execute(context.Background(), Request{
+		Image:   "alpine:3.20",
+		Command: []string{"true"},
+	}); err == nil || err.Error() != "denied by middleware" {
+		t.Errorf("err = %v, want denied by middleware", err)
+	}
+}
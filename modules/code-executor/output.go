@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxOutputBytes caps how much stdout and stderr a Result
+// buffers (each) unless the request overrides it. A stray cat of a
+// huge log should truncate the response, not balloon gateway memory.
+const DefaultMaxOutputBytes = 4 << 20
+
+// capBuffer accumulates command output up to a byte cap, counting
+// every byte it is offered so truncation can be reported alongside the
+// full size.
+type capBuffer struct {
+	max   int
+	total int64
+	buf   strings.Builder
+}
+
+// newCapBuffer creates a buffer capped at max bytes. Zero applies
+// DefaultMaxOutputBytes; negative means unlimited.
+func newCapBuffer(max int) *capBuffer {
+	if max == 0 {
+		max = DefaultMaxOutputBytes
+	}
+	return &capBuffer{max: max}
+}
+
+func (b *capBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if b.max > 0 {
+		if room := b.max - b.buf.Len(); room < len(p) {
+			if room > 0 {
+				b.buf.Write(p[:room])
+			}
+			// Report the full length: the writer accepted the bytes, it
+			// just chose not to keep them.
+			return len(p), nil
+		}
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (b *capBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+func (b *capBuffer) String() string { return b.buf.String() }
+
+// truncated reports whether any output was dropped at the cap.
+func (b *capBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
+
+// OutputLine is one line of combined command output: which stream it
+// came from and when it arrived. Separate stdout/stderr strings lose
+// the interleaving that explains a build failure; this keeps it.
+type OutputLine struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream"`
+	Text   string    `json:"text"`
+}
+
+// combinedLog collects both streams into one time-ordered line list.
+// Ordering is by arrival at the executor, which is as close to
+// production order as separate pipes allow.
+type combinedLog struct {
+	mu    sync.Mutex
+	max   int
+	total int
+	lines []OutputLine
+	// partial buffers an unterminated trailing line per stream.
+	partial map[string][]byte
+}
+
+func newCombinedLog(max int) *combinedLog {
+	if max == 0 {
+		max = DefaultMaxOutputBytes
+	}
+	return &combinedLog{max: max, partial: make(map[string][]byte)}
+}
+
+// add appends one chunk of a stream, splitting it into lines. Chunks
+// past the byte cap are dropped; the capped buffers already report
+// truncation.
+func (l *combinedLog) add(stream string, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.max > 0 && l.total > l.max {
+		return
+	}
+	l.total += len(p)
+
+	now := time.Now().UTC()
+	buf := append(l.partial[stream], p...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.lines = append(l.lines, OutputLine{Time: now, Stream: stream, Text: string(buf[:i])})
+		buf = buf[i+1:]
+	}
+	l.partial[stream] = buf
+}
+
+// snapshot flushes unterminated lines and returns the ordered list.
+func (l *combinedLog) snapshot() []OutputLine {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, stream := range []string{"stdout", "stderr"} {
+		if rest := l.partial[stream]; len(rest) > 0 {
+			l.lines = append(l.lines, OutputLine{Time: now, Stream: stream, Text: string(rest)})
+			l.partial[stream] = nil
+		}
+	}
+	return l.lines
+}
+
+// chainStream fans one chunk out to both callbacks; either may be nil.
+func chainStream(a, b StreamFunc) StreamFunc {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return func(p []byte) {
+		a(p)
+		b(p)
+	}
+}
@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// CommandPolicy inspects commands before execution, so teams letting
+// agents run on shared infrastructure can stop destructive or
+// exfiltrating commands (`rm -rf /`, curl-pipe-to-shell) before the
+// container does. Patterns are regular expressions matched against the
+// space-joined command line.
+type CommandPolicy struct {
+	// Allow lists patterns a command must match. Empty allows all
+	// commands (minus denials).
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists patterns that reject a command even when allowed. Deny
+	// wins over allow.
+	Deny []string `json:"deny,omitempty"`
+	// Confirm lists patterns a command may only run with explicit
+	// caller confirmation — a middle ground for commands too useful to
+	// deny outright but too dangerous for an autonomous agent to fire
+	// unreviewed.
+	Confirm []string `json:"confirm,omitempty"`
+}
+
+// Check returns an errdefs.ErrForbidden error when the command is not
+// entitled under the policy. Unparseable patterns fail closed: a policy
+// that can't be compiled blocks rather than silently allowing.
+func (p CommandPolicy) Check(command []string) error {
+	line := strings.Join(command, " ")
+	for _, pattern := range p.Deny {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("command policy: bad deny pattern %q: %v: %w", pattern, err, errdefs.ErrForbidden)
+		}
+		if re.MatchString(line) {
+			return fmt.Errorf("command denied by policy (%s): %w", pattern, errdefs.ErrForbidden)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range p.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("command policy: bad allow pattern %q: %v: %w", pattern, err, errdefs.ErrForbidden)
+		}
+		if re.MatchString(line) {
+			return nil
+		}
+	}
+	return fmt.Errorf("command not in policy allowlist: %w", errdefs.ErrForbidden)
+}
+
+// ConfirmCheck returns an errdefs.ErrConflict error when the command
+// matches a confirm pattern and the request did not carry
+// confirmation. Conflict rather than forbidden: the caller can resolve
+// it by resending the request confirmed.
+func (p CommandPolicy) ConfirmCheck(command []string, confirmed bool) error {
+	if confirmed || len(p.Confirm) == 0 {
+		return nil
+	}
+	line := strings.Join(command, " ")
+	for _, pattern := range p.Confirm {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("command policy: bad confirm pattern %q: %v: %w", pattern, err, errdefs.ErrForbidden)
+		}
+		if re.MatchString(line) {
+			return fmt.Errorf("command requires confirmation (%s): resend with confirmed set: %w", pattern, errdefs.ErrConflict)
+		}
+	}
+	return nil
+}
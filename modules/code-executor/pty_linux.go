@@ -0,0 +1,80 @@
+//go:build linux
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// ptmx ioctls not exposed by the syscall package.
+const (
+	ioctlGetPTYNumber = 0x80045430 // TIOCGPTN
+	ioctlUnlockPTY    = 0x40045431 // TIOCSPTLCK
+)
+
+// startSession runs the command with a freshly allocated PTY as its
+// controlling terminal and returns the session holding the master side.
+func startSession(cmd *exec.Cmd) (*Session, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	// The child holds its own descriptor now; keeping ours open would
+	// stop reads from returning EOF when the command exits.
+	slave.Close()
+
+	return &Session{pty: master, cmd: cmd}, nil
+}
+
+// openPTY allocates a pseudo-terminal pair via /dev/ptmx.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open ptmx: %w", err)
+	}
+
+	var unlock int32
+	if err := ioctl(master.Fd(), ioctlUnlockPTY, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("unlock pty: %w", err)
+	}
+	var number uint32
+	if err := ioctl(master.Fd(), ioctlGetPTYNumber, uintptr(unsafe.Pointer(&number))); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("resolve pty number: %w", err)
+	}
+
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("open pty slave: %w", err)
+	}
+	return master, slave, nil
+}
+
+// resizePTY sets the terminal's window size.
+func resizePTY(f *os.File, cols, rows int) error {
+	size := struct{ rows, cols, x, y uint16 }{uint16(rows), uint16(cols), 0, 0}
+	return ioctl(f.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&size)))
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Interactive sessions need a PTY; only the Linux allocation is
+// implemented. Gateways run on Linux hosts alongside the container
+// runtime, so this only bites local development on other platforms.
+
+func startSession(cmd *exec.Cmd) (*Session, error) {
+	return nil, fmt.Errorf("interactive sessions are only supported on linux")
+}
+
+func resizePTY(f *os.File, cols, rows int) error {
+	return fmt.Errorf("interactive sessions are only supported on linux")
+}
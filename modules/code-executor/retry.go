@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// Retry retries a command that exits non-zero, for flaky
+// network-dependent work (package installs, git fetches) that callers
+// would otherwise each re-implement. Only command failures are retried;
+// pipeline errors and timeouts are not, and each attempt gets the
+// request's full timeout.
+type Retry struct {
+	// Attempts is the total number of tries; 0 or 1 means no retry.
+	Attempts int `json:"attempts,omitempty"`
+	// BackoffSeconds is the wait before the first retry, doubling each
+	// attempt. 0 means one second.
+	BackoffSeconds int `json:"backoff_seconds,omitempty"`
+	// On are regexes matched against the failed attempt's output; the
+	// command is only retried when one matches, so deterministic
+	// failures (compile errors) don't burn attempts. Empty retries any
+	// non-zero exit. Bad patterns never match.
+	On []string `json:"on,omitempty"`
+}
+
+// shouldRetry reports whether the attempt's outcome warrants another
+// try. attempt is 1-based.
+func (r Retry) shouldRetry(attempt int, result *Result, err error) bool {
+	if attempt >= r.Attempts {
+		return false
+	}
+	// Pipeline errors and timeouts surface as err; only a command that
+	// ran and exited non-zero is a retry candidate.
+	if err != nil || result == nil || result.ExitCode == 0 {
+		return false
+	}
+	if len(r.On) == 0 {
+		return true
+	}
+	output := result.Stdout + "\n" + result.Stderr
+	for _, pattern := range r.On {
+		re, compileErr := regexp.Compile(pattern)
+		if compileErr != nil {
+			continue
+		}
+		if re.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// wait sleeps the backoff for the given 1-based attempt, doubling each
+// retry. Returns false when the context ends first.
+func (r Retry) wait(ctx context.Context, attempt int) bool {
+	backoff := time.Duration(r.BackoffSeconds) * time.Second
+	if backoff == 0 {
+		backoff = time.Second
+	}
+	backoff <<= attempt - 1
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// flakyBin writes a fake CLI that fails with the given message until
+// marker exists, then succeeds. Each invocation appends to a count file
+// next to the binary.
+func flakyBin(t *testing.T, failures int, message string) string {
+	t.Helper()
+	return fakeBin(t, `count="$0.count"
+echo x >> "$count"
+if [ "$(wc -l < "$count")" -le `+strconv.Itoa(failures)+` ]; then
+  echo "`+message+`" >&2
+  exit 1
+fi
+echo ok`)
+}
+
+func attempts(t *testing.T, bin string) int {
+	t.Helper()
+	data, err := os.ReadFile(bin + ".count")
+	if err != nil {
+		t.Fatalf("read attempt count: %v", err)
+	}
+	return strings.Count(string(data), "x")
+}
+
+func TestRetryRecoversTransientFailure(t *testing.T) {
+	e := New()
+	e.Bin = flakyBin(t, 1, "connection reset by peer")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"apt-get", "update"},
+		Retry:   Retry{Attempts: 3},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("exit code = %d, want 0 after retry", result.ExitCode)
+	}
+	if got := attempts(t, e.Bin); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestRetryClassifierSkipsDeterministicFailures(t *testing.T) {
+	e := New()
+	e.Bin = flakyBin(t, 9, "syntax error")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"go", "build"},
+		Retry:   Retry{Attempts: 3, On: []string{"connection reset"}},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("expected failure to stick")
+	}
+	if got := attempts(t, e.Bin); got != 1 {
+		t.Errorf("attempts = %d, want 1 (classifier should not match)", got)
+	}
+}
+
+func TestRetryStopsAfterAttempts(t *testing.T) {
+	e := New()
+	e.Bin = flakyBin(t, 9, "connection reset by peer")
+
+	result, err := e.Execute(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"git", "fetch"},
+		Retry:   Retry{Attempts: 2},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.ExitCode == 0 {
+		t.Error("expected failure to stick")
+	}
+	if got := attempts(t, e.Bin); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
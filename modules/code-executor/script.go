@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ScriptRunner runs a multi-line script with a chosen interpreter.
+// Callers otherwise flatten scripts into sh -c one-liners, which breaks
+// on quoting and loses line numbers in error output.
+type ScriptRunner interface {
+	ExecuteScript(ctx context.Context, req Request, script, shell string) (*Result, error)
+}
+
+// ExecuteScript runs script with the interpreter (sh when empty)
+// inside the pipeline container. Everything else about the execution —
+// image, mounts, env, caps, retries — comes from the request.
+func (e *Executor) ExecuteScript(ctx context.Context, req Request, script, shell string) (*Result, error) {
+	return executeScript(ctx, e, req, script, shell)
+}
+
+// ExecuteScript runs script with the interpreter (sh when empty)
+// inside the workspace pod.
+func (e *Kube) ExecuteScript(ctx context.Context, req Request, script, shell string) (*Result, error) {
+	return executeScript(ctx, e, req, script, shell)
+}
+
+// executeScript delivers the script over stdin: interpreters execute
+// stdin when invoked with no file argument, so nothing needs staging
+// or cleaning up inside the container. The trade-off is that the
+// script itself cannot read request stdin, so a populated Stdin is
+// rejected rather than silently swallowed by the interpreter.
+func executeScript(ctx context.Context, r Runner, req Request, script, shell string) (*Result, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, fmt.Errorf("script is required")
+	}
+	if len(req.Stdin) > 0 {
+		return nil, fmt.Errorf("script execution reserves stdin for the script")
+	}
+	if shell == "" {
+		shell = "sh"
+	}
+	req.Command = []string{shell}
+	req.Stdin = []byte(script)
+	return r.Execute(ctx, req)
+}
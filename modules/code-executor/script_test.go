@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecuteScriptMultiline(t *testing.T) {
+	e := New()
+	// The fake CLI hands its stdin — the script — to a real shell.
+	e.Bin = fakeBin(t, "exec sh")
+
+	script := `greeting=hello
+for n in 1 2; do
+  echo "$greeting $n"
+done`
+	result, err := e.ExecuteScript(context.Background(), Request{
+		Image: "alpine:3.20",
+	}, script, "")
+	if err != nil {
+		t.Fatalf("execute script: %v", err)
+	}
+	if got := strings.TrimSpace(result.Stdout); got != "hello 1\nhello 2" {
+		t.Errorf("stdout = %q, want the loop's two lines", got)
+	}
+}
+
+func TestExecuteScriptValidation(t *testing.T) {
+	e := New()
+	e.Bin = fakeBin(t, "exec sh")
+
+	if _, err := e.ExecuteScript(context.Background(), Request{Image: "alpine:3.20"}, "  \n", ""); err == nil {
+		t.Error("empty script accepted, want error")
+	}
+	req := Request{Image: "alpine:3.20", Stdin: []byte("data")}
+	if _, err := e.ExecuteScript(context.Background(), req, "cat", ""); err == nil {
+		t.Error("script with request stdin accepted, want error")
+	}
+}
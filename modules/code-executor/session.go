@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Session is one interactive command attached to a pseudo-terminal.
+// Reads return terminal output (including the echo of typed input);
+// writes feed the command's stdin.
+type Session struct {
+	pty *os.File
+	cmd *exec.Cmd
+}
+
+func (s *Session) Read(p []byte) (int, error) { return s.pty.Read(p) }
+
+func (s *Session) Write(p []byte) (int, error) { return s.pty.Write(p) }
+
+// Resize adjusts the PTY to the client's terminal dimensions.
+func (s *Session) Resize(cols, rows int) error { return resizePTY(s.pty, cols, rows) }
+
+// Close terminates the session's process and releases the PTY.
+func (s *Session) Close() error {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill() //nolint:errcheck
+		s.cmd.Wait()         //nolint:errcheck
+	}
+	return s.pty.Close()
+}
+
+// InteractiveRunner is a Runner that can open PTY-backed sessions, for
+// shells and browser terminals instead of one-shot commands.
+type InteractiveRunner interface {
+	Runner
+	Interactive(ctx context.Context, req Request) (*Session, error)
+}
+
+// Interactive opens a PTY-backed session in the environment through the
+// CLI's terminal step. An empty command means a plain shell.
+func (e *Executor) Interactive(ctx context.Context, req Request) (*Session, error) {
+	if req.Image == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+	if len(req.Command) == 0 {
+		req.Command = []string{"sh"}
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	if err := e.policy.Check(req.Command); err != nil {
+		return nil, err
+	}
+	if err := e.policy.ConfirmCheck(req.Command, req.Confirmed); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Bin, interactiveArgs(req)...)
+	if req.Engine != "" {
+		cmd.Env = append(os.Environ(), runnerHostEnv+"="+req.Engine)
+	}
+	return startSession(cmd)
+}
+
+// interactiveArgs builds the dagger pipeline for a session: the same
+// container setup as an exec, ending in a terminal step instead of
+// with-exec/stdout.
+func interactiveArgs(req Request) []string {
+	args := basePipelineArgs(req)
+	args = append(args, "terminal", "--cmd="+strings.Join(req.Command, ","))
+	return args
+}
+
+// Interactive opens a PTY-backed session in the workspace pod via
+// kubectl exec -it. An empty command means a plain shell.
+func (e *Kube) Interactive(ctx context.Context, req Request) (*Session, error) {
+	if req.Pod == "" {
+		return nil, fmt.Errorf("pod is required")
+	}
+	if len(req.Command) == 0 {
+		req.Command = []string{"sh"}
+	}
+	if req.Workdir == "" {
+		req.Workdir = DefaultWorkdir
+	}
+	if err := e.policy.Check(req.Command); err != nil {
+		return nil, err
+	}
+	if err := e.policy.ConfirmCheck(req.Command, req.Confirmed); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Bin, kubeAttachArgs(req, e.Namespace)...)
+	return startSession(cmd)
+}
+
+// kubeAttachArgs builds the kubectl exec invocation for an interactive
+// session: like kubeExecArgs, but with stdin and a TTY requested.
+func kubeAttachArgs(req Request, namespace string) []string {
+	script := "cd " + shellQuote(req.Workdir) + " && exec " + shellJoin(req.Command)
+	return []string{"exec", "-i", "-t", "-n", namespace, req.Pod, "--", "sh", "-c", script}
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInteractiveArgs(t *testing.T) {
+	args := interactiveArgs(Request{
+		Image:    "golang:1.21",
+		Worktree: "/repo/.patina/worktrees/demo",
+		Workdir:  DefaultWorkdir,
+		Command:  []string{"bash", "-l"},
+	})
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"from --address=golang:1.21",
+		"--directory=/repo/.patina/worktrees/demo",
+		"terminal --cmd=bash,-l",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "with-exec") {
+		t.Errorf("interactive args must not contain with-exec: %s", joined)
+	}
+}
+
+func TestKubeAttachArgs(t *testing.T) {
+	args := kubeAttachArgs(Request{
+		Pod:     "patina-demo",
+		Workdir: "/src",
+		Command: []string{"bash"},
+	}, "patina")
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"exec -i -t -n patina patina-demo --",
+		"cd '/src' && exec 'bash'",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+}
+
+func TestInteractiveDeniedByPolicy(t *testing.T) {
+	e := New()
+	e.SetCommandPolicy(CommandPolicy{Deny: []string{`^bash`}})
+
+	if _, err := e.Interactive(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"bash"},
+	}); err == nil {
+		t.Fatal("expected denied command to fail")
+	}
+}
+
+func TestInteractiveSessionRoundTrip(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("PTY allocation is linux-only")
+	}
+	e := New()
+	// The fake CLI ignores the pipeline args and echoes its stdin, so a
+	// write comes back on the PTY (twice: terminal echo plus cat).
+	e.Bin = fakeBin(t, "exec cat")
+
+	session, err := e.Interactive(context.Background(), Request{
+		Image:   "golang:1.21",
+		Command: []string{"sh"},
+	})
+	if err != nil {
+		t.Fatalf("interactive: %v", err)
+	}
+	defer session.Close()
+
+	if err := session.Resize(80, 24); err != nil {
+		t.Fatalf("resize: %v", err)
+	}
+	if _, err := session.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 256)
+		var out strings.Builder
+		for !strings.Contains(out.String(), "ping") {
+			n, err := session.Read(buf)
+			if n > 0 {
+				out.Write(buf[:n])
+			}
+			if err != nil {
+				break
+			}
+		}
+		done <- out.String()
+	}()
+
+	select {
+	case out := <-done:
+		if !strings.Contains(out, "ping") {
+			t.Errorf("session output missing echo: %q", out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for session output")
+	}
+}
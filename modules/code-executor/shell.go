@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shell is a persistent shell session: every Run executes in the same
+// long-lived shell process, so `cd` and `export` survive between
+// commands instead of being replayed on every exec. The shell runs on a
+// PTY, which interleaves stderr into the captured output.
+type Shell struct {
+	session *Session
+	// mu serializes Runs: the protocol is one command, one sentinel,
+	// and interleaved writers would corrupt it.
+	mu sync.Mutex
+	// lines delivers shell output line by line; closed when the
+	// session's output ends.
+	lines chan string
+}
+
+// ShellRunner is a Runner that can open persistent shell sessions.
+type ShellRunner interface {
+	Runner
+	OpenShell(ctx context.Context, req Request) (*Shell, error)
+}
+
+// OpenShell starts a persistent shell in the environment. The request's
+// command is ignored; the session always runs a plain sh.
+func (e *Executor) OpenShell(ctx context.Context, req Request) (*Shell, error) {
+	req.Command = []string{"sh"}
+	session, err := e.Interactive(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newShell(ctx, session)
+}
+
+// OpenShell starts a persistent shell in the workspace pod. The
+// request's command is ignored; the session always runs a plain sh.
+func (e *Kube) OpenShell(ctx context.Context, req Request) (*Shell, error) {
+	req.Command = []string{"sh"}
+	session, err := e.Interactive(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newShell(ctx, session)
+}
+
+// newShell wraps the PTY session in the line protocol and quiets the
+// terminal: echo off so commands don't come back as output, prompts
+// empty so they don't either.
+func newShell(ctx context.Context, session *Session) (*Shell, error) {
+	sh := &Shell{session: session, lines: make(chan string, 64)}
+	go sh.readLines()
+
+	if _, err := sh.run(ctx, "stty -echo 2>/dev/null; PS1=; PS2="); err != nil {
+		session.Close() //nolint:errcheck
+		return nil, fmt.Errorf("initialize shell: %w", err)
+	}
+	return sh, nil
+}
+
+// readLines pumps session output into the lines channel, normalizing
+// the PTY's \r\n endings.
+func (s *Shell) readLines() {
+	scanner := bufio.NewScanner(s.session)
+	for scanner.Scan() {
+		s.lines <- strings.TrimSuffix(scanner.Text(), "\r")
+	}
+	close(s.lines)
+}
+
+// Run executes one command in the shell and returns its output and
+// exit status. The command runs exactly as typed, so shell syntax
+// (pipes, &&, variable expansion) works.
+func (s *Shell) Run(ctx context.Context, command string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run(ctx, command)
+}
+
+func (s *Shell) run(ctx context.Context, command string) (*Result, error) {
+	// The sentinel is random per command so output that happens to
+	// contain a previous sentinel can't end the read early. Its leading
+	// newline terminates any partial line the command left behind.
+	sentinel := newSentinel()
+	input := command + "\nprintf '\\n%s %d\\n' " + sentinel + " $?\n"
+	if _, err := s.session.Write([]byte(input)); err != nil {
+		return nil, fmt.Errorf("write command: %w", err)
+	}
+
+	start := time.Now()
+	var output []string
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				return nil, fmt.Errorf("shell exited while running %q", command)
+			}
+			if rest, found := strings.CutPrefix(line, sentinel+" "); found {
+				code, err := strconv.Atoi(rest)
+				if err != nil {
+					return nil, fmt.Errorf("malformed exit status %q", rest)
+				}
+				return &Result{
+					Stdout:   strings.Join(output, "\n"),
+					ExitCode: code,
+					Duration: time.Since(start),
+				}, nil
+			}
+			output = append(output, line)
+		case <-ctx.Done():
+			// The command is still running and the protocol is out of
+			// sync; the session is only good for Close now.
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close terminates the shell process. Environment and directory state
+// die with it; only worktree files survive.
+func (s *Shell) Close() error {
+	return s.session.Close()
+}
+
+// newSentinel returns a random output delimiter for one Run.
+func newSentinel() string {
+	buf := make([]byte, 6)
+	rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+	return "__patina_" + hex.EncodeToString(buf)
+}
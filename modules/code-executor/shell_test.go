@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestShellPreservesState drives a real shell on a PTY through the
+// sentinel protocol: cwd and exported variables set by one Run are
+// visible to the next, which is the whole point over one-shot execs.
+func TestShellPreservesState(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("PTY allocation is linux-only")
+	}
+	e := New()
+	// The fake CLI ignores the pipeline args and hands the PTY to a
+	// local shell, standing in for the container's.
+	e.Bin = fakeBin(t, "exec sh")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sh, err := e.OpenShell(ctx, Request{Image: "golang:1.21"})
+	if err != nil {
+		t.Fatalf("open shell: %v", err)
+	}
+	defer sh.Close()
+
+	dir := t.TempDir()
+	if _, err := sh.Run(ctx, "cd "+dir); err != nil {
+		t.Fatalf("cd: %v", err)
+	}
+	pwd, err := sh.Run(ctx, "pwd")
+	if err != nil {
+		t.Fatalf("pwd: %v", err)
+	}
+	if pwd.Stdout != dir+"\n" {
+		t.Errorf("pwd after cd = %q, want %q", pwd.Stdout, dir+"\n")
+	}
+
+	if _, err := sh.Run(ctx, "export PATINA_SHELL=persisted"); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	echo, err := sh.Run(ctx, "echo $PATINA_SHELL")
+	if err != nil {
+		t.Fatalf("echo: %v", err)
+	}
+	if echo.Stdout != "persisted\n" {
+		t.Errorf("echo after export = %q, want %q", echo.Stdout, "persisted\n")
+	}
+
+	// Failing commands are results, not errors, matching Execute.
+	failed, err := sh.Run(ctx, "false")
+	if err != nil {
+		t.Fatalf("false: %v", err)
+	}
+	if failed.ExitCode != 1 {
+		t.Errorf("exit code = %d, want 1", failed.ExitCode)
+	}
+}
+
+// TestShellRunCanceled asserts a hung command returns the context's
+// error instead of blocking Run forever.
+func TestShellRunCanceled(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("PTY allocation is linux-only")
+	}
+	e := New()
+	e.Bin = fakeBin(t, "exec sh")
+
+	sh, err := e.OpenShell(context.Background(), Request{Image: "golang:1.21"})
+	if err != nil {
+		t.Fatalf("open shell: %v", err)
+	}
+	defer sh.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := sh.Run(ctx, "sleep 30"); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// collectUsage reads the finished command's rusage, which wait4
+// accumulates over the runtime CLI and every child it reaped. For local
+// engines that covers the command's own work; a remote engine only
+// shows the CLI's relay overhead, so callers should treat the numbers
+// as a floor, not an exact bill.
+func collectUsage(cmd *exec.Cmd) *Usage {
+	if cmd.ProcessState == nil {
+		return nil
+	}
+	ru, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return nil
+	}
+	return &Usage{
+		CPUTime: time.Duration(ru.Utime.Nano() + ru.Stime.Nano()),
+		// ru_maxrss is KiB on Linux.
+		PeakMemoryBytes: ru.Maxrss * 1024,
+		// ru_oublock counts 512-byte blocks queued for write.
+		BytesWritten: ru.Oublock * 512,
+	}
+}
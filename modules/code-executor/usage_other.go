@@ -0,0 +1,11 @@
+//go:build !linux
+
+package executor
+
+import "os/exec"
+
+// collectUsage reports nothing: rusage accounting is only wired up on
+// Linux, where the gateway runs in production.
+func collectUsage(cmd *exec.Cmd) *Usage {
+	return nil
+}
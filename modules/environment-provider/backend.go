@@ -0,0 +1,122 @@
+package provider
+
+import "context"
+
+// Backend abstracts the container runtime the provider drives. Some
+// hosts cannot run the Dagger engine, so the gateway picks a backend at
+// startup instead of hard-wiring one.
+type Backend interface {
+	// Name identifies the backend (e.g. "dagger", "docker").
+	Name() string
+	// WarmImage makes cfg.Image available in the runtime's cache, for
+	// cfg.Platform when one is selected.
+	WarmImage(ctx context.Context, cfg Config) error
+	// BuildImage builds the environment image from cfg.Dockerfile.
+	BuildImage(ctx context.Context, cfg Config) error
+}
+
+// GPUBackend is implemented by backends that can attach GPU devices to
+// workspace containers. Requesting GPUs on any other backend is an
+// error, so users find out at create time rather than at first exec.
+type GPUBackend interface {
+	Backend
+	// SupportsGPU reports whether GPUs are actually available right now
+	// (driver installed, device plugin running, capacity advertised).
+	SupportsGPU(ctx context.Context) error
+}
+
+// ManagedLabel marks images and volumes created by patina, so the admin
+// surface can find (and prune) exactly what patina created and nothing
+// else on a shared host.
+const ManagedLabel = "dev.patina/managed=true"
+
+// Image is a runtime-cached image with the size the runtime reports.
+type Image struct {
+	// Reference is the image name and tag.
+	Reference string `json:"reference"`
+	// Size is the runtime's human-readable size (e.g. "1.2GB").
+	Size string `json:"size"`
+}
+
+// CacheVolume is a named cache volume with the size the runtime reports.
+type CacheVolume struct {
+	Name string `json:"name"`
+	Size string `json:"size"`
+}
+
+// AuthBackend is implemented by backends that can authenticate to image
+// registries. Requesting registry auth on any other backend is an
+// error, so users find out at create time instead of a failed pull.
+type AuthBackend interface {
+	Backend
+	// Login makes the credential available to subsequent pulls and
+	// builds against its registry.
+	Login(ctx context.Context, auth RegistryAuth) error
+}
+
+// ExportBackend is implemented by backends that can get an environment
+// image back out of the runtime, either pushed to a registry or written
+// as a tarball.
+type ExportBackend interface {
+	Backend
+	// PublishImage pushes the image to the registry reference ref.
+	PublishImage(ctx context.Context, image, ref string) error
+	// SaveImage writes the image as an OCI tarball at path.
+	SaveImage(ctx context.Context, image, path string) error
+}
+
+// AdminBackend is implemented by backends that can report and reclaim
+// the disk space patina's images and cache volumes accumulate.
+type AdminBackend interface {
+	Backend
+	// Images lists patina-labeled images.
+	Images(ctx context.Context) ([]Image, error)
+	// CacheVolumes lists patina-labeled cache volumes.
+	CacheVolumes(ctx context.Context) ([]CacheVolume, error)
+	// Prune removes unused patina-labeled images and cache volumes,
+	// returning the runtime's reclaimed-space report.
+	Prune(ctx context.Context) (string, error)
+}
+
+// LinkBackend is implemented by backends whose environments share a
+// network and can reach each other. Linking workspaces on any other
+// backend is an error: one-shot pipelines have no live peer to dial.
+type LinkBackend interface {
+	Backend
+	// RuntimeAddress returns the address peers dial to reach the named
+	// environment.
+	RuntimeAddress(ctx context.Context, name string) (string, error)
+}
+
+// PortBackend is implemented by backends that can expose container
+// ports on the host. Requesting ports on any other backend is an error,
+// so users find out at create time rather than at first connection.
+type PortBackend interface {
+	Backend
+	// ExposePorts resolves cfg.Ports to host bindings once the
+	// environment's runtime exists.
+	ExposePorts(ctx context.Context, cfg Config) ([]PortBinding, error)
+}
+
+// ProgressBackend is implemented by backends that can report pipeline
+// progress as it happens, labeled with the environment the pipeline
+// belongs to. Backends without progress reporting simply stay silent;
+// creates still work, they're just quiet.
+type ProgressBackend interface {
+	Backend
+	// SetProgressSink registers the callback progress lines are delivered
+	// to. A nil sink disables reporting.
+	SetProgressSink(sink func(label, step string))
+}
+
+// LifecycleBackend is implemented by backends whose environments are
+// long-running (e.g. Kubernetes pods) rather than one-shot pipelines
+// against a cached image. The provider creates and destroys the runtime
+// alongside the environment record.
+type LifecycleBackend interface {
+	Backend
+	// CreateRuntime starts the environment's runtime for cfg.Name.
+	CreateRuntime(ctx context.Context, cfg Config) error
+	// DestroyRuntime stops the runtime for the named environment.
+	DestroyRuntime(ctx context.Context, name string) error
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DaggerBackend drives the Dagger engine through the dagger CLI.
+type DaggerBackend struct {
+	// Bin is the dagger binary to invoke. Defaults to "dagger".
+	Bin string
+	// sink receives pipeline progress lines; nil disables reporting.
+	sink func(label, step string)
+}
+
+// NewDaggerBackend creates a backend using the dagger CLI from PATH.
+func NewDaggerBackend() *DaggerBackend {
+	return &DaggerBackend{Bin: "dagger"}
+}
+
+// Name implements Backend.
+func (b *DaggerBackend) Name() string { return "dagger" }
+
+// SetProgressSink implements ProgressBackend. With a sink set, pipelines
+// run with plain progress and every step line is forwarded as it
+// happens, labeled with the environment the pipeline belongs to.
+func (b *DaggerBackend) SetProgressSink(sink func(label, step string)) {
+	b.sink = sink
+}
+
+// WarmImage resolves the image through a no-op pipeline so the first
+// exec doesn't pay for the pull.
+func (b *DaggerBackend) WarmImage(ctx context.Context, cfg Config) error {
+	args := []string{"core", "container"}
+	if cfg.Platform != "" {
+		args = append(args, "--platform="+cfg.Platform)
+	}
+	args = append(args, "from", "--address="+cfg.Image, "id")
+	return b.runOn(ctx, cfg.Engine, cfg.Name, args...)
+}
+
+// BuildImage runs a Dockerfile build through the engine. Secrets are
+// passed as Dagger secret URIs so their values stay out of image layers,
+// and the host SSH agent can be forwarded for private dependencies.
+func (b *DaggerBackend) BuildImage(ctx context.Context, cfg Config) error {
+	args, err := buildArgs(cfg, os.Getenv("SSH_AUTH_SOCK"))
+	if err != nil {
+		return err
+	}
+	return b.runOn(ctx, cfg.Engine, cfg.Name, args...)
+}
+
+// PublishImage implements ExportBackend by pushing the image through
+// the engine.
+func (b *DaggerBackend) PublishImage(ctx context.Context, image, ref string) error {
+	return b.run(ctx, image, "core", "container", "from", "--address="+image, "publish", "--address="+ref)
+}
+
+// SaveImage implements ExportBackend by exporting the image as an OCI
+// tarball.
+func (b *DaggerBackend) SaveImage(ctx context.Context, image, path string) error {
+	return b.run(ctx, image, "core", "container", "from", "--address="+image, "export", "--path="+path)
+}
+
+// run invokes the dagger CLI against the local engine.
+func (b *DaggerBackend) run(ctx context.Context, label string, args ...string) error {
+	return b.runOn(ctx, "", label, args...)
+}
+
+// runOn invokes the dagger CLI, surfacing stderr on failure. A non-empty
+// engine targets that remote engine through the CLI's runner-host
+// variable. With a progress sink set, the pipeline runs with plain
+// progress and stderr is streamed line by line to the sink under the
+// given label, so a multi-minute pull reports steps instead of silence.
+func (b *DaggerBackend) runOn(ctx context.Context, engine, label string, args ...string) error {
+	if b.sink != nil {
+		args = append([]string{"--progress=plain"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, b.Bin, args...)
+	if engine != "" {
+		cmd.Env = append(os.Environ(), RunnerHostEnv+"="+engine)
+	}
+	var stderr strings.Builder
+
+	if b.sink == nil {
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("dagger %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("dagger %s: %v", strings.Join(args, " "), err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("dagger %s: %v", strings.Join(args, " "), err)
+	}
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		stderr.WriteString(line + "\n")
+		b.sink(label, line)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("dagger %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// buildArgs assembles the dagger pipeline for a Dockerfile build.
+func buildArgs(cfg Config, sshAuthSock string) ([]string, error) {
+	buildContext := cfg.BuildContext
+	if buildContext == "" {
+		buildContext = filepath.Dir(cfg.Dockerfile)
+	}
+
+	args := []string{
+		"core", "host", "directory", "--path=" + buildContext,
+		"docker-build", "--dockerfile=" + cfg.Dockerfile,
+	}
+	if cfg.Platform != "" {
+		args = append(args, "--platform="+cfg.Platform)
+	}
+
+	for _, secret := range cfg.BuildSecrets {
+		uri, err := secret.uri()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--secrets="+secret.Name+"="+uri)
+	}
+
+	if cfg.ForwardSSH {
+		if sshAuthSock == "" {
+			return nil, fmt.Errorf("SSH forwarding requested but SSH_AUTH_SOCK is not set")
+		}
+		args = append(args, "--ssh-auth-socket="+sshAuthSock)
+	}
+
+	args = append(args, "id")
+	return args, nil
+}
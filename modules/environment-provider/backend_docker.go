@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DockerBackend drives a local Docker Engine through the docker CLI.
+// It exists for hosts that cannot run the Dagger engine.
+type DockerBackend struct {
+	// Bin is the docker binary to invoke. Defaults to "docker".
+	Bin string
+}
+
+// NewDockerBackend creates a backend using the docker CLI from PATH.
+func NewDockerBackend() *DockerBackend {
+	return &DockerBackend{Bin: "docker"}
+}
+
+// Name implements Backend.
+func (b *DockerBackend) Name() string { return "docker" }
+
+// WarmImage pulls the image into the local engine.
+func (b *DockerBackend) WarmImage(ctx context.Context, cfg Config) error {
+	args := []string{"pull"}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+	return b.run(ctx, append(args, cfg.Image)...)
+}
+
+// BuildImage runs a BuildKit build. Secrets use docker's native
+// --secret mounts and SSH forwarding uses --ssh, matching the Dagger
+// backend's guarantees: values never land in image layers.
+func (b *DockerBackend) BuildImage(ctx context.Context, cfg Config) error {
+	args, err := dockerBuildArgs(cfg, os.Getenv("SSH_AUTH_SOCK"))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, b.Bin, args...)
+	// Secret and SSH mounts require BuildKit.
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// PublishImage implements ExportBackend by tagging and pushing the
+// image.
+func (b *DockerBackend) PublishImage(ctx context.Context, image, ref string) error {
+	if err := b.run(ctx, "tag", image, ref); err != nil {
+		return err
+	}
+	return b.run(ctx, "push", ref)
+}
+
+// SaveImage implements ExportBackend by writing the image as a tarball.
+func (b *DockerBackend) SaveImage(ctx context.Context, image, path string) error {
+	return b.run(ctx, "save", "--output", path, image)
+}
+
+// Login implements AuthBackend through docker login. The password goes
+// over stdin so it never appears in the process list.
+func (b *DockerBackend) Login(ctx context.Context, auth RegistryAuth) error {
+	password, err := auth.password()
+	if err != nil {
+		return err
+	}
+	username := auth.Username
+	if username == "" {
+		// Token-only registries ignore the username but docker requires one.
+		username = "token"
+	}
+
+	cmd := exec.CommandContext(ctx, b.Bin, "login", auth.Registry,
+		"--username", username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(password)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker login %s: %s", auth.Registry, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Images implements AdminBackend by listing patina-labeled images.
+func (b *DockerBackend) Images(ctx context.Context) ([]Image, error) {
+	out, err := b.runOut(ctx, "images", "--filter", "label="+ManagedLabel,
+		"--format", "{{.Repository}}:{{.Tag}}\t{{.Size}}")
+	if err != nil {
+		return nil, err
+	}
+	return parseImageList(out), nil
+}
+
+// CacheVolumes implements AdminBackend by listing patina-labeled volumes
+// with the sizes docker's disk-usage report attributes to them.
+func (b *DockerBackend) CacheVolumes(ctx context.Context) ([]CacheVolume, error) {
+	names, err := b.runOut(ctx, "volume", "ls", "--filter", "label="+ManagedLabel,
+		"--format", "{{.Name}}")
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := b.runOut(ctx, "system", "df", "-v", "--format", "{{json .Volumes}}")
+	if err != nil {
+		return nil, err
+	}
+	return parseCacheVolumes(names, sizes)
+}
+
+// Prune implements AdminBackend by removing unused patina-labeled images
+// and volumes, returning docker's reclaimed-space reports.
+func (b *DockerBackend) Prune(ctx context.Context) (string, error) {
+	images, err := b.runOut(ctx, "image", "prune", "--force", "--filter", "label="+ManagedLabel)
+	if err != nil {
+		return "", err
+	}
+	volumes, err := b.runOut(ctx, "volume", "prune", "--force", "--filter", "label="+ManagedLabel)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(images + "\n" + volumes), nil
+}
+
+// parseImageList parses "reference<TAB>size" lines from docker images.
+func parseImageList(out string) []Image {
+	var images []Image
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		reference, size, ok := strings.Cut(line, "\t")
+		if !ok || reference == "" {
+			continue
+		}
+		images = append(images, Image{Reference: reference, Size: size})
+	}
+	return images
+}
+
+// parseCacheVolumes joins the labeled volume names with docker's
+// disk-usage sizes. Volumes missing from the report get size "unknown".
+func parseCacheVolumes(names, sizes string) ([]CacheVolume, error) {
+	var reported []struct {
+		Name string `json:"Name"`
+		Size string `json:"Size"`
+	}
+	if trimmed := strings.TrimSpace(sizes); trimmed != "" {
+		if err := json.Unmarshal([]byte(trimmed), &reported); err != nil {
+			return nil, fmt.Errorf("parse docker disk usage: %w", err)
+		}
+	}
+	sizeByName := make(map[string]string, len(reported))
+	for _, vol := range reported {
+		sizeByName[vol.Name] = vol.Size
+	}
+
+	var volumes []CacheVolume
+	for _, name := range strings.Split(strings.TrimSpace(names), "\n") {
+		if name == "" {
+			continue
+		}
+		size, ok := sizeByName[name]
+		if !ok {
+			size = "unknown"
+		}
+		volumes = append(volumes, CacheVolume{Name: name, Size: size})
+	}
+	return volumes, nil
+}
+
+// run invokes the docker CLI, surfacing stderr on failure.
+func (b *DockerBackend) run(ctx context.Context, args ...string) error {
+	_, err := b.runOut(ctx, args...)
+	return err
+}
+
+// runOut invokes the docker CLI and returns its stdout, surfacing
+// stderr on failure.
+func (b *DockerBackend) runOut(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, b.Bin, args...)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// dockerBuildArgs assembles the docker build invocation for a config.
+func dockerBuildArgs(cfg Config, sshAuthSock string) ([]string, error) {
+	buildContext := cfg.BuildContext
+	if buildContext == "" {
+		buildContext = filepath.Dir(cfg.Dockerfile)
+	}
+
+	// The managed label lets the admin surface list and prune exactly
+	// what patina built.
+	args := []string{"build", "--file", cfg.Dockerfile, "--label", ManagedLabel}
+	if cfg.Platform != "" {
+		args = append(args, "--platform", cfg.Platform)
+	}
+
+	for _, secret := range cfg.BuildSecrets {
+		mount, err := secret.dockerMount()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--secret", mount)
+	}
+
+	if cfg.ForwardSSH {
+		if sshAuthSock == "" {
+			return nil, fmt.Errorf("SSH forwarding requested but SSH_AUTH_SOCK is not set")
+		}
+		args = append(args, "--ssh", "default="+sshAuthSock)
+	}
+
+	args = append(args, buildContext)
+	return args, nil
+}
+
+// dockerMount renders the secret as a docker build --secret value.
+func (s BuildSecret) dockerMount() (string, error) {
+	// Reuse the URI validation; the source rules are identical.
+	if _, err := s.uri(); err != nil {
+		return "", err
+	}
+	if s.Env != "" {
+		return "id=" + s.Name + ",env=" + s.Env, nil
+	}
+	return "id=" + s.Name + ",src=" + s.File, nil
+}
@@ -0,0 +1,396 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultNamespace is the Kubernetes namespace workspaces run in unless
+// the gateway overrides it.
+const DefaultNamespace = "patina"
+
+// KubeBackend runs workspaces as pods in a Kubernetes namespace, so a
+// shared cluster can host hundreds of workspaces instead of one Dagger
+// host. It drives the cluster through kubectl.
+type KubeBackend struct {
+	// Bin is the kubectl binary to invoke. Defaults to "kubectl".
+	Bin string
+	// Namespace is where workspace pods are created.
+	Namespace string
+}
+
+// NewKubeBackend creates a backend using kubectl from PATH.
+func NewKubeBackend(namespace string) *KubeBackend {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &KubeBackend{Bin: "kubectl", Namespace: namespace}
+}
+
+// Name implements Backend.
+func (b *KubeBackend) Name() string { return "kubernetes" }
+
+// WarmImage is a no-op: the kubelet pulls the image when the pod is
+// scheduled, on the node that will actually run it.
+func (b *KubeBackend) WarmImage(_ context.Context, _ Config) error {
+	return nil
+}
+
+// BuildImage is not supported; clusters consume pre-built images from a
+// registry.
+func (b *KubeBackend) BuildImage(_ context.Context, cfg Config) error {
+	return fmt.Errorf("kubernetes backend cannot build %s: push a pre-built image to a registry instead", cfg.Dockerfile)
+}
+
+// CreateRuntime creates the workspace pod and waits for it to be ready.
+func (b *KubeBackend) CreateRuntime(ctx context.Context, cfg Config) error {
+	manifest, err := json.Marshal(podManifest(cfg, b.Namespace))
+	if err != nil {
+		return fmt.Errorf("render pod manifest: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, b.Bin, "apply", "-n", b.Namespace, "-f", "-")
+	cmd.Stdin = bytes.NewReader(manifest)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("create pod %s: %s", PodName(cfg.Name), strings.TrimSpace(stderr.String()))
+	}
+
+	return b.run(ctx, "wait", "-n", b.Namespace, "--for=condition=Ready", "--timeout=120s", "pod/"+PodName(cfg.Name))
+}
+
+// Login implements AuthBackend by storing the credential as a
+// dockerconfigjson secret in the namespace; workspace pods reference it
+// through imagePullSecrets.
+func (b *KubeBackend) Login(ctx context.Context, auth RegistryAuth) error {
+	password, err := auth.password()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(pullSecretManifest(auth, password, b.Namespace))
+	if err != nil {
+		return fmt.Errorf("render pull secret: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, b.Bin, "apply", "-n", b.Namespace, "-f", "-")
+	cmd.Stdin = bytes.NewReader(manifest)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("create pull secret for %s: %s", auth.Registry, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// pullSecretName returns the secret name for a registry's credential.
+func pullSecretName(registry string) string {
+	return "patina-auth-" + strings.ReplaceAll(registry, ".", "-")
+}
+
+// pullSecretManifest renders a registry credential as a
+// kubernetes.io/dockerconfigjson secret.
+func pullSecretManifest(auth RegistryAuth, password, namespace string) map[string]any {
+	dockerConfig := map[string]any{
+		"auths": map[string]any{
+			auth.Registry: map[string]any{
+				"username": auth.Username,
+				"password": password,
+				"auth":     base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + password)),
+			},
+		},
+	}
+	configJSON, _ := json.Marshal(dockerConfig) //nolint:errcheck // map of strings cannot fail
+
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "kubernetes.io/dockerconfigjson",
+		"metadata": map[string]any{
+			"name":      pullSecretName(auth.Registry),
+			"namespace": namespace,
+			"labels": map[string]any{
+				"app.kubernetes.io/managed-by": "patina",
+			},
+		},
+		"stringData": map[string]any{
+			".dockerconfigjson": string(configJSON),
+		},
+	}
+}
+
+// SupportsGPU checks that at least one node advertises NVIDIA GPU
+// capacity (i.e. the device plugin is installed and running).
+func (b *KubeBackend) SupportsGPU(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, b.Bin, "get", "nodes",
+		"-o", `jsonpath={.items[*].status.allocatable.nvidia\.com/gpu}`)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("query node GPU capacity: %s", strings.TrimSpace(stderr.String()))
+	}
+	if strings.TrimSpace(stdout.String()) == "" {
+		return fmt.Errorf("no node advertises nvidia.com/gpu capacity")
+	}
+	return nil
+}
+
+// ExposePorts implements PortBackend. The pod manifest binds each
+// container port to the same port on its node, so the resolved host
+// bindings are the identity mapping.
+func (b *KubeBackend) ExposePorts(_ context.Context, cfg Config) ([]PortBinding, error) {
+	bindings := make([]PortBinding, len(cfg.Ports))
+	for i, port := range cfg.Ports {
+		bindings[i] = PortBinding{ContainerPort: port, HostPort: port}
+	}
+	return bindings, nil
+}
+
+// RuntimeAddress implements LinkBackend: pods in the namespace reach
+// each other by pod IP, so peers get the workspace pod's address.
+func (b *KubeBackend) RuntimeAddress(ctx context.Context, name string) (string, error) {
+	out, err := b.runOut(ctx, "get", "pod", "-n", b.Namespace, PodName(name),
+		"-o", "jsonpath={.status.podIP}")
+	if err != nil {
+		return "", err
+	}
+	address := strings.TrimSpace(out)
+	if address == "" {
+		return "", fmt.Errorf("pod %s has no IP yet", PodName(name))
+	}
+	return address, nil
+}
+
+// DestroyRuntime deletes the workspace pod.
+func (b *KubeBackend) DestroyRuntime(ctx context.Context, name string) error {
+	return b.run(ctx, "delete", "pod", "-n", b.Namespace, "--ignore-not-found", PodName(name))
+}
+
+// run invokes kubectl, surfacing stderr on failure.
+func (b *KubeBackend) run(ctx context.Context, args ...string) error {
+	_, err := b.runOut(ctx, args...)
+	return err
+}
+
+// runOut invokes kubectl and returns its stdout, surfacing stderr on
+// failure.
+func (b *KubeBackend) runOut(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, b.Bin, args...)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// PodName returns the pod name for a workspace environment.
+func PodName(name string) string {
+	return "patina-" + name
+}
+
+// podManifest derives a pod spec from the environment config. The pod
+// idles on sleep; the executor runs commands through the exec
+// subresource.
+func podManifest(cfg Config, namespace string) map[string]any {
+	image := cfg.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	workdir := cfg.Workdir
+	if workdir == "" {
+		workdir = DefaultWorkdir
+	}
+
+	container := map[string]any{
+		"name":       "workspace",
+		"image":      image,
+		"workingDir": workdir,
+		"command":    []string{"sleep", "infinity"},
+	}
+	// Exposed ports bind on the node the pod schedules to, so a dev
+	// server in the workspace is reachable at <node>:<port>.
+	if len(cfg.Ports) > 0 {
+		var ports []map[string]any
+		for _, port := range cfg.Ports {
+			ports = append(ports, map[string]any{"containerPort": port, "hostPort": port})
+		}
+		container["ports"] = ports
+	}
+	if limits := resourceLimits(cfg.Resources); len(limits) > 0 {
+		container["resources"] = map[string]any{"limits": limits}
+	}
+	// The kubelet runs the probe natively; pipeline backends are probed
+	// by the gateway instead.
+	if len(cfg.Health.Command) > 0 {
+		container["livenessProbe"] = map[string]any{
+			"exec":             map[string]any{"command": cfg.Health.Command},
+			"periodSeconds":    cfg.Health.Interval(),
+			"failureThreshold": cfg.Health.MaxRetries(),
+		}
+	}
+
+	// Cache volumes map to pre-provisioned PVCs named patina-cache-<name>,
+	// so caches are shared across workspace pods and survive recreation.
+	var volumes []map[string]any
+	for _, name := range sortedCacheNames(cfg.Caches) {
+		mounts, _ := container["volumeMounts"].([]map[string]any)
+		container["volumeMounts"] = append(mounts, map[string]any{
+			"name":      "cache-" + name,
+			"mountPath": cfg.Caches[name],
+		})
+		volumes = append(volumes, map[string]any{
+			"name": "cache-" + name,
+			"persistentVolumeClaim": map[string]any{
+				"claimName": "patina-cache-" + name,
+			},
+		})
+	}
+
+	// Extra mounts are hostPath volumes; copy mode has no pipeline step
+	// to implement it and is rejected at create time.
+	for i, m := range cfg.Mounts {
+		name := fmt.Sprintf("mount-%d", i)
+		volumeMount := map[string]any{"name": name, "mountPath": m.Target}
+		if m.mode() == MountModeRO {
+			volumeMount["readOnly"] = true
+		}
+		mounts, _ := container["volumeMounts"].([]map[string]any)
+		container["volumeMounts"] = append(mounts, volumeMount)
+		volumes = append(volumes, map[string]any{
+			"name":     name,
+			"hostPath": map[string]any{"path": m.Source, "type": "Directory"},
+		})
+	}
+
+	containers := []map[string]any{container}
+
+	// Services run as sidecar containers in the pod, so the main container
+	// reaches them on localhost. Hostname/port env vars keep workloads
+	// portable between backends.
+	mainEnv := envList(cfg.Env)
+	for _, svc := range cfg.Services {
+		sidecar := map[string]any{
+			"name":  svc.Name,
+			"image": svc.Image,
+		}
+		var ports []map[string]any
+		for _, port := range svc.Ports {
+			ports = append(ports, map[string]any{"containerPort": port})
+		}
+		sidecar["ports"] = ports
+		if env := envList(svc.Env); len(env) > 0 {
+			sidecar["env"] = env
+		}
+		containers = append(containers, sidecar)
+
+		mainEnv = append(mainEnv,
+			map[string]any{"name": svc.EnvPrefix() + "_HOST", "value": "localhost"},
+			map[string]any{"name": svc.EnvPrefix() + "_PORT", "value": strconv.Itoa(svc.Ports[0])},
+		)
+	}
+	if len(mainEnv) > 0 {
+		container["env"] = mainEnv
+	}
+
+	spec := map[string]any{
+		"restartPolicy": "Never",
+		"containers":    containers,
+	}
+	// Clusters can't emulate foreign architectures; schedule the pod on a
+	// node that matches the selected platform instead.
+	if cfg.Platform != "" {
+		spec["nodeSelector"] = map[string]any{"kubernetes.io/arch": platformArch(cfg.Platform)}
+	}
+	// Pods only take numeric IDs; a name without pinned IDs is resolved
+	// by the image's own passwd and left to the container runtime.
+	if cfg.UID > 0 {
+		securityContext := map[string]any{"runAsUser": cfg.UID}
+		if cfg.GID > 0 {
+			securityContext["runAsGroup"] = cfg.GID
+			securityContext["fsGroup"] = cfg.GID
+		}
+		spec["securityContext"] = securityContext
+	}
+	if len(cfg.RegistryAuth) > 0 {
+		var pullSecrets []map[string]any
+		for _, auth := range cfg.RegistryAuth {
+			pullSecrets = append(pullSecrets, map[string]any{"name": pullSecretName(auth.Registry)})
+		}
+		spec["imagePullSecrets"] = pullSecrets
+	}
+	if len(volumes) > 0 {
+		spec["volumes"] = volumes
+	}
+
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]any{
+			"name":      PodName(cfg.Name),
+			"namespace": namespace,
+			"labels": map[string]any{
+				"app.kubernetes.io/managed-by": "patina",
+				"patina.dev/workspace":         cfg.Name,
+			},
+		},
+		"spec": spec,
+	}
+}
+
+// envList renders an env map as Kubernetes env entries, sorted by name
+// so manifests are deterministic.
+func envList(env map[string]string) []map[string]any {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string]any{"name": name, "value": env[name]})
+	}
+	return out
+}
+
+// sortedCacheNames returns cache names in lexical order so manifests are
+// deterministic.
+func sortedCacheNames(caches map[string]string) []string {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resourceLimits renders config quotas as Kubernetes resource limits.
+func resourceLimits(res Resources) map[string]any {
+	limits := map[string]any{}
+	if res.CPUs > 0 {
+		limits["cpu"] = strconv.FormatFloat(res.CPUs, 'f', -1, 64)
+	}
+	if res.MemoryMB > 0 {
+		limits["memory"] = fmt.Sprintf("%dMi", res.MemoryMB)
+	}
+	if res.DiskMB > 0 {
+		limits["ephemeral-storage"] = fmt.Sprintf("%dMi", res.DiskMB)
+	}
+	if res.GPUs > 0 {
+		limits["nvidia.com/gpu"] = strconv.Itoa(res.GPUs)
+	}
+	return limits
+}
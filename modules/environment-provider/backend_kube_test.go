@@ -0,0 +1,429 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPodManifest(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo", Image: "golang:1.21"}, "patina")
+
+	metadata := manifest["metadata"].(map[string]any)
+	if metadata["name"] != "patina-demo" {
+		t.Errorf("pod name = %v, want patina-demo", metadata["name"])
+	}
+
+	labels := metadata["labels"].(map[string]any)
+	if labels["patina.dev/workspace"] != "demo" {
+		t.Errorf("workspace label = %v, want demo", labels["patina.dev/workspace"])
+	}
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	if containers[0]["image"] != "golang:1.21" {
+		t.Errorf("image = %v, want golang:1.21", containers[0]["image"])
+	}
+	if containers[0]["workingDir"] != DefaultWorkdir {
+		t.Errorf("workingDir = %v, want project dir default", containers[0]["workingDir"])
+	}
+}
+
+func TestPodManifestDefaults(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo"}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	if containers[0]["image"] != DefaultImage {
+		t.Errorf("image = %v, want %s default", containers[0]["image"], DefaultImage)
+	}
+}
+
+func TestPodManifestResourceLimits(t *testing.T) {
+	manifest := podManifest(Config{
+		Name:      "demo",
+		Resources: Resources{CPUs: 1.5, MemoryMB: 512, DiskMB: 2048},
+	}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	limits := containers[0]["resources"].(map[string]any)["limits"].(map[string]any)
+
+	if limits["cpu"] != "1.5" {
+		t.Errorf("cpu = %v, want 1.5", limits["cpu"])
+	}
+	if limits["memory"] != "512Mi" {
+		t.Errorf("memory = %v, want 512Mi", limits["memory"])
+	}
+	if limits["ephemeral-storage"] != "2048Mi" {
+		t.Errorf("ephemeral-storage = %v, want 2048Mi", limits["ephemeral-storage"])
+	}
+
+	// No limits requested: no resources block at all.
+	bare := podManifest(Config{Name: "demo"}, "patina")
+	spec = bare["spec"].(map[string]any)
+	containers = spec["containers"].([]map[string]any)
+	if _, ok := containers[0]["resources"]; ok {
+		t.Error("unlimited config should not render a resources block")
+	}
+}
+
+func TestPodManifestCacheVolumes(t *testing.T) {
+	manifest := podManifest(Config{
+		Name:   "demo",
+		Caches: map[string]string{"cargo": "/usr/local/cargo/registry"},
+	}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	volumes := spec["volumes"].([]map[string]any)
+	claim := volumes[0]["persistentVolumeClaim"].(map[string]any)
+	if claim["claimName"] != "patina-cache-cargo" {
+		t.Errorf("claim = %v, want patina-cache-cargo", claim["claimName"])
+	}
+
+	containers := spec["containers"].([]map[string]any)
+	mounts := containers[0]["volumeMounts"].([]map[string]any)
+	if mounts[0]["mountPath"] != "/usr/local/cargo/registry" {
+		t.Errorf("mountPath = %v, want /usr/local/cargo/registry", mounts[0]["mountPath"])
+	}
+
+	// No caches: no volumes block at all.
+	bare := podManifest(Config{Name: "demo"}, "patina")
+	if _, ok := bare["spec"].(map[string]any)["volumes"]; ok {
+		t.Error("cacheless config should not render a volumes block")
+	}
+}
+
+func TestPodManifestSidecars(t *testing.T) {
+	manifest := podManifest(Config{
+		Name: "demo",
+		Services: []Service{{
+			Name:  "postgres",
+			Image: "postgres:16",
+			Ports: []int{5432},
+			Env:   map[string]string{"POSTGRES_PASSWORD": "test"},
+		}},
+	}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	if len(containers) != 2 {
+		t.Fatalf("len(containers) = %d, want workspace + sidecar", len(containers))
+	}
+
+	sidecar := containers[1]
+	if sidecar["name"] != "postgres" || sidecar["image"] != "postgres:16" {
+		t.Errorf("sidecar = %v/%v, want postgres/postgres:16", sidecar["name"], sidecar["image"])
+	}
+	env := sidecar["env"].([]map[string]any)
+	if env[0]["name"] != "POSTGRES_PASSWORD" {
+		t.Errorf("sidecar env = %v, want POSTGRES_PASSWORD", env[0]["name"])
+	}
+
+	// The main container learns where the service lives.
+	mainEnv := containers[0]["env"].([]map[string]any)
+	if mainEnv[0]["name"] != "POSTGRES_HOST" || mainEnv[0]["value"] != "localhost" {
+		t.Errorf("main env[0] = %v, want POSTGRES_HOST=localhost", mainEnv[0])
+	}
+	if mainEnv[1]["name"] != "POSTGRES_PORT" || mainEnv[1]["value"] != "5432" {
+		t.Errorf("main env[1] = %v, want POSTGRES_PORT=5432", mainEnv[1])
+	}
+}
+
+func TestValidateServices(t *testing.T) {
+	valid := []Service{{Name: "redis", Image: "redis:7", Ports: []int{6379}}}
+	if err := validateServices(valid); err != nil {
+		t.Errorf("valid services rejected: %v", err)
+	}
+	if err := validateServices([]Service{{Image: "redis:7", Ports: []int{6379}}}); err == nil {
+		t.Error("nameless service accepted")
+	}
+	if err := validateServices([]Service{{Name: "redis", Ports: []int{6379}}}); err == nil {
+		t.Error("imageless service accepted")
+	}
+	if err := validateServices([]Service{{Name: "redis", Image: "redis:7"}}); err == nil {
+		t.Error("portless service accepted")
+	}
+}
+
+func TestValidServiceName(t *testing.T) {
+	for _, name := range []string{"postgres", "test-db", "redis7"} {
+		if !validServiceName(name) {
+			t.Errorf("valid name %q rejected", name)
+		}
+	}
+	for _, name := range []string{"My_DB", "db.internal", "-db", "db-", strings.Repeat("a", 64)} {
+		if validServiceName(name) {
+			t.Errorf("non-DNS name %q accepted", name)
+		}
+	}
+}
+
+func TestServiceEndpoints(t *testing.T) {
+	services := []Service{{Name: "postgres", Image: "postgres:16", Ports: []int{5432}}}
+
+	// Pipeline backends reach services by name.
+	endpoints := NewWithBackend(NewDaggerBackend()).ServiceEndpoints(services)
+	if endpoints[0].Host != "postgres" || endpoints[0].Port != 5432 {
+		t.Errorf("dagger endpoint = %+v, want postgres:5432", endpoints[0])
+	}
+
+	// Pod sidecars share the network namespace and answer on localhost.
+	endpoints = NewWithBackend(NewKubeBackend("")).ServiceEndpoints(services)
+	if endpoints[0].Host != "localhost" {
+		t.Errorf("kube endpoint host = %q, want localhost", endpoints[0].Host)
+	}
+
+	if NewWithBackend(NewDaggerBackend()).ServiceEndpoints(nil) != nil {
+		t.Error("no services should resolve to no endpoints")
+	}
+}
+
+func TestPodManifestGPULimit(t *testing.T) {
+	manifest := podManifest(Config{
+		Name:      "demo",
+		Resources: Resources{GPUs: 2},
+	}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	limits := containers[0]["resources"].(map[string]any)["limits"].(map[string]any)
+	if limits["nvidia.com/gpu"] != "2" {
+		t.Errorf("gpu limit = %v, want 2", limits["nvidia.com/gpu"])
+	}
+}
+
+func TestGPUUnsupportedBackend(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	_, err := p.Create(context.Background(), Config{
+		Name:      "demo",
+		Resources: Resources{GPUs: 1},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot attach GPUs") {
+		t.Errorf("err = %v, want clear GPU-unsupported error", err)
+	}
+}
+
+func TestPodManifestSecurityContext(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo", User: "dev", UID: 1000, GID: 1000}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	securityContext := spec["securityContext"].(map[string]any)
+	if securityContext["runAsUser"] != 1000 || securityContext["runAsGroup"] != 1000 {
+		t.Errorf("securityContext = %v, want runAsUser/runAsGroup 1000", securityContext)
+	}
+	if securityContext["fsGroup"] != 1000 {
+		t.Errorf("fsGroup = %v, want 1000", securityContext["fsGroup"])
+	}
+
+	// No pinned IDs: no security context.
+	bare := podManifest(Config{Name: "demo", User: "dev"}, "patina")
+	if _, ok := bare["spec"].(map[string]any)["securityContext"]; ok {
+		t.Error("named user without IDs should not render a security context")
+	}
+}
+
+func TestPullSecretManifest(t *testing.T) {
+	auth := RegistryAuth{Registry: "ghcr.io", Username: "dev", PasswordEnv: "TOKEN"}
+	manifest := pullSecretManifest(auth, "hunter2", "patina")
+
+	if manifest["type"] != "kubernetes.io/dockerconfigjson" {
+		t.Errorf("type = %v, want dockerconfigjson", manifest["type"])
+	}
+	metadata := manifest["metadata"].(map[string]any)
+	if metadata["name"] != "patina-auth-ghcr-io" {
+		t.Errorf("name = %v, want patina-auth-ghcr-io", metadata["name"])
+	}
+
+	config := manifest["stringData"].(map[string]any)[".dockerconfigjson"].(string)
+	for _, want := range []string{`"ghcr.io"`, `"username":"dev"`, `"password":"hunter2"`} {
+		if !strings.Contains(config, want) {
+			t.Errorf("dockerconfigjson missing %s: %s", want, config)
+		}
+	}
+}
+
+func TestPodManifestPullSecrets(t *testing.T) {
+	manifest := podManifest(Config{
+		Name:         "demo",
+		RegistryAuth: []RegistryAuth{{Registry: "ghcr.io", PasswordEnv: "TOKEN"}},
+	}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	pullSecrets := spec["imagePullSecrets"].([]map[string]any)
+	if pullSecrets[0]["name"] != "patina-auth-ghcr-io" {
+		t.Errorf("pull secret = %v, want patina-auth-ghcr-io", pullSecrets[0]["name"])
+	}
+}
+
+func TestPodManifestPorts(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo", Ports: []int{3000, 8080}}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	ports := containers[0]["ports"].([]map[string]any)
+	if len(ports) != 2 {
+		t.Fatalf("len(ports) = %d, want 2", len(ports))
+	}
+	if ports[0]["containerPort"] != 3000 || ports[0]["hostPort"] != 3000 {
+		t.Errorf("ports[0] = %v, want 3000 bound to the node", ports[0])
+	}
+
+	// No ports: no ports block at all.
+	bare := podManifest(Config{Name: "demo"}, "patina")
+	containers = bare["spec"].(map[string]any)["containers"].([]map[string]any)
+	if _, ok := containers[0]["ports"]; ok {
+		t.Error("portless config should not render a ports block")
+	}
+}
+
+func TestPodManifestPlatform(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo", Platform: "linux/arm64"}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	selector := spec["nodeSelector"].(map[string]any)
+	if selector["kubernetes.io/arch"] != "arm64" {
+		t.Errorf("nodeSelector = %v, want arm64", selector)
+	}
+
+	bare := podManifest(Config{Name: "demo"}, "patina")
+	if _, ok := bare["spec"].(map[string]any)["nodeSelector"]; ok {
+		t.Error("platformless config should not render a node selector")
+	}
+}
+
+func TestKubeExposePorts(t *testing.T) {
+	bindings, err := NewKubeBackend("").ExposePorts(context.Background(), Config{Ports: []int{3000}})
+	if err != nil {
+		t.Fatalf("ExposePorts: %v", err)
+	}
+	if len(bindings) != 1 || bindings[0].HostPort != 3000 {
+		t.Errorf("bindings = %v, want identity binding for 3000", bindings)
+	}
+}
+
+func TestValidatePorts(t *testing.T) {
+	if err := validatePorts([]int{80, 65535}); err != nil {
+		t.Errorf("valid ports rejected: %v", err)
+	}
+	if err := validatePorts([]int{0}); err == nil {
+		t.Error("port 0 accepted")
+	}
+	if err := validatePorts([]int{70000}); err == nil {
+		t.Error("out-of-range port accepted")
+	}
+}
+
+func TestPortsUnsupportedBackend(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	_, err := p.Create(context.Background(), Config{Name: "demo", Ports: []int{3000}})
+	if err == nil || !strings.Contains(err.Error(), "cannot expose ports") {
+		t.Errorf("err = %v, want clear ports-unsupported error", err)
+	}
+}
+
+func TestValidateCaches(t *testing.T) {
+	if err := validateCaches(map[string]string{"cargo": "/usr/local/cargo"}); err != nil {
+		t.Errorf("valid caches rejected: %v", err)
+	}
+	if err := validateCaches(map[string]string{"npm": "relative/path"}); err == nil {
+		t.Error("relative mount path accepted")
+	}
+	if err := validateCaches(map[string]string{"": "/cache"}); err == nil {
+		t.Error("empty cache name accepted")
+	}
+}
+
+func TestResourcesValidate(t *testing.T) {
+	if err := (Resources{CPUs: 1}).validate(); err != nil {
+		t.Errorf("valid resources rejected: %v", err)
+	}
+	if err := (Resources{MemoryMB: -1}).validate(); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
+
+func TestKubeBackendRejectsBuilds(t *testing.T) {
+	b := NewKubeBackend("")
+	if b.Namespace != DefaultNamespace {
+		t.Errorf("namespace = %q, want %q default", b.Namespace, DefaultNamespace)
+	}
+	if err := b.BuildImage(context.Background(), Config{Dockerfile: "Dockerfile"}); err == nil {
+		t.Error("expected error for Dockerfile builds on kubernetes")
+	}
+}
+
+func TestPodManifestHealthProbe(t *testing.T) {
+	probe := HealthProbe{Command: []string{"go", "version"}, IntervalSeconds: 10, Retries: 5}
+	manifest := podManifest(Config{Name: "demo", Health: probe}, "patina")
+
+	containers := manifest["spec"].(map[string]any)["containers"].([]map[string]any)
+	liveness := containers[0]["livenessProbe"].(map[string]any)
+	if liveness["periodSeconds"] != 10 || liveness["failureThreshold"] != 5 {
+		t.Errorf("livenessProbe = %v, want period 10 and threshold 5", liveness)
+	}
+	command := liveness["exec"].(map[string]any)["command"].([]string)
+	if len(command) != 2 || command[0] != "go" {
+		t.Errorf("probe command = %v, want [go version]", command)
+	}
+
+	// No probe: no liveness block at all.
+	bare := podManifest(Config{Name: "demo"}, "patina")
+	containers = bare["spec"].(map[string]any)["containers"].([]map[string]any)
+	if _, ok := containers[0]["livenessProbe"]; ok {
+		t.Error("probeless config should not render a livenessProbe block")
+	}
+}
+
+func TestHealthProbeDefaults(t *testing.T) {
+	var probe HealthProbe
+	if probe.Interval() != DefaultHealthInterval {
+		t.Errorf("Interval() = %d, want %d", probe.Interval(), DefaultHealthInterval)
+	}
+	if probe.MaxRetries() != DefaultHealthRetries {
+		t.Errorf("MaxRetries() = %d, want %d", probe.MaxRetries(), DefaultHealthRetries)
+	}
+	set := HealthProbe{IntervalSeconds: 7, Retries: 1}
+	if set.Interval() != 7 || set.MaxRetries() != 1 {
+		t.Errorf("explicit settings not honored: %+v", set)
+	}
+	if err := (HealthProbe{IntervalSeconds: -1}).validate(); err == nil {
+		t.Error("expected error for negative interval")
+	}
+}
+
+func TestPodManifestMounts(t *testing.T) {
+	manifest := podManifest(Config{Name: "demo", Mounts: []Mount{
+		{Source: "/data/fixtures", Target: "/fixtures", Mode: MountModeRO},
+		{Source: "/data/scratch", Target: "/scratch"},
+	}}, "patina")
+
+	spec := manifest["spec"].(map[string]any)
+	containers := spec["containers"].([]map[string]any)
+	mounts := containers[0]["volumeMounts"].([]map[string]any)
+	if len(mounts) != 2 {
+		t.Fatalf("len(volumeMounts) = %d, want 2", len(mounts))
+	}
+	if mounts[0]["mountPath"] != "/fixtures" || mounts[0]["readOnly"] != true {
+		t.Errorf("mounts[0] = %v, want read-only /fixtures", mounts[0])
+	}
+	if _, ok := mounts[1]["readOnly"]; ok {
+		t.Errorf("rw mount should not set readOnly: %v", mounts[1])
+	}
+
+	volumes := spec["volumes"].([]map[string]any)
+	hostPath := volumes[0]["hostPath"].(map[string]any)
+	if hostPath["path"] != "/data/fixtures" {
+		t.Errorf("volumes[0] hostPath = %v, want /data/fixtures", hostPath)
+	}
+}
+
+func TestCreateRejectsCopyMountOnPods(t *testing.T) {
+	p := NewWithBackend(NewKubeBackend("patina"))
+	_, err := p.Create(context.Background(), Config{Name: "demo", Mounts: []Mount{
+		{Source: "/seed", Target: "/seed", Mode: MountModeCopy},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "cannot copy") {
+		t.Errorf("err = %v, want copy-mount rejection", err)
+	}
+}
@@ -0,0 +1,78 @@
+package provider
+
+import "sync"
+
+// RunnerHostEnv is the environment variable the dagger CLI reads to
+// target a remote engine instead of auto-provisioning a local one.
+const RunnerHostEnv = "_EXPERIMENTAL_DAGGER_RUNNER_HOST"
+
+// EngineScheduler places environments across a fleet of Dagger engines
+// so a gateway can scale beyond the single local engine. Placement is
+// sticky: an environment's pipelines must all hit the same engine,
+// because that engine holds its image and cache state.
+type EngineScheduler struct {
+	mu      sync.Mutex
+	engines []string
+	// assigned maps environment name to engine endpoint.
+	assigned map[string]string
+}
+
+// NewEngineScheduler creates a scheduler over the given engine
+// endpoints (tcp://host:port or unix:// socket addresses).
+func NewEngineScheduler(engines []string) *EngineScheduler {
+	return &EngineScheduler{
+		engines:  engines,
+		assigned: make(map[string]string),
+	}
+}
+
+// Assign returns the engine for the named environment, placing it on
+// the least-loaded engine on first sight. Ties go to the earliest
+// configured engine, so placement is deterministic.
+func (s *EngineScheduler) Assign(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if engine, ok := s.assigned[name]; ok {
+		return engine
+	}
+
+	loads := make(map[string]int, len(s.engines))
+	for _, engine := range s.assigned {
+		loads[engine]++
+	}
+	best := ""
+	for _, engine := range s.engines {
+		if best == "" || loads[engine] < loads[best] {
+			best = engine
+		}
+	}
+	if best != "" {
+		s.assigned[name] = best
+	}
+	return best
+}
+
+// Release drops the environment's placement, freeing its slot in the
+// load count. Called when the environment is destroyed.
+func (s *EngineScheduler) Release(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.assigned, name)
+}
+
+// Loads reports how many environments each engine currently holds, for
+// the gateway's operator surface.
+func (s *EngineScheduler) Loads() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loads := make(map[string]int, len(s.engines))
+	for _, engine := range s.engines {
+		loads[engine] = 0
+	}
+	for _, engine := range s.assigned {
+		loads[engine]++
+	}
+	return loads
+}
@@ -0,0 +1,51 @@
+package provider
+
+import "testing"
+
+func TestEngineSchedulerLeastLoaded(t *testing.T) {
+	s := NewEngineScheduler([]string{"tcp://engine-a:1234", "tcp://engine-b:1234"})
+
+	a := s.Assign("ws-1")
+	b := s.Assign("ws-2")
+	if a != "tcp://engine-a:1234" || b != "tcp://engine-b:1234" {
+		t.Errorf("assignments = %q, %q, want round-robin across the fleet", a, b)
+	}
+	if third := s.Assign("ws-3"); third != "tcp://engine-a:1234" {
+		t.Errorf("third assignment = %q, want the tied earliest engine", third)
+	}
+}
+
+func TestEngineSchedulerSticky(t *testing.T) {
+	s := NewEngineScheduler([]string{"tcp://engine-a:1234", "tcp://engine-b:1234"})
+
+	first := s.Assign("ws-1")
+	for i := 0; i < 3; i++ {
+		if again := s.Assign("ws-1"); again != first {
+			t.Fatalf("assignment moved from %q to %q; placement must be sticky", first, again)
+		}
+	}
+}
+
+func TestEngineSchedulerRelease(t *testing.T) {
+	s := NewEngineScheduler([]string{"tcp://engine-a:1234", "tcp://engine-b:1234"})
+
+	s.Assign("ws-1") // engine-a
+	s.Assign("ws-2") // engine-b
+	s.Release("ws-1")
+
+	// engine-a is now empty, so the next environment lands there.
+	if next := s.Assign("ws-3"); next != "tcp://engine-a:1234" {
+		t.Errorf("assignment after release = %q, want the freed engine", next)
+	}
+	loads := s.Loads()
+	if loads["tcp://engine-a:1234"] != 1 || loads["tcp://engine-b:1234"] != 1 {
+		t.Errorf("loads = %v, want 1 per engine", loads)
+	}
+}
+
+func TestEngineSchedulerEmptyFleet(t *testing.T) {
+	s := NewEngineScheduler(nil)
+	if engine := s.Assign("ws-1"); engine != "" {
+		t.Errorf("assignment with no engines = %q, want empty (local engine)", engine)
+	}
+}
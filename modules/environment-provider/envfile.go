@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseEnvFile reads a .env-style file: NAME=value lines, with blank
+// lines and # comments ignored, an optional "export " prefix, and
+// single or double quotes stripped from values. Teams already maintain
+// .env.development files; this accepts the common dialect instead of
+// inventing a new one.
+func ParseEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("env file: %w", err)
+	}
+
+	env := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("env file %s: line %d is not NAME=value", path, i+1)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// ReadEnvFiles parses each file and merges them in order, later files
+// overriding earlier ones. Values are read from the host at use time,
+// the same way registry credentials are, so secret-style values never
+// have to land in workspace records.
+func ReadEnvFiles(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	merged := make(map[string]string)
+	for _, path := range paths {
+		env, err := ParseEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range env {
+			merged[name] = value
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeEnvFile(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := writeEnvFile(t, ".env.development", `
+# database settings
+DATABASE_URL=postgres://localhost/dev
+export API_TOKEN="secret-token"
+EMPTY=
+QUOTED='single quoted'
+`)
+
+	env, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile: %v", err)
+	}
+	want := map[string]string{
+		"DATABASE_URL": "postgres://localhost/dev",
+		"API_TOKEN":    "secret-token",
+		"EMPTY":        "",
+		"QUOTED":       "single quoted",
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("env[%s] = %q, want %q", name, env[name], value)
+		}
+	}
+	if len(env) != len(want) {
+		t.Errorf("len(env) = %d, want %d", len(env), len(want))
+	}
+}
+
+func TestParseEnvFileRejectsBadLines(t *testing.T) {
+	for _, body := range []string{
+		"not a var line",
+		"2BAD=starts-with-digit",
+	} {
+		path := writeEnvFile(t, ".env", body)
+		if _, err := ParseEnvFile(path); err == nil {
+			t.Errorf("ParseEnvFile(%q) accepted invalid line", body)
+		}
+	}
+
+	if _, err := ParseEnvFile("/nonexistent/.env"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestReadEnvFilesMergeOrder(t *testing.T) {
+	base := writeEnvFile(t, ".env", "SHARED=base\nONLY_BASE=yes")
+	local := writeEnvFile(t, ".env.local", "SHARED=local")
+
+	env, err := ReadEnvFiles([]string{base, local})
+	if err != nil {
+		t.Fatalf("ReadEnvFiles: %v", err)
+	}
+	// Later files override earlier ones.
+	if env["SHARED"] != "local" || env["ONLY_BASE"] != "yes" {
+		t.Errorf("env = %v, want later file to win", env)
+	}
+
+	if env, err := ReadEnvFiles(nil); err != nil || env != nil {
+		t.Errorf("ReadEnvFiles(nil) = %v, %v, want nil, nil", env, err)
+	}
+}
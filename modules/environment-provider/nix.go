@@ -0,0 +1,43 @@
+package provider
+
+// DefaultNixImage is the image flake-based environments run in. It
+// carries nix itself; the flake's dev shell supplies everything else.
+const DefaultNixImage = "nixos/nix:2.24.9"
+
+// nixConfigEnv enables the (still experimental) flake commands.
+const nixConfigEnv = "experimental-features = nix-command flakes"
+
+// ApplyNixFlake expands a flake-based config: the nix image as default,
+// flakes enabled, and a setup step that builds the dev shell up front so
+// the first exec doesn't pay for it. Explicit config wins over the
+// defaults. Applying twice is a no-op, so the gateway can expand a
+// config before handing it to Create.
+func ApplyNixFlake(cfg Config) Config {
+	if cfg.NixFlake == "" {
+		return cfg
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultNixImage
+	}
+
+	env := make(map[string]string, len(cfg.Env)+1)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	if _, ok := env["NIX_CONFIG"]; !ok {
+		env["NIX_CONFIG"] = nixConfigEnv
+	}
+	cfg.Env = env
+
+	warm := "nix develop " + cfg.NixFlake + " --command true"
+	if len(cfg.Setup) == 0 || cfg.Setup[0] != warm {
+		cfg.Setup = append([]string{warm}, cfg.Setup...)
+	}
+	return cfg
+}
+
+// NixCommand wraps an argv so it runs inside the flake's dev shell,
+// where the toolchain the flake declares is on PATH.
+func NixCommand(flake string, command []string) []string {
+	return append([]string{"nix", "develop", flake, "--command"}, command...)
+}
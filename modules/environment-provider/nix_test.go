@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyNixFlake(t *testing.T) {
+	cfg := ApplyNixFlake(Config{NixFlake: ".", Setup: []string{"make deps"}})
+
+	if cfg.Image != DefaultNixImage {
+		t.Errorf("image = %q, want nix default", cfg.Image)
+	}
+	if !strings.Contains(cfg.Env["NIX_CONFIG"], "flakes") {
+		t.Errorf("NIX_CONFIG = %q, want flakes enabled", cfg.Env["NIX_CONFIG"])
+	}
+	if cfg.Setup[0] != "nix develop . --command true" {
+		t.Errorf("setup[0] = %q, want dev shell warm-up", cfg.Setup[0])
+	}
+	if cfg.Setup[1] != "make deps" {
+		t.Errorf("setup[1] = %q, want original setup preserved", cfg.Setup[1])
+	}
+
+	// Applying twice is a no-op.
+	if again := ApplyNixFlake(cfg); len(again.Setup) != len(cfg.Setup) {
+		t.Errorf("second apply grew setup to %v", again.Setup)
+	}
+
+	// Explicit config wins over the defaults.
+	custom := ApplyNixFlake(Config{NixFlake: ".", Image: "my/nix:local"})
+	if custom.Image != "my/nix:local" {
+		t.Errorf("image = %q, want explicit image preserved", custom.Image)
+	}
+
+	// No flake: untouched.
+	if got := ApplyNixFlake(Config{Image: "ubuntu:24.04"}); got.Setup != nil {
+		t.Errorf("flakeless config gained setup: %v", got.Setup)
+	}
+}
+
+func TestNixCommand(t *testing.T) {
+	got := NixCommand(".", []string{"go", "test", "./..."})
+	want := []string{"nix", "develop", ".", "--command", "go", "test", "./..."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NixCommand = %v, want %v", got, want)
+	}
+}
+
+func TestCreateRejectsFlakeWithDockerfile(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	_, err := p.Create(context.Background(), Config{
+		Name:       "demo",
+		NixFlake:   ".",
+		Dockerfile: "Dockerfile",
+	})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("err = %v, want mutual-exclusion error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Packages declares system and toolchain packages for the environment,
+// so simple setups don't need hand-written shell scripts. Each list maps
+// to its installer; declare the one matching the base image.
+type Packages struct {
+	// Apt packages, for Debian/Ubuntu images.
+	Apt []string `json:"apt,omitempty"`
+	// Apk packages, for Alpine images.
+	Apk []string `json:"apk,omitempty"`
+	// Pip packages, installed with the image's pip.
+	Pip []string `json:"pip,omitempty"`
+	// Npm packages, installed globally with the image's npm.
+	Npm []string `json:"npm,omitempty"`
+}
+
+// installCommands renders the declared packages as setup commands,
+// system installers first so toolchain installs find their libraries.
+func (p Packages) installCommands() []string {
+	var commands []string
+	if len(p.Apt) > 0 {
+		commands = append(commands,
+			"apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends "+strings.Join(p.Apt, " "))
+	}
+	if len(p.Apk) > 0 {
+		commands = append(commands, "apk add --no-cache "+strings.Join(p.Apk, " "))
+	}
+	if len(p.Pip) > 0 {
+		commands = append(commands, "pip install --no-cache-dir "+strings.Join(p.Pip, " "))
+	}
+	if len(p.Npm) > 0 {
+		commands = append(commands, "npm install -g "+strings.Join(p.Npm, " "))
+	}
+	return commands
+}
+
+// validate rejects package names that would escape the generated shell
+// commands. The charset covers version pins (pkg==1.2, pkg@1.2.3) and
+// npm scopes (@org/pkg).
+func (p Packages) validate() error {
+	for _, name := range append(append(append(append([]string(nil), p.Apt...), p.Apk...), p.Pip...), p.Npm...) {
+		if name == "" {
+			return fmt.Errorf("package name is required")
+		}
+		for _, r := range name {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			case strings.ContainsRune("._+=:@/~^<>,-", r):
+			default:
+				return fmt.Errorf("package name %q contains unsafe character %q", name, r)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyPackages expands declared packages into setup commands ahead of
+// the config's own setup. Applying twice is a no-op, so the gateway can
+// expand a config before handing it to Create.
+func ApplyPackages(cfg Config) Config {
+	commands := cfg.Packages.installCommands()
+	if len(commands) == 0 {
+		return cfg
+	}
+	if len(cfg.Setup) > 0 && cfg.Setup[0] == commands[0] {
+		return cfg
+	}
+	cfg.Setup = append(commands, cfg.Setup...)
+	return cfg
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackagesInstallCommands(t *testing.T) {
+	commands := Packages{
+		Apt: []string{"build-essential", "pkg-config"},
+		Pip: []string{"requests==2.31"},
+		Npm: []string{"@angular/cli"},
+	}.installCommands()
+
+	if len(commands) != 3 {
+		t.Fatalf("len(commands) = %d, want 3", len(commands))
+	}
+	if !strings.Contains(commands[0], "apt-get install -y --no-install-recommends build-essential pkg-config") {
+		t.Errorf("apt command = %q", commands[0])
+	}
+	if !strings.Contains(commands[1], "pip install --no-cache-dir requests==2.31") {
+		t.Errorf("pip command = %q", commands[1])
+	}
+	if !strings.Contains(commands[2], "npm install -g @angular/cli") {
+		t.Errorf("npm command = %q", commands[2])
+	}
+}
+
+func TestPackagesValidate(t *testing.T) {
+	valid := Packages{Apt: []string{"git"}, Apk: []string{"musl-dev"}, Pip: []string{"black"}, Npm: []string{"@org/pkg@1.2.3"}}
+	if err := valid.validate(); err != nil {
+		t.Errorf("valid packages rejected: %v", err)
+	}
+	if err := (Packages{Apt: []string{"git; rm -rf /"}}).validate(); err == nil {
+		t.Error("shell injection accepted")
+	}
+	if err := (Packages{Pip: []string{""}}).validate(); err == nil {
+		t.Error("empty package name accepted")
+	}
+}
+
+func TestApplyPackages(t *testing.T) {
+	cfg := ApplyPackages(Config{
+		Packages: Packages{Apk: []string{"git"}},
+		Setup:    []string{"make deps"},
+	})
+	if len(cfg.Setup) != 2 || !strings.HasPrefix(cfg.Setup[0], "apk add") {
+		t.Errorf("setup = %v, want install command prepended", cfg.Setup)
+	}
+	if cfg.Setup[1] != "make deps" {
+		t.Errorf("setup[1] = %q, want original setup preserved", cfg.Setup[1])
+	}
+
+	// Applying twice is a no-op.
+	if again := ApplyPackages(cfg); len(again.Setup) != len(cfg.Setup) {
+		t.Errorf("second apply grew setup to %v", again.Setup)
+	}
+
+	// No packages: untouched.
+	if got := ApplyPackages(Config{Setup: []string{"make"}}); len(got.Setup) != 1 {
+		t.Errorf("packageless config gained setup: %v", got.Setup)
+	}
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// ImagePolicy controls which base images environments may use, so
+// operators in regulated settings can stop `FROM random/dockerhub:latest`
+// before it runs. Patterns match the full image reference and '*'
+// matches any run of characters, e.g. "ghcr.io/myorg/*" or "*:latest".
+type ImagePolicy struct {
+	// Allow lists patterns an image must match. Empty allows all images
+	// (minus denials).
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists patterns that reject an image even when allowed. Deny
+	// wins over allow.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Check returns an errdefs.ErrForbidden error when the image is not
+// entitled under the policy.
+func (p ImagePolicy) Check(image string) error {
+	for _, pattern := range p.Deny {
+		if matchPattern(pattern, image) {
+			return fmt.Errorf("image %s denied by policy (%s): %w", image, pattern, errdefs.ErrForbidden)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range p.Allow {
+		if matchPattern(pattern, image) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s not in policy allowlist: %w", image, errdefs.ErrForbidden)
+}
+
+// matchPattern reports whether ref matches pattern, where '*' matches
+// any run of characters including '/' and ':'. path.Match is not used
+// because image references contain separators a glob star must cross.
+func matchPattern(pattern, ref string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == ref
+	}
+
+	if !strings.HasPrefix(ref, parts[0]) {
+		return false
+	}
+	ref = ref[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(ref, part)
+		if idx < 0 {
+			return false
+		}
+		ref = ref[idx+len(part):]
+	}
+	return strings.HasSuffix(ref, parts[len(parts)-1])
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		ref     string
+		want    bool
+	}{
+		{"ubuntu:24.04", "ubuntu:24.04", true},
+		{"ubuntu:24.04", "ubuntu:22.04", false},
+		{"ghcr.io/myorg/*", "ghcr.io/myorg/builder:v3", true},
+		{"ghcr.io/myorg/*", "ghcr.io/other/builder:v3", false},
+		{"*:latest", "random/dockerhub:latest", true},
+		{"*:latest", "random/dockerhub:v1", false},
+		{"harbor.internal/*/release:*", "harbor.internal/team-a/release:2024.1", true},
+		{"*", "anything:at-all", true},
+	}
+	for _, tc := range cases {
+		if got := matchPattern(tc.pattern, tc.ref); got != tc.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tc.pattern, tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestImagePolicyCheck(t *testing.T) {
+	// Empty policy allows everything.
+	if err := (ImagePolicy{}).Check("random/dockerhub:latest"); err != nil {
+		t.Errorf("empty policy rejected image: %v", err)
+	}
+
+	policy := ImagePolicy{
+		Allow: []string{"ghcr.io/myorg/*", "ubuntu:*"},
+		Deny:  []string{"*:latest"},
+	}
+
+	if err := policy.Check("ubuntu:24.04"); err != nil {
+		t.Errorf("allowed image rejected: %v", err)
+	}
+	if err := policy.Check("random/dockerhub:v1"); !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want ErrForbidden for unlisted image", err)
+	}
+	// Deny wins over allow.
+	if err := policy.Check("ghcr.io/myorg/builder:latest"); !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want ErrForbidden for denied tag", err)
+	}
+}
+
+func TestCreateEnforcesImagePolicy(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	p.SetImagePolicy(ImagePolicy{Allow: []string{"ghcr.io/myorg/*"}})
+
+	_, err := p.Create(context.Background(), Config{Name: "demo", Image: "random/dockerhub:latest"})
+	if !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want ErrForbidden", err)
+	}
+	// The default image is subject to policy too.
+	_, err = p.Create(context.Background(), Config{Name: "demo"})
+	if !errdefs.IsForbidden(err) {
+		t.Errorf("err = %v, want ErrForbidden for default image", err)
+	}
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// refillInterval is how often the pool tops itself back up, in addition
+// to the immediate refill after every Take.
+const refillInterval = time.Minute
+
+// WarmPool keeps environments for configured templates pre-created in
+// the runtime, so workspace creation hands out a ready environment in
+// seconds instead of paying for pulls and builds on demand. Runtimes
+// that create per-workspace resources (pods) still pay those at create
+// time; the pool keeps their images warm.
+type WarmPool struct {
+	provider  *Provider
+	templates *Templates
+	// names are the templates kept warm.
+	names []string
+	// size is the number of pre-created environments kept per template.
+	size int
+
+	mu   sync.Mutex
+	pool map[string][]*Environment
+
+	refill chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewWarmPool creates a pool of size environments for each named
+// template. Call Start to begin filling.
+func NewWarmPool(p *Provider, templates *Templates, names []string, size int) *WarmPool {
+	return &WarmPool{
+		provider:  p,
+		templates: templates,
+		names:     names,
+		size:      size,
+		pool:      make(map[string][]*Environment),
+		refill:    make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start fills the pool in the background and keeps it topped up until
+// Stop is called.
+func (wp *WarmPool) Start() {
+	go wp.run()
+}
+
+// Stop ends background refilling. Pooled environments stay in the
+// runtime cache; they are not torn down.
+func (wp *WarmPool) Stop() {
+	close(wp.stop)
+	<-wp.done
+}
+
+// Take pops a pre-created environment for the template, or nil when the
+// pool has none. A refill is kicked off either way.
+func (wp *WarmPool) Take(template string) *Environment {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	select {
+	case wp.refill <- struct{}{}:
+	default: // refill already pending
+	}
+
+	envs := wp.pool[template]
+	if len(envs) == 0 {
+		return nil
+	}
+	env := envs[len(envs)-1]
+	wp.pool[template] = envs[:len(envs)-1]
+	return env
+}
+
+// run is the pool's background loop: fill immediately, then on every
+// Take and on a slow timer.
+func (wp *WarmPool) run() {
+	defer close(wp.done)
+	ticker := time.NewTicker(refillInterval)
+	defer ticker.Stop()
+
+	wp.fill()
+	for {
+		select {
+		case <-wp.stop:
+			return
+		case <-wp.refill:
+			wp.fill()
+		case <-ticker.C:
+			wp.fill()
+		}
+	}
+}
+
+// fill tops every template up to the pool size.
+func (wp *WarmPool) fill() {
+	for _, name := range wp.names {
+		tpl, err := wp.templates.Resolve(name)
+		if err != nil {
+			log.Printf("warm pool: %v", err)
+			continue
+		}
+		for wp.count(name) < wp.size {
+			cfg := tpl.Apply(Config{Name: "pool-" + name + "-" + newID()})
+			env, err := wp.provider.Create(context.Background(), cfg)
+			if err != nil {
+				log.Printf("warm pool: create %s: %v", name, err)
+				break
+			}
+			wp.put(name, env)
+		}
+	}
+}
+
+func (wp *WarmPool) count(template string) int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return len(wp.pool[template])
+}
+
+func (wp *WarmPool) put(template string, env *Environment) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.pool[template] = append(wp.pool[template], env)
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// stubBackend counts warm requests without touching any runtime.
+type stubBackend struct {
+	mu     sync.Mutex
+	warmed []string
+}
+
+func (b *stubBackend) Name() string { return "stub" }
+
+func (b *stubBackend) WarmImage(_ context.Context, cfg Config) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.warmed = append(b.warmed, cfg.Image)
+	return nil
+}
+
+func (b *stubBackend) BuildImage(_ context.Context, _ Config) error { return nil }
+
+func TestWarmPoolFillAndTake(t *testing.T) {
+	backend := &stubBackend{}
+	p := NewWithBackend(backend)
+	wp := NewWarmPool(p, NewTemplates(""), []string{"go-1.22"}, 2)
+
+	wp.fill()
+	if got := wp.count("go-1.22"); got != 2 {
+		t.Fatalf("pool size = %d, want 2", got)
+	}
+
+	env := wp.Take("go-1.22")
+	if env == nil {
+		t.Fatal("Take returned nil from a filled pool")
+	}
+	if env.Image != "golang:1.22" {
+		t.Errorf("pooled image = %q, want template image", env.Image)
+	}
+	if got := wp.count("go-1.22"); got != 1 {
+		t.Errorf("pool size after take = %d, want 1", got)
+	}
+
+	// Unknown templates miss without filling anything.
+	if env := wp.Take("cobol-74"); env != nil {
+		t.Errorf("Take for unknown template = %v, want nil", env)
+	}
+}
+
+func TestWarmPoolRefillOnTake(t *testing.T) {
+	backend := &stubBackend{}
+	p := NewWithBackend(backend)
+	wp := NewWarmPool(p, NewTemplates(""), []string{"node-20"}, 1)
+	wp.Start()
+	defer wp.Stop()
+
+	// Drain until a fill has happened, then confirm it refills.
+	for wp.Take("node-20") == nil {
+	}
+	for wp.Take("node-20") == nil {
+	}
+}
+
+func TestWarmPoolUnknownTemplate(t *testing.T) {
+	p := NewWithBackend(&stubBackend{})
+	wp := NewWarmPool(p, NewTemplates(""), []string{"no-such"}, 1)
+
+	// Unresolvable templates are skipped, not fatal.
+	wp.fill()
+	if got := wp.count("no-such"); got != 0 {
+		t.Errorf("pool size = %d, want 0", got)
+	}
+}
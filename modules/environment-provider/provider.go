@@ -0,0 +1,763 @@
+// Package provider creates container environments for workspaces.
+//
+// Environments are images prepared in a container runtime's cache;
+// execution runs as one-shot pipelines against them. The runtime itself
+// is abstracted behind Backend so hosts that cannot run the Dagger
+// engine can fall back to a local Docker Engine.
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultImage is used when a workspace doesn't specify one.
+const DefaultImage = "ubuntu:24.04"
+
+// DefaultWorkdir is where the project is mounted inside environment
+// containers, matching the executor's default working directory.
+const DefaultWorkdir = "/workspace/project"
+
+// Config describes the environment to create.
+type Config struct {
+	// Name identifies the environment (usually the workspace name).
+	Name string `json:"name"`
+	// Image is the base container image. Defaults to DefaultImage.
+	Image string `json:"image"`
+	// Platform selects the image architecture (e.g. "linux/amd64",
+	// "linux/arm64"). Empty trusts the host default; Apple Silicon hosts
+	// and cross-compilation workflows pick one explicitly.
+	Platform string `json:"platform,omitempty"`
+	// Engine is the Dagger engine endpoint the environment is placed on
+	// (tcp://host:port or unix:// socket). Normally assigned by the
+	// gateway's engine scheduler; empty uses the local engine.
+	Engine string `json:"engine,omitempty"`
+	// Workdir is the mount point for the workspace inside the container.
+	Workdir string `json:"workdir"`
+	// Dockerfile builds the environment image instead of pulling Image.
+	// The path is relative to BuildContext.
+	Dockerfile string `json:"dockerfile,omitempty"`
+	// BuildContext is the host directory used as the build context.
+	// Defaults to the directory containing the Dockerfile.
+	BuildContext string `json:"build_context,omitempty"`
+	// NixFlake builds the environment from a flake.nix dev shell instead
+	// of a Dockerfile. It is a flake reference resolved in the workdir
+	// (usually "." for the repo's own flake).
+	NixFlake string `json:"nix_flake,omitempty"`
+	// BuildSecrets are mounted into the build as runtime secrets so they
+	// never end up in image layers.
+	BuildSecrets []BuildSecret `json:"build_secrets,omitempty"`
+	// ForwardSSH forwards the host SSH agent into the build for fetching
+	// private dependencies.
+	ForwardSSH bool `json:"forward_ssh,omitempty"`
+	// Resources bounds the environment's container so one runaway
+	// workspace cannot starve the host. Zero values mean unlimited.
+	Resources Resources `json:"resources,omitempty"`
+	// Caches maps named cache volumes to their mount path inside the
+	// container (e.g. "cargo" -> "/root/.cargo/registry"). Cache volumes
+	// outlive the environment, so package caches survive workspace
+	// recreation.
+	Caches map[string]string `json:"caches,omitempty"`
+	// Mounts bind extra host directories into the environment, each with
+	// its own choice between a live mount and an isolated copy.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Services are companion containers (e.g. Postgres, Redis) started
+	// alongside the environment; the main container reaches each one by
+	// its name and gets <NAME>_HOST/<NAME>_PORT env vars.
+	Services []Service `json:"services,omitempty"`
+	// Ports are container ports exposed on the host, so a dev server
+	// running in the workspace can be reached from outside. The resolved
+	// host bindings are reported on the Environment.
+	Ports []int `json:"ports,omitempty"`
+	// RegistryAuth holds per-registry credentials so Image can reference
+	// private registries (ghcr.io, an internal Harbor, ...).
+	RegistryAuth []RegistryAuth `json:"registry_auth,omitempty"`
+	// Proxy routes container traffic through a corporate proxy and
+	// installs its CA into the container trust store.
+	Proxy Proxy `json:"proxy,omitempty"`
+	// Env sets environment variables in the workspace container.
+	Env map[string]string `json:"env,omitempty"`
+	// EnvFiles are .env-style host files whose variables are injected
+	// into the container. Explicit Env entries win over file values.
+	EnvFiles []string `json:"env_files,omitempty"`
+	// Setup are shell commands run once after the environment is ready
+	// (tool installs, dependency priming). The provider records them;
+	// the gateway runs them through the executor.
+	Setup []string `json:"setup,omitempty"`
+	// Packages declares system/toolchain packages to install, expanded
+	// into setup commands ahead of Setup.
+	Packages Packages `json:"packages,omitempty"`
+	// Health probes the environment after creation, so a workspace whose
+	// toolchain is broken reports unhealthy instead of ready.
+	Health HealthProbe `json:"health,omitempty"`
+	// User is the non-root user commands run as. Root-owned files on
+	// mounted worktrees are the default failure mode; matching the host
+	// user avoids it.
+	User string `json:"user,omitempty"`
+	// UID and GID pin the user's IDs, typically to the host user's, so
+	// files written in the container stay owned by the developer.
+	UID int `json:"uid,omitempty"`
+	GID int `json:"gid,omitempty"`
+}
+
+// UserSpec renders the config's user for container runtimes: numeric
+// "uid:gid" when IDs are pinned, else the user name. Empty means root.
+func (c Config) UserSpec() string {
+	if c.UID > 0 {
+		gid := c.GID
+		if gid == 0 {
+			gid = c.UID
+		}
+		return fmt.Sprintf("%d:%d", c.UID, gid)
+	}
+	return c.User
+}
+
+// EnsureUserCommand returns a shell command that creates the config's
+// user in the image when missing, covering both useradd (glibc) and
+// adduser (busybox/alpine) images. Empty when no named user is set.
+func (c Config) EnsureUserCommand() string {
+	if c.User == "" {
+		return ""
+	}
+	uid := c.UID
+	if uid == 0 {
+		uid = 1000
+	}
+	return fmt.Sprintf(
+		"id -u %[1]s >/dev/null 2>&1 || useradd -m -u %[2]d %[1]s 2>/dev/null || adduser -D -u %[2]d %[1]s",
+		c.User, uid)
+}
+
+// RegistryAuth is a credential for one image registry. Like build
+// secrets, the password is sourced from the host at use time so values
+// never land in workspace records.
+type RegistryAuth struct {
+	// Registry is the registry host, e.g. "ghcr.io".
+	Registry string `json:"registry"`
+	// Username for basic auth. Token-only registries accept a dummy
+	// username with the token as password; leave it empty for those.
+	Username string `json:"username,omitempty"`
+	// PasswordEnv names a host env var holding the password or token.
+	PasswordEnv string `json:"password_env,omitempty"`
+	// PasswordFile is a host file holding the password or token.
+	PasswordFile string `json:"password_file,omitempty"`
+}
+
+// validate rejects credentials without a registry or password source.
+func (a RegistryAuth) validate() error {
+	if a.Registry == "" {
+		return fmt.Errorf("registry auth: registry is required")
+	}
+	switch {
+	case a.PasswordEnv != "" && a.PasswordFile != "":
+		return fmt.Errorf("registry auth %s: set password_env or password_file, not both", a.Registry)
+	case a.PasswordEnv == "" && a.PasswordFile == "":
+		return fmt.Errorf("registry auth %s: password_env or password_file is required", a.Registry)
+	}
+	return nil
+}
+
+// password resolves the credential's password from its host source.
+func (a RegistryAuth) password() (string, error) {
+	if err := a.validate(); err != nil {
+		return "", err
+	}
+	if a.PasswordEnv != "" {
+		value := os.Getenv(a.PasswordEnv)
+		if value == "" {
+			return "", fmt.Errorf("registry auth %s: %s is not set", a.Registry, a.PasswordEnv)
+		}
+		return value, nil
+	}
+	data, err := os.ReadFile(a.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("registry auth %s: %w", a.Registry, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Mount modes: how a host path gets into the environment.
+const (
+	// MountModeRO binds the host path read-only; the environment sees
+	// live host changes but cannot write back.
+	MountModeRO = "ro-mount"
+	// MountModeRW binds the host path read-write; changes flow both ways.
+	MountModeRW = "rw-mount"
+	// MountModeCopy copies the host path in, isolating the environment
+	// from later host changes (and the host from the environment).
+	MountModeCopy = "copy"
+)
+
+// Mount binds one host directory into the environment. The mode is a
+// per-path choice between live host-synced state and an isolated copy,
+// instead of one hardcoded behavior for everything.
+type Mount struct {
+	// Source is the host directory.
+	Source string `json:"source"`
+	// Target is the absolute path inside the container.
+	Target string `json:"target"`
+	// Mode is ro-mount, rw-mount or copy. Defaults to rw-mount.
+	Mode string `json:"mode,omitempty"`
+}
+
+// mode returns the mount's mode with the default applied.
+func (m Mount) mode() string {
+	if m.Mode == "" {
+		return MountModeRW
+	}
+	return m.Mode
+}
+
+// validateMounts rejects unusable mount declarations.
+func validateMounts(mounts []Mount) error {
+	for _, m := range mounts {
+		if m.Source == "" || m.Target == "" {
+			return fmt.Errorf("mount source and target are required")
+		}
+		if !strings.HasPrefix(m.Target, "/") {
+			return fmt.Errorf("mount target %q must be absolute", m.Target)
+		}
+		switch m.mode() {
+		case MountModeRO, MountModeRW, MountModeCopy:
+		default:
+			return fmt.Errorf("mount %s: mode %q must be ro-mount, rw-mount or copy", m.Target, m.Mode)
+		}
+	}
+	return nil
+}
+
+// Service is a companion container started with the environment, for
+// workloads like integration test suites that need a live database.
+type Service struct {
+	// Name is the service's hostname and the prefix of its injected env
+	// vars (name "postgres" yields POSTGRES_HOST and POSTGRES_PORT).
+	Name string `json:"name"`
+	// Image is the service container image.
+	Image string `json:"image"`
+	// Ports are the TCP ports the service listens on. The first port is
+	// advertised as <NAME>_PORT.
+	Ports []int `json:"ports"`
+	// Env sets environment variables on the service container (e.g.
+	// POSTGRES_PASSWORD).
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// EnvPrefix returns the service's env var prefix: upper-cased name with
+// dashes mapped to underscores.
+func (s Service) EnvPrefix() string {
+	return strings.ToUpper(strings.ReplaceAll(s.Name, "-", "_"))
+}
+
+// validateServices rejects unusable service declarations.
+func validateServices(services []Service) error {
+	for _, svc := range services {
+		if svc.Name == "" {
+			return fmt.Errorf("service name is required")
+		}
+		if !validServiceName(svc.Name) {
+			return fmt.Errorf("service name %q must be a DNS label (lowercase alphanumerics and dashes)", svc.Name)
+		}
+		if svc.Image == "" {
+			return fmt.Errorf("service %s: image is required", svc.Name)
+		}
+		if len(svc.Ports) == 0 {
+			return fmt.Errorf("service %s: at least one port is required", svc.Name)
+		}
+	}
+	return nil
+}
+
+// validServiceName reports whether the name is a DNS-safe label, since
+// service names become hostnames (pipeline aliases, pod sidecars) and
+// anything else fails at resolution time with no useful error.
+func validServiceName(name string) bool {
+	if len(name) > 63 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-' && i > 0 && i < len(name)-1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validatePlatform rejects platforms that aren't an os/arch pair.
+func validatePlatform(platform string) error {
+	if platform == "" {
+		return nil
+	}
+	os, arch, ok := strings.Cut(platform, "/")
+	if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+		return fmt.Errorf("platform %q must be os/arch (e.g. linux/amd64)", platform)
+	}
+	return nil
+}
+
+// platformArch returns the architecture half of an os/arch platform.
+func platformArch(platform string) string {
+	_, arch, _ := strings.Cut(platform, "/")
+	return arch
+}
+
+// validatePorts rejects ports outside the valid TCP range.
+func validatePorts(ports []int) error {
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("port %d is out of range (1-65535)", port)
+		}
+	}
+	return nil
+}
+
+// validateCaches rejects unusable cache declarations. Names become
+// runtime volume identifiers and mount paths must be absolute.
+func validateCaches(caches map[string]string) error {
+	for name, path := range caches {
+		if name == "" {
+			return fmt.Errorf("cache volume name is required")
+		}
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("cache %s: mount path %q must be absolute", name, path)
+		}
+	}
+	return nil
+}
+
+// Health probe defaults.
+const (
+	DefaultHealthInterval = 30
+	DefaultHealthRetries  = 3
+)
+
+// HealthProbe is a periodic command that checks the environment from
+// the inside; exit 0 means healthy.
+type HealthProbe struct {
+	// Command is the probe argv. Empty disables probing.
+	Command []string `json:"command,omitempty"`
+	// IntervalSeconds between probes. Defaults to DefaultHealthInterval.
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	// Retries is how many consecutive failures mark the environment
+	// unhealthy. Defaults to DefaultHealthRetries.
+	Retries int `json:"retries,omitempty"`
+}
+
+// Interval returns the probe interval with the default applied.
+func (h HealthProbe) Interval() int {
+	if h.IntervalSeconds <= 0 {
+		return DefaultHealthInterval
+	}
+	return h.IntervalSeconds
+}
+
+// MaxRetries returns the failure threshold with the default applied.
+func (h HealthProbe) MaxRetries() int {
+	if h.Retries <= 0 {
+		return DefaultHealthRetries
+	}
+	return h.Retries
+}
+
+// validate rejects negative probe settings.
+func (h HealthProbe) validate() error {
+	if h.IntervalSeconds < 0 || h.Retries < 0 {
+		return fmt.Errorf("health probe interval and retries must not be negative")
+	}
+	return nil
+}
+
+// Resources are per-workspace container quotas.
+type Resources struct {
+	// CPUs is the CPU core limit (fractions allowed, e.g. 1.5).
+	CPUs float64 `json:"cpus,omitempty"`
+	// MemoryMB is the memory limit in megabytes.
+	MemoryMB int `json:"memory_mb,omitempty"`
+	// DiskMB is the writable-layer/ephemeral-storage limit in megabytes.
+	DiskMB int `json:"disk_mb,omitempty"`
+	// GPUs requests GPU devices for CUDA workloads. Only backends that
+	// can attach GPUs accept a non-zero count.
+	GPUs int `json:"gpus,omitempty"`
+}
+
+// validate rejects negative quotas.
+func (r Resources) validate() error {
+	if r.CPUs < 0 || r.MemoryMB < 0 || r.DiskMB < 0 || r.GPUs < 0 {
+		return fmt.Errorf("resource limits must not be negative")
+	}
+	return nil
+}
+
+// BuildSecret is a build-time secret sourced from the host. Exactly one
+// of Env or File must be set.
+type BuildSecret struct {
+	// Name is the secret ID the Dockerfile references
+	// (RUN --mount=type=secret,id=<name>).
+	Name string `json:"name"`
+	// Env is a host environment variable holding the secret value.
+	Env string `json:"env,omitempty"`
+	// File is a host file holding the secret value.
+	File string `json:"file,omitempty"`
+}
+
+// uri renders the secret as a Dagger secret URI (env:// or file://).
+func (s BuildSecret) uri() (string, error) {
+	if s.Name == "" {
+		return "", fmt.Errorf("build secret name is required")
+	}
+	switch {
+	case s.Env != "" && s.File != "":
+		return "", fmt.Errorf("build secret %s: set env or file, not both", s.Name)
+	case s.Env != "":
+		return "env://" + s.Env, nil
+	case s.File != "":
+		return "file://" + s.File, nil
+	default:
+		return "", fmt.Errorf("build secret %s: env or file is required", s.Name)
+	}
+}
+
+// PortBinding reports where an exposed container port landed on the
+// host, so clients know the address to dial.
+type PortBinding struct {
+	// ContainerPort is the port inside the container.
+	ContainerPort int `json:"container_port"`
+	// HostPort is the port the binding resolved to on the host.
+	HostPort int `json:"host_port"`
+}
+
+// ServiceEndpoint records where a sidecar service is reachable from
+// inside the workspace, so code can discover its database instead of
+// hardcoding addresses.
+type ServiceEndpoint struct {
+	// Name is the service's declared name.
+	Name string `json:"name"`
+	// Host is the hostname the service answers on inside the workspace.
+	Host string `json:"host"`
+	// Port is the service's primary port (also injected as <NAME>_PORT).
+	Port int `json:"port"`
+}
+
+// Environment is a created container environment.
+type Environment struct {
+	ID        string    `json:"id"`
+	Image     string    `json:"image"`
+	CreatedAt time.Time `json:"created_at"`
+	// Ports are the resolved host bindings for the config's exposed
+	// ports; empty when none were requested.
+	Ports []PortBinding `json:"ports,omitempty"`
+	// Services are the resolved endpoints of the config's sidecar
+	// services; empty when none were declared.
+	Services []ServiceEndpoint `json:"services,omitempty"`
+}
+
+// Provider creates and destroys container environments through a Backend.
+type Provider struct {
+	backend Backend
+	// sem bounds simultaneous backend operations; nil means unlimited.
+	sem chan struct{}
+	// policy restricts which base images environments may use.
+	policy ImagePolicy
+}
+
+// New creates a provider on the default Dagger backend.
+func New() *Provider {
+	return NewWithBackend(NewDaggerBackend())
+}
+
+// NewWithBackend creates a provider on the given container runtime.
+func NewWithBackend(b Backend) *Provider {
+	return &Provider{backend: b}
+}
+
+// Backend returns the container runtime this provider drives.
+func (p *Provider) Backend() Backend {
+	return p.backend
+}
+
+// SetImagePolicy restricts which base images environments may use.
+func (p *Provider) SetImagePolicy(policy ImagePolicy) {
+	p.policy = policy
+}
+
+// CheckImage applies the provider's image policy to an image reference.
+// Exposed so callers can reject a disallowed image synchronously before
+// kicking off background creation.
+func (p *Provider) CheckImage(image string) error {
+	return p.policy.Check(image)
+}
+
+// SetConcurrencyLimit bounds simultaneous backend operations. Unbounded
+// concurrent builds destabilize the engine under load spikes; n <= 0
+// removes the limit.
+func (p *Provider) SetConcurrencyLimit(n int) {
+	if n <= 0 {
+		p.sem = nil
+		return
+	}
+	p.sem = make(chan struct{}, n)
+}
+
+// acquire takes a semaphore slot, honoring context cancellation. The
+// returned func releases the slot.
+func (p *Provider) acquire(ctx context.Context) (func(), error) {
+	if p.sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.sem <- struct{}{}:
+		return func() { <-p.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Create prepares a container environment: fills config defaults, makes
+// the image available in the runtime and returns the environment record.
+func (p *Provider) Create(ctx context.Context, cfg Config) (*Environment, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("environment name is required")
+	}
+	if cfg.NixFlake != "" && cfg.Dockerfile != "" {
+		return nil, fmt.Errorf("nix flake and Dockerfile are mutually exclusive")
+	}
+	if err := cfg.Packages.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Health.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Proxy.CABundle != "" {
+		if _, err := os.Stat(cfg.Proxy.CABundle); err != nil {
+			return nil, fmt.Errorf("ca bundle: %w", err)
+		}
+	}
+	cfg = ApplyNixFlake(cfg)
+	cfg = ApplyPackages(cfg)
+	cfg = ApplyProxy(cfg)
+	if len(cfg.EnvFiles) > 0 {
+		fileEnv, err := ReadEnvFiles(cfg.EnvFiles)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Env = mergeMaps(fileEnv, cfg.Env)
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if err := p.policy.Check(cfg.Image); err != nil {
+		return nil, err
+	}
+	if err := validatePlatform(cfg.Platform); err != nil {
+		return nil, err
+	}
+	if err := cfg.Resources.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.UID < 0 || cfg.GID < 0 {
+		return nil, fmt.Errorf("uid and gid must not be negative")
+	}
+	if err := validateCaches(cfg.Caches); err != nil {
+		return nil, err
+	}
+	if err := validateServices(cfg.Services); err != nil {
+		return nil, err
+	}
+	if err := validateMounts(cfg.Mounts); err != nil {
+		return nil, err
+	}
+	// Pods bind hostPath volumes; there is no pipeline step to copy into.
+	if _, ok := p.backend.(LifecycleBackend); ok {
+		for _, m := range cfg.Mounts {
+			if m.mode() == MountModeCopy {
+				return nil, fmt.Errorf("backend %s cannot copy %s into the pod; use ro-mount or rw-mount", p.backend.Name(), m.Source)
+			}
+		}
+	}
+	if err := validatePorts(cfg.Ports); err != nil {
+		return nil, err
+	}
+	if len(cfg.Ports) > 0 {
+		if _, ok := p.backend.(PortBackend); !ok {
+			return nil, fmt.Errorf("backend %s cannot expose ports on the host", p.backend.Name())
+		}
+	}
+	if cfg.Resources.GPUs > 0 {
+		gb, ok := p.backend.(GPUBackend)
+		if !ok {
+			return nil, fmt.Errorf("backend %s cannot attach GPUs", p.backend.Name())
+		}
+		if err := gb.SupportsGPU(ctx); err != nil {
+			return nil, fmt.Errorf("GPUs unavailable: %w", err)
+		}
+	}
+
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Authenticate before pulling or building, so private base images
+	// fail with a credential error instead of a silent pull failure.
+	if len(cfg.RegistryAuth) > 0 {
+		ab, ok := p.backend.(AuthBackend)
+		if !ok {
+			return nil, fmt.Errorf("backend %s cannot authenticate to registries", p.backend.Name())
+		}
+		for _, auth := range cfg.RegistryAuth {
+			if err := ab.Login(ctx, auth); err != nil {
+				return nil, fmt.Errorf("login to %s: %w", auth.Registry, err)
+			}
+		}
+	}
+
+	if cfg.Dockerfile != "" {
+		// Build the image in the runtime cache; exec pipelines reuse it.
+		if err := p.backend.BuildImage(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("build %s: %w", cfg.Dockerfile, err)
+		}
+	} else {
+		// Pull the image through the runtime so first exec doesn't pay for it.
+		if err := p.backend.WarmImage(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("warm image %s: %w", cfg.Image, err)
+		}
+	}
+
+	// Long-running backends (pods) also need their runtime started.
+	if lb, ok := p.backend.(LifecycleBackend); ok {
+		if err := lb.CreateRuntime(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("create runtime for %s: %w", cfg.Name, err)
+		}
+	}
+
+	env := &Environment{
+		ID:        newID(),
+		Image:     cfg.Image,
+		CreatedAt: time.Now().UTC(),
+		Services:  p.ServiceEndpoints(cfg.Services),
+	}
+	// Bindings resolve after the runtime exists: the backend decides
+	// which host ports the container ports actually landed on.
+	if len(cfg.Ports) > 0 {
+		bindings, err := p.backend.(PortBackend).ExposePorts(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("expose ports for %s: %w", cfg.Name, err)
+		}
+		env.Ports = bindings
+	}
+	return env, nil
+}
+
+// Rebuild recreates the environment's container with an updated config
+// (new base image, new env vars) while keeping the environment's
+// identity, so callers can change a workspace without delete-and-
+// recreate. The worktree mount is untouched: pipeline backends mount it
+// per exec, lifecycle backends remount it with the new runtime.
+func (p *Provider) Rebuild(ctx context.Context, env *Environment, cfg Config) (*Environment, error) {
+	if env == nil {
+		return nil, fmt.Errorf("environment is required")
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("environment name is required")
+	}
+
+	// Tear down the old runtime first; pipeline backends have nothing
+	// running, so this is a no-op for them.
+	if err := p.Destroy(ctx, cfg.Name); err != nil {
+		return nil, fmt.Errorf("destroy old runtime for %s: %w", cfg.Name, err)
+	}
+
+	rebuilt, err := p.Create(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Only the container changed; the environment record survives.
+	rebuilt.ID = env.ID
+	rebuilt.CreatedAt = env.CreatedAt
+	return rebuilt, nil
+}
+
+// ServiceEndpoints resolves where each sidecar service is reachable on
+// this provider's backend: pod sidecars share the workspace's network
+// namespace and answer on localhost, pipeline services answer on their
+// name. The same addresses land in <NAME>_HOST/<NAME>_PORT env vars.
+func (p *Provider) ServiceEndpoints(services []Service) []ServiceEndpoint {
+	if len(services) == 0 {
+		return nil
+	}
+	endpoints := make([]ServiceEndpoint, len(services))
+	for i, svc := range services {
+		host := svc.Name
+		if _, ok := p.backend.(LifecycleBackend); ok {
+			host = "localhost"
+		}
+		endpoints[i] = ServiceEndpoint{Name: svc.Name, Host: host}
+		if len(svc.Ports) > 0 {
+			endpoints[i].Port = svc.Ports[0]
+		}
+	}
+	return endpoints
+}
+
+// Export publishes the environment's image under ref, closing the loop
+// between interactive workspaces and CI: a provisioned workspace can be
+// reused as a base image or handed to a pipeline. A ref ending in .tar
+// writes an OCI tarball to that path instead of pushing.
+func (p *Provider) Export(ctx context.Context, env *Environment, ref string) error {
+	if env == nil || env.Image == "" {
+		return fmt.Errorf("environment with an image is required")
+	}
+	if ref == "" {
+		return fmt.Errorf("export reference is required")
+	}
+	eb, ok := p.backend.(ExportBackend)
+	if !ok {
+		return fmt.Errorf("backend %s cannot export images", p.backend.Name())
+	}
+
+	release, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if strings.HasSuffix(ref, ".tar") {
+		if err := eb.SaveImage(ctx, env.Image, ref); err != nil {
+			return fmt.Errorf("save %s: %w", env.Image, err)
+		}
+		return nil
+	}
+	if err := eb.PublishImage(ctx, env.Image, ref); err != nil {
+		return fmt.Errorf("publish %s: %w", env.Image, err)
+	}
+	return nil
+}
+
+// Destroy releases the named environment. Pipeline backends have no
+// long-running container to stop; lifecycle backends tear down their
+// runtime. The runtime cache is left intact either way.
+func (p *Provider) Destroy(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("environment name is required")
+	}
+	if lb, ok := p.backend.(LifecycleBackend); ok {
+		return lb.DestroyRuntime(ctx, name)
+	}
+	return nil
+}
+
+// newID returns a short random environment ID.
+func newID() string {
+	buf := make([]byte, 4)
+	rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+	return "env-" + hex.EncodeToString(buf)
+}
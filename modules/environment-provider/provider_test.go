@@ -0,0 +1,353 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildArgsSecrets(t *testing.T) {
+	cfg := Config{
+		Dockerfile:   "docker/Dockerfile",
+		BuildContext: "docker",
+		BuildSecrets: []BuildSecret{
+			{Name: "npm_token", Env: "NPM_TOKEN"},
+			{Name: "netrc", File: "/home/dev/.netrc"},
+		},
+	}
+
+	args, err := buildArgs(cfg, "")
+	if err != nil {
+		t.Fatalf("buildArgs: %v", err)
+	}
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"--dockerfile=docker/Dockerfile",
+		"--path=docker",
+		"--secrets=npm_token=env://NPM_TOKEN",
+		"--secrets=netrc=file:///home/dev/.netrc",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "--ssh-auth-socket") {
+		t.Error("SSH socket passed without ForwardSSH")
+	}
+}
+
+func TestBuildArgsDefaultContext(t *testing.T) {
+	args, err := buildArgs(Config{Dockerfile: "docker/Dockerfile"}, "")
+	if err != nil {
+		t.Fatalf("buildArgs: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--path=docker") {
+		t.Errorf("context should default to Dockerfile directory: %v", args)
+	}
+}
+
+func TestBuildArgsSSHForwarding(t *testing.T) {
+	cfg := Config{Dockerfile: "Dockerfile", ForwardSSH: true}
+
+	if _, err := buildArgs(cfg, ""); err == nil {
+		t.Error("expected error when SSH_AUTH_SOCK is unset")
+	}
+
+	args, err := buildArgs(cfg, "/tmp/agent.sock")
+	if err != nil {
+		t.Fatalf("buildArgs: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--ssh-auth-socket=/tmp/agent.sock") {
+		t.Errorf("args missing SSH socket: %v", args)
+	}
+}
+
+func TestDockerBuildArgsSecrets(t *testing.T) {
+	cfg := Config{
+		Dockerfile: "docker/Dockerfile",
+		BuildSecrets: []BuildSecret{
+			{Name: "npm_token", Env: "NPM_TOKEN"},
+			{Name: "netrc", File: "/home/dev/.netrc"},
+		},
+		ForwardSSH: true,
+	}
+
+	args, err := dockerBuildArgs(cfg, "/tmp/agent.sock")
+	if err != nil {
+		t.Fatalf("dockerBuildArgs: %v", err)
+	}
+
+	joined := strings.Join(args, " ")
+	for _, want := range []string{
+		"--file docker/Dockerfile",
+		"--secret id=npm_token,env=NPM_TOKEN",
+		"--secret id=netrc,src=/home/dev/.netrc",
+		"--ssh default=/tmp/agent.sock",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args missing %q: %s", want, joined)
+		}
+	}
+	if args[len(args)-1] != "docker" {
+		t.Errorf("last arg = %q, want build context", args[len(args)-1])
+	}
+}
+
+func TestValidatePlatform(t *testing.T) {
+	for _, platform := range []string{"", "linux/amd64", "linux/arm64"} {
+		if err := validatePlatform(platform); err != nil {
+			t.Errorf("valid platform %q rejected: %v", platform, err)
+		}
+	}
+	for _, platform := range []string{"amd64", "linux/", "/amd64", "linux/arm/v7/x"} {
+		if err := validatePlatform(platform); err == nil {
+			t.Errorf("malformed platform %q accepted", platform)
+		}
+	}
+}
+
+func TestBuildArgsPlatform(t *testing.T) {
+	args, err := buildArgs(Config{Dockerfile: "Dockerfile", Platform: "linux/arm64"}, "")
+	if err != nil {
+		t.Fatalf("buildArgs: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--platform=linux/arm64") {
+		t.Errorf("args missing platform: %v", args)
+	}
+}
+
+func TestDockerBuildArgsPlatform(t *testing.T) {
+	args, err := dockerBuildArgs(Config{Dockerfile: "Dockerfile", Platform: "linux/arm64"}, "")
+	if err != nil {
+		t.Fatalf("dockerBuildArgs: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--platform linux/arm64") {
+		t.Errorf("args missing platform: %v", args)
+	}
+}
+
+func TestDockerBuildArgsManagedLabel(t *testing.T) {
+	args, err := dockerBuildArgs(Config{Dockerfile: "Dockerfile"}, "")
+	if err != nil {
+		t.Fatalf("dockerBuildArgs: %v", err)
+	}
+	if !strings.Contains(strings.Join(args, " "), "--label "+ManagedLabel) {
+		t.Errorf("args missing managed label: %v", args)
+	}
+}
+
+func TestParseImageList(t *testing.T) {
+	images := parseImageList("patina/demo:latest\t1.2GB\npatina/other:v2\t640MB\n")
+	if len(images) != 2 {
+		t.Fatalf("len(images) = %d, want 2", len(images))
+	}
+	if images[0].Reference != "patina/demo:latest" || images[0].Size != "1.2GB" {
+		t.Errorf("images[0] = %+v", images[0])
+	}
+
+	if got := parseImageList(""); len(got) != 0 {
+		t.Errorf("empty output parsed to %v", got)
+	}
+}
+
+func TestParseCacheVolumes(t *testing.T) {
+	sizes := `[{"Name":"patina-cache-cargo","Size":"2.1GB"},{"Name":"unrelated","Size":"5GB"}]`
+	volumes, err := parseCacheVolumes("patina-cache-cargo\npatina-cache-npm\n", sizes)
+	if err != nil {
+		t.Fatalf("parseCacheVolumes: %v", err)
+	}
+	if len(volumes) != 2 {
+		t.Fatalf("len(volumes) = %d, want 2", len(volumes))
+	}
+	if volumes[0].Size != "2.1GB" {
+		t.Errorf("cargo size = %q, want 2.1GB", volumes[0].Size)
+	}
+	// Labeled volume missing from the disk-usage report.
+	if volumes[1].Size != "unknown" {
+		t.Errorf("npm size = %q, want unknown", volumes[1].Size)
+	}
+}
+
+func TestBackendNames(t *testing.T) {
+	if got := NewDaggerBackend().Name(); got != "dagger" {
+		t.Errorf("dagger backend name = %q", got)
+	}
+	if got := NewDockerBackend().Name(); got != "docker" {
+		t.Errorf("docker backend name = %q", got)
+	}
+}
+
+func TestRegistryAuthValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		auth RegistryAuth
+		ok   bool
+	}{
+		{"env source", RegistryAuth{Registry: "ghcr.io", Username: "dev", PasswordEnv: "GHCR_TOKEN"}, true},
+		{"file source", RegistryAuth{Registry: "harbor.internal", PasswordFile: "/run/secret"}, true},
+		{"missing registry", RegistryAuth{PasswordEnv: "TOKEN"}, false},
+		{"no source", RegistryAuth{Registry: "ghcr.io"}, false},
+		{"both sources", RegistryAuth{Registry: "ghcr.io", PasswordEnv: "T", PasswordFile: "/f"}, false},
+	}
+	for _, tc := range cases {
+		err := tc.auth.validate()
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestRegistryAuthPassword(t *testing.T) {
+	t.Setenv("PATINA_TEST_TOKEN", "hunter2")
+	auth := RegistryAuth{Registry: "ghcr.io", PasswordEnv: "PATINA_TEST_TOKEN"}
+	password, err := auth.password()
+	if err != nil {
+		t.Fatalf("password: %v", err)
+	}
+	if password != "hunter2" {
+		t.Errorf("password = %q, want hunter2", password)
+	}
+
+	auth.PasswordEnv = "PATINA_TEST_UNSET"
+	if _, err := auth.password(); err == nil {
+		t.Error("expected error for unset env var")
+	}
+}
+
+func TestAuthUnsupportedBackend(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	_, err := p.Create(context.Background(), Config{
+		Name:         "demo",
+		RegistryAuth: []RegistryAuth{{Registry: "ghcr.io", PasswordEnv: "TOKEN"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot authenticate") {
+		t.Errorf("err = %v, want clear auth-unsupported error", err)
+	}
+}
+
+func TestUserSpec(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, ""},
+		{Config{User: "dev"}, "dev"},
+		{Config{User: "dev", UID: 1000, GID: 1000}, "1000:1000"},
+		// GID defaults to the UID when unset.
+		{Config{UID: 501}, "501:501"},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.UserSpec(); got != tc.want {
+			t.Errorf("UserSpec(%+v) = %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
+
+func TestEnsureUserCommand(t *testing.T) {
+	if got := (Config{}).EnsureUserCommand(); got != "" {
+		t.Errorf("no user should mean no command, got %q", got)
+	}
+
+	cmd := (Config{User: "dev", UID: 501}).EnsureUserCommand()
+	for _, want := range []string{"id -u dev", "useradd -m -u 501 dev", "adduser -D -u 501 dev"} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("command missing %q: %s", want, cmd)
+		}
+	}
+}
+
+func TestExportValidation(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	ctx := context.Background()
+
+	if err := p.Export(ctx, nil, "ghcr.io/org/env:v1"); err == nil {
+		t.Error("expected error exporting nil environment")
+	}
+	if err := p.Export(ctx, &Environment{Image: "ubuntu:24.04"}, ""); err == nil {
+		t.Error("expected error exporting without a reference")
+	}
+}
+
+func TestExportUnsupportedBackend(t *testing.T) {
+	p := NewWithBackend(NewKubeBackend(""))
+	err := p.Export(context.Background(), &Environment{Image: "ubuntu:24.04"}, "ghcr.io/org/env:v1")
+	if err == nil || !strings.Contains(err.Error(), "cannot export") {
+		t.Errorf("err = %v, want clear export-unsupported error", err)
+	}
+}
+
+func TestBuildSecretValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		secret BuildSecret
+		ok     bool
+	}{
+		{"env source", BuildSecret{Name: "s", Env: "VAR"}, true},
+		{"file source", BuildSecret{Name: "s", File: "/f"}, true},
+		{"missing name", BuildSecret{Env: "VAR"}, false},
+		{"no source", BuildSecret{Name: "s"}, false},
+		{"both sources", BuildSecret{Name: "s", Env: "VAR", File: "/f"}, false},
+	}
+	for _, tc := range cases {
+		_, err := tc.secret.uri()
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestRebuildKeepsIdentity(t *testing.T) {
+	p := NewWithBackend(&stubBackend{})
+
+	env, err := p.Create(context.Background(), Config{Name: "demo", Image: "golang:1.21"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	rebuilt, err := p.Rebuild(context.Background(), env, Config{Name: "demo", Image: "golang:1.22"})
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if rebuilt.ID != env.ID {
+		t.Errorf("rebuilt ID = %s, want %s", rebuilt.ID, env.ID)
+	}
+	if !rebuilt.CreatedAt.Equal(env.CreatedAt) {
+		t.Errorf("rebuilt CreatedAt = %v, want %v", rebuilt.CreatedAt, env.CreatedAt)
+	}
+	if rebuilt.Image != "golang:1.22" {
+		t.Errorf("rebuilt image = %s, want golang:1.22", rebuilt.Image)
+	}
+
+	if _, err := p.Rebuild(context.Background(), nil, Config{Name: "demo"}); err == nil {
+		t.Error("expected error for nil environment")
+	}
+}
+
+func TestValidateMounts(t *testing.T) {
+	valid := []Mount{
+		{Source: "/data", Target: "/data", Mode: MountModeRO},
+		{Source: "/seed", Target: "/seed", Mode: MountModeCopy},
+		{Source: "/scratch", Target: "/scratch"},
+	}
+	if err := validateMounts(valid); err != nil {
+		t.Errorf("validateMounts(valid) = %v", err)
+	}
+
+	for _, bad := range [][]Mount{
+		{{Target: "/data"}},
+		{{Source: "/data"}},
+		{{Source: "/data", Target: "data"}},
+		{{Source: "/data", Target: "/data", Mode: "bind"}},
+	} {
+		if err := validateMounts(bad); err == nil {
+			t.Errorf("validateMounts(%+v) accepted invalid mount", bad)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// caMountTarget is where a configured CA bundle's directory is mounted
+// inside environments.
+const caMountTarget = "/etc/patina/ca"
+
+// Proxy routes workspace traffic through a corporate proxy and trusts
+// its TLS-intercepting CA. Workspaces behind such proxies cannot
+// download anything without both pieces: the proxy URLs to reach out
+// through, and the CA so intercepted TLS still verifies.
+type Proxy struct {
+	// HTTP and HTTPS are proxy URLs injected as HTTP_PROXY and
+	// HTTPS_PROXY (plus the lowercase twins many tools read instead).
+	HTTP  string `json:"http,omitempty"`
+	HTTPS string `json:"https,omitempty"`
+	// NoProxy lists hosts reached directly, injected as NO_PROXY.
+	NoProxy string `json:"no_proxy,omitempty"`
+	// CABundle is a host PEM file installed into the container trust
+	// store. Its directory is mounted read-only at caMountTarget.
+	CABundle string `json:"ca_bundle,omitempty"`
+}
+
+// empty reports whether no proxy settings are configured.
+func (p Proxy) empty() bool {
+	return p == Proxy{}
+}
+
+// ApplyProxy expands the config's proxy settings: proxy env vars, a
+// read-only mount of the CA bundle's directory and a setup command
+// installing the bundle into the trust store. Explicit Env entries win
+// over injected ones, and like the other expanders it is idempotent so
+// the gateway and Create can both run it.
+func ApplyProxy(cfg Config) Config {
+	if cfg.Proxy.empty() {
+		return cfg
+	}
+
+	env := make(map[string]string, len(cfg.Env)+8)
+	for k, v := range cfg.Env {
+		env[k] = v
+	}
+	inject := func(name, value string) {
+		if value == "" {
+			return
+		}
+		for _, key := range []string{name, strings.ToLower(name)} {
+			if _, ok := env[key]; !ok {
+				env[key] = value
+			}
+		}
+	}
+	inject("HTTP_PROXY", cfg.Proxy.HTTP)
+	inject("HTTPS_PROXY", cfg.Proxy.HTTPS)
+	inject("NO_PROXY", cfg.Proxy.NoProxy)
+
+	if cfg.Proxy.CABundle != "" {
+		caPath := caMountTarget + "/" + filepath.Base(cfg.Proxy.CABundle)
+		// SSL_CERT_FILE covers openssl-based tools (curl, git, go) even
+		// before setup runs the trust-store install.
+		inject("SSL_CERT_FILE", caPath)
+
+		mounted := false
+		for _, m := range cfg.Mounts {
+			if m.Target == caMountTarget {
+				mounted = true
+			}
+		}
+		if !mounted {
+			cfg.Mounts = append(cfg.Mounts, Mount{
+				Source: filepath.Dir(cfg.Proxy.CABundle),
+				Target: caMountTarget,
+				Mode:   MountModeRO,
+			})
+		}
+
+		install := caInstallCommand(caPath)
+		present := false
+		for _, cmd := range cfg.Setup {
+			if cmd == install {
+				present = true
+			}
+		}
+		if !present {
+			// Prepended so package installs behind the proxy already
+			// trust the intercepting CA.
+			cfg.Setup = append([]string{install}, cfg.Setup...)
+		}
+	}
+
+	cfg.Env = env
+	return cfg
+}
+
+// caInstallCommand installs the mounted CA bundle into the image's
+// trust store, covering update-ca-certificates (debian/ubuntu/alpine)
+// and update-ca-trust (fedora/rhel) images.
+func caInstallCommand(path string) string {
+	return fmt.Sprintf(
+		"{ cp %[1]s /usr/local/share/ca-certificates/patina-ca.crt 2>/dev/null && update-ca-certificates; } || { cp %[1]s /etc/pki/ca-trust/source/anchors/patina-ca.pem && update-ca-trust; }",
+		path)
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyProxyEnv(t *testing.T) {
+	cfg := ApplyProxy(Config{
+		Proxy: Proxy{
+			HTTP:    "http://proxy.corp:3128",
+			HTTPS:   "http://proxy.corp:3128",
+			NoProxy: "localhost,.corp",
+		},
+		Env: map[string]string{"HTTPS_PROXY": "http://other:8080"},
+	})
+
+	want := map[string]string{
+		"HTTP_PROXY":  "http://proxy.corp:3128",
+		"http_proxy":  "http://proxy.corp:3128",
+		"HTTPS_PROXY": "http://other:8080", // explicit Env wins
+		"https_proxy": "http://proxy.corp:3128",
+		"NO_PROXY":    "localhost,.corp",
+		"no_proxy":    "localhost,.corp",
+	}
+	if !reflect.DeepEqual(cfg.Env, want) {
+		t.Errorf("Env = %v, want %v", cfg.Env, want)
+	}
+}
+
+func TestApplyProxyCABundle(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "corp-ca.pem")
+	if err := os.WriteFile(bundle, []byte("cert"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := ApplyProxy(Config{
+		Proxy: Proxy{CABundle: bundle},
+		Setup: []string{"make deps"},
+	})
+
+	if len(cfg.Mounts) != 1 || cfg.Mounts[0].Source != dir || cfg.Mounts[0].Mode != MountModeRO {
+		t.Errorf("Mounts = %v, want one ro-mount of %s", cfg.Mounts, dir)
+	}
+	if cfg.Mounts[0].Target != caMountTarget {
+		t.Errorf("mount target = %q, want %q", cfg.Mounts[0].Target, caMountTarget)
+	}
+	caPath := caMountTarget + "/corp-ca.pem"
+	if cfg.Env["SSL_CERT_FILE"] != caPath {
+		t.Errorf("SSL_CERT_FILE = %q, want %q", cfg.Env["SSL_CERT_FILE"], caPath)
+	}
+	if len(cfg.Setup) != 2 || cfg.Setup[0] != caInstallCommand(caPath) || cfg.Setup[1] != "make deps" {
+		t.Errorf("Setup = %v, want install command before existing setup", cfg.Setup)
+	}
+
+	// Applying again must not duplicate the mount or the setup command.
+	again := ApplyProxy(cfg)
+	if len(again.Mounts) != 1 || len(again.Setup) != 2 {
+		t.Errorf("second apply added artifacts: mounts %v, setup %v", again.Mounts, again.Setup)
+	}
+}
+
+func TestValidateProxyCABundle(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	errs := p.Validate(context.Background(), Config{
+		Name:  "demo",
+		Proxy: Proxy{CABundle: "/does/not/exist.pem"},
+	})
+	found := false
+	for _, e := range errs {
+		if e.Field == "proxy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("errs = %v, want a proxy error for a missing CA bundle", errs)
+	}
+}
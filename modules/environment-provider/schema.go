@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// configSchema is the published JSON Schema for Config, so external
+// tools (templates, .patina.yaml, gateway clients) can validate
+// environment configs before submission. Kept by hand next to the
+// struct; TestConfigSchemaMatchesStruct pins the two together.
+const configSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://patina.dev/schema/environment-config.json",
+  "title": "Environment configuration",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "description": "Environment name (usually the workspace name)."},
+    "image": {"type": "string", "description": "Base container image."},
+    "platform": {"type": "string", "description": "Image architecture, e.g. linux/amd64."},
+    "engine": {"type": "string", "description": "Dagger engine endpoint the environment runs on; normally scheduler-assigned."},
+    "workdir": {"type": "string", "description": "Mount point for the workspace inside the container."},
+    "dockerfile": {"type": "string", "description": "Build the image from this Dockerfile instead of pulling."},
+    "build_context": {"type": "string", "description": "Host directory used as the build context."},
+    "nix_flake": {"type": "string", "description": "Flake reference whose dev shell provides the environment."},
+    "build_secrets": {"type": "array", "items": {"$ref": "#/definitions/build_secret"}},
+    "forward_ssh": {"type": "boolean", "description": "Forward the host SSH agent into the build."},
+    "resources": {"$ref": "#/definitions/resources"},
+    "caches": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Named cache volumes mapped to container mount paths."},
+    "mounts": {"type": "array", "items": {"$ref": "#/definitions/mount"}},
+    "services": {"type": "array", "items": {"$ref": "#/definitions/service"}},
+    "ports": {"type": "array", "items": {"type": "integer"}, "description": "Container ports exposed on the host."},
+    "registry_auth": {"type": "array", "items": {"$ref": "#/definitions/registry_auth"}},
+    "proxy": {"$ref": "#/definitions/proxy"},
+    "env": {"type": "object", "additionalProperties": {"type": "string"}},
+    "env_files": {"type": "array", "items": {"type": "string"}},
+    "setup": {"type": "array", "items": {"type": "string"}, "description": "Shell commands run once after the environment is ready."},
+    "packages": {"$ref": "#/definitions/packages"},
+    "health": {"$ref": "#/definitions/health_probe"},
+    "user": {"type": "string", "description": "Non-root user commands run as."},
+    "uid": {"type": "integer"},
+    "gid": {"type": "integer"}
+  },
+  "additionalProperties": false,
+  "definitions": {
+    "build_secret": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "env": {"type": "string"},
+        "file": {"type": "string"}
+      },
+      "required": ["name"],
+      "additionalProperties": false
+    },
+    "resources": {
+      "type": "object",
+      "properties": {
+        "cpus": {"type": "number"},
+        "memory_mb": {"type": "integer"},
+        "disk_mb": {"type": "integer"},
+        "gpus": {"type": "integer"}
+      },
+      "additionalProperties": false
+    },
+    "mount": {
+      "type": "object",
+      "properties": {
+        "source": {"type": "string"},
+        "target": {"type": "string"},
+        "mode": {"type": "string", "enum": ["ro-mount", "rw-mount", "copy"]}
+      },
+      "required": ["source", "target"],
+      "additionalProperties": false
+    },
+    "service": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "image": {"type": "string"},
+        "ports": {"type": "array", "items": {"type": "integer"}},
+        "env": {"type": "object", "additionalProperties": {"type": "string"}}
+      },
+      "required": ["name", "image", "ports"],
+      "additionalProperties": false
+    },
+    "registry_auth": {
+      "type": "object",
+      "properties": {
+        "registry": {"type": "string"},
+        "username": {"type": "string"},
+        "password_env": {"type": "string"},
+        "password_file": {"type": "string"}
+      },
+      "required": ["registry"],
+      "additionalProperties": false
+    },
+    "proxy": {
+      "type": "object",
+      "properties": {
+        "http": {"type": "string"},
+        "https": {"type": "string"},
+        "no_proxy": {"type": "string"},
+        "ca_bundle": {"type": "string"}
+      },
+      "additionalProperties": false
+    },
+    "packages": {
+      "type": "object",
+      "properties": {
+        "apt": {"type": "array", "items": {"type": "string"}},
+        "apk": {"type": "array", "items": {"type": "string"}},
+        "pip": {"type": "array", "items": {"type": "string"}},
+        "npm": {"type": "array", "items": {"type": "string"}}
+      },
+      "additionalProperties": false
+    },
+    "health_probe": {
+      "type": "object",
+      "properties": {
+        "command": {"type": "array", "items": {"type": "string"}},
+        "interval_seconds": {"type": "integer"},
+        "retries": {"type": "integer"}
+      },
+      "additionalProperties": false
+    }
+  }
+}`
+
+// ConfigSchema returns the JSON Schema describing Config.
+func ConfigSchema() json.RawMessage {
+	return json.RawMessage(configSchema)
+}
+
+// ParseConfig decodes a JSON config strictly: unknown fields and type
+// mismatches are errors rather than silently dropped, so a typoed
+// field name in a template or .patina.yaml fails loudly before the
+// environment is built.
+func ParseConfig(data []byte) (Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parse config: %w", err)
+	}
+	return cfg, nil
+}
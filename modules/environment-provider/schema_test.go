@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jsonTags returns the JSON field names a struct marshals to.
+func jsonTags(t *testing.T, v any) map[string]bool {
+	t.Helper()
+	tags := make(map[string]bool)
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			t.Fatalf("field %s has no json name", typ.Field(i).Name)
+		}
+		tags[name] = true
+	}
+	return tags
+}
+
+// TestConfigSchemaMatchesStruct pins the hand-written schema to the
+// Config struct: a field added to one without the other fails here.
+func TestConfigSchemaMatchesStruct(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(ConfigSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	tags := jsonTags(t, Config{})
+	for name := range tags {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("schema missing Config field %q", name)
+		}
+	}
+	for name := range schema.Properties {
+		if !tags[name] {
+			t.Errorf("schema property %q has no Config field", name)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{
+		"name": "demo",
+		"image": "golang:1.21",
+		"caches": {"gomod": "/go/pkg/mod"}
+	}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Name != "demo" || cfg.Caches["gomod"] != "/go/pkg/mod" {
+		t.Errorf("parsed config = %+v", cfg)
+	}
+}
+
+func TestParseConfigRejectsUnknownFields(t *testing.T) {
+	if _, err := ParseConfig([]byte(`{"name": "demo", "imge": "golang:1.21"}`)); err == nil {
+		t.Error("expected typoed field to be rejected")
+	}
+}
+
+func TestParseConfigRejectsTypeMismatch(t *testing.T) {
+	if _, err := ParseConfig([]byte(`{"name": "demo", "ports": "8080"}`)); err == nil {
+		t.Error("expected type mismatch to be rejected")
+	}
+}
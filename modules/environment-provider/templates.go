@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Template is a reusable environment preset: everything a workspace
+// needs to build a given stack, resolvable by name at create time.
+type Template struct {
+	// Extends names templates this one composes on top of, base first.
+	// A small set of building blocks (base "linux-dev", overlays
+	// "with-postgres", "gpu") beats a combinatorial catalog.
+	Extends []string `json:"extends,omitempty"`
+	// Image is the base container image.
+	Image string `json:"image"`
+	// Caches maps cache volume names to mount paths.
+	Caches map[string]string `json:"caches,omitempty"`
+	// Env sets environment variables in the workspace container.
+	Env map[string]string `json:"env,omitempty"`
+	// Setup are shell commands run once after the environment is ready.
+	Setup []string `json:"setup,omitempty"`
+}
+
+// builtinTemplates are the presets shipped with patina, one per common
+// toolchain. Cache mounts follow each toolchain's default locations.
+var builtinTemplates = map[string]Template{
+	"go-1.22": {
+		Image: "golang:1.22",
+		Caches: map[string]string{
+			"go-mod":   "/go/pkg/mod",
+			"go-build": "/root/.cache/go-build",
+		},
+	},
+	"rust-stable": {
+		Image: "rust:1",
+		Caches: map[string]string{
+			"cargo-registry": "/usr/local/cargo/registry",
+			"cargo-git":      "/usr/local/cargo/git",
+		},
+	},
+	"node-20": {
+		Image: "node:20",
+		Caches: map[string]string{
+			"npm": "/root/.npm",
+		},
+	},
+	"python-3.12": {
+		Image: "python:3.12",
+		Caches: map[string]string{
+			"pip": "/root/.cache/pip",
+		},
+		Env: map[string]string{
+			"PIP_DISABLE_PIP_VERSION_CHECK": "1",
+		},
+	},
+}
+
+// Templates resolves named environment presets: the built-in catalog
+// plus user-defined JSON templates loaded from a directory. User
+// templates shadow built-ins of the same name, so nothing is locked in.
+type Templates struct {
+	// Dir holds user templates as <name>.json files. Empty disables
+	// user templates.
+	Dir string
+}
+
+// NewTemplates creates a catalog with user templates from dir.
+func NewTemplates(dir string) *Templates {
+	return &Templates{Dir: dir}
+}
+
+// Resolve returns the named template with its inheritance chain
+// flattened: bases merge in declaration order, each overlaying the
+// previous, and the extending template overlays them all. The merge is
+// deterministic, so the same template files always yield the same
+// effective config.
+func (t *Templates) Resolve(name string) (*Template, error) {
+	return t.resolve(name, nil)
+}
+
+// resolve loads and flattens one template. seen carries the names on
+// the current chain so inheritance cycles fail instead of recursing
+// forever.
+func (t *Templates) resolve(name string, seen []string) (*Template, error) {
+	for _, prior := range seen {
+		if prior == name {
+			return nil, fmt.Errorf("template %s: inheritance cycle through %s", seen[0], name)
+		}
+	}
+
+	tpl, err := t.load(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(tpl.Extends) == 0 {
+		return tpl, nil
+	}
+
+	var merged Template
+	for _, base := range tpl.Extends {
+		resolved, err := t.resolve(base, append(seen, name))
+		if err != nil {
+			return nil, err
+		}
+		merged = overlayTemplate(merged, *resolved)
+	}
+	merged = overlayTemplate(merged, *tpl)
+	merged.Extends = nil
+	return &merged, nil
+}
+
+// overlayTemplate merges top onto base: top's image wins when set, maps
+// merge with top's entries winning, setup concatenates base-then-top so
+// base tooling installs before overlay tooling that depends on it.
+func overlayTemplate(base, top Template) Template {
+	out := base
+	if top.Image != "" {
+		out.Image = top.Image
+	}
+	out.Caches = mergeMaps(base.Caches, top.Caches)
+	out.Env = mergeMaps(base.Env, top.Env)
+	if len(top.Setup) > 0 {
+		out.Setup = append(append([]string(nil), base.Setup...), top.Setup...)
+	}
+	return out
+}
+
+// load reads the named template without resolving inheritance.
+func (t *Templates) load(name string) (*Template, error) {
+	// Template names come straight from create requests; refuse anything
+	// that would resolve to a file outside the templates directory.
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	if t.Dir != "" {
+		data, err := os.ReadFile(filepath.Join(t.Dir, name+".json"))
+		switch {
+		case err == nil:
+			var tpl Template
+			if err := json.Unmarshal(data, &tpl); err != nil {
+				return nil, fmt.Errorf("template %s: %w", name, err)
+			}
+			return &tpl, nil
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+	}
+
+	if tpl, ok := builtinTemplates[name]; ok {
+		return &tpl, nil
+	}
+	return nil, fmt.Errorf("unknown template %q (known: %s)", name, strings.Join(t.Names(), ", "))
+}
+
+// Names lists the resolvable template names, sorted.
+func (t *Templates) Names() []string {
+	seen := make(map[string]bool, len(builtinTemplates))
+	for name := range builtinTemplates {
+		seen[name] = true
+	}
+	if t.Dir != "" {
+		entries, err := os.ReadDir(t.Dir)
+		if err == nil {
+			for _, entry := range entries {
+				if name := strings.TrimSuffix(entry.Name(), ".json"); name != entry.Name() {
+					seen[name] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply fills cfg's unset fields from the template. Explicit config
+// always wins: the template only supplies defaults.
+func (tpl *Template) Apply(cfg Config) Config {
+	if cfg.Image == "" {
+		cfg.Image = tpl.Image
+	}
+	cfg.Caches = mergeMaps(tpl.Caches, cfg.Caches)
+	cfg.Env = mergeMaps(tpl.Env, cfg.Env)
+	// Template setup runs first; config setup appends to it.
+	if len(tpl.Setup) > 0 {
+		cfg.Setup = append(append([]string(nil), tpl.Setup...), cfg.Setup...)
+	}
+	return cfg
+}
+
+// mergeMaps overlays overrides onto base, copying so neither input is
+// mutated. A nil result stays nil.
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 {
+		return overrides
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveBuiltinTemplate(t *testing.T) {
+	tpl, err := NewTemplates("").Resolve("go-1.22")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if tpl.Image != "golang:1.22" {
+		t.Errorf("image = %q, want golang:1.22", tpl.Image)
+	}
+	if tpl.Caches["go-mod"] != "/go/pkg/mod" {
+		t.Errorf("go-mod cache = %q, want /go/pkg/mod", tpl.Caches["go-mod"])
+	}
+}
+
+func TestResolveUnknownTemplate(t *testing.T) {
+	if _, err := NewTemplates("").Resolve("cobol-74"); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestResolveUserTemplate(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{"image": "internal/builder:v3", "setup": ["make deps"]}`
+	if err := os.WriteFile(filepath.Join(dir, "builder.json"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// User templates shadow built-ins of the same name.
+	shadow := `{"image": "internal/go:1.22"}`
+	if err := os.WriteFile(filepath.Join(dir, "go-1.22.json"), []byte(shadow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates := NewTemplates(dir)
+
+	tpl, err := templates.Resolve("builder")
+	if err != nil {
+		t.Fatalf("resolve user template: %v", err)
+	}
+	if tpl.Image != "internal/builder:v3" || len(tpl.Setup) != 1 {
+		t.Errorf("template = %+v, want custom image and setup", tpl)
+	}
+
+	tpl, err = templates.Resolve("go-1.22")
+	if err != nil {
+		t.Fatalf("resolve shadowed template: %v", err)
+	}
+	if tpl.Image != "internal/go:1.22" {
+		t.Errorf("image = %q, want user template to shadow built-in", tpl.Image)
+	}
+}
+
+func TestResolveRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(dir, "secret.json")
+	if err := os.WriteFile(outside, []byte(`{"image": "stolen"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "templates")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	templates := NewTemplates(sub)
+	for _, name := range []string{"../secret", "a/b", `a\b`, ".."} {
+		if _, err := templates.Resolve(name); err == nil {
+			t.Errorf("Resolve(%q) succeeded, want invalid-name error", name)
+		}
+	}
+}
+
+func TestTemplatesNames(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "builder.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	names := NewTemplates(dir).Names()
+	want := map[string]bool{"builder": false, "go-1.22": false, "rust-stable": false}
+	for _, name := range names {
+		if _, ok := want[name]; ok {
+			want[name] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("names missing %s: %v", name, names)
+		}
+	}
+}
+
+func TestTemplateApply(t *testing.T) {
+	tpl := &Template{
+		Image:  "golang:1.22",
+		Caches: map[string]string{"go-mod": "/go/pkg/mod"},
+		Env:    map[string]string{"CGO_ENABLED": "0"},
+		Setup:  []string{"go mod download"},
+	}
+
+	cfg := tpl.Apply(Config{
+		Name:   "demo",
+		Caches: map[string]string{"go-mod": "/custom/mod"},
+		Setup:  []string{"make generate"},
+	})
+
+	if cfg.Image != "golang:1.22" {
+		t.Errorf("image = %q, want template default", cfg.Image)
+	}
+	// Explicit config wins over the template.
+	if cfg.Caches["go-mod"] != "/custom/mod" {
+		t.Errorf("go-mod cache = %q, want config override", cfg.Caches["go-mod"])
+	}
+	if cfg.Env["CGO_ENABLED"] != "0" {
+		t.Errorf("env = %v, want template env merged", cfg.Env)
+	}
+	// Template setup runs before config setup.
+	if len(cfg.Setup) != 2 || cfg.Setup[0] != "go mod download" || cfg.Setup[1] != "make generate" {
+		t.Errorf("setup = %v, want template setup first", cfg.Setup)
+	}
+
+	// An explicit image is never overridden.
+	cfg = tpl.Apply(Config{Image: "golang:1.23"})
+	if cfg.Image != "golang:1.23" {
+		t.Errorf("image = %q, want explicit image kept", cfg.Image)
+	}
+}
+
+func TestTemplateInheritance(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("linux-dev", `{"image": "ubuntu:24.04", "env": {"TERM": "xterm"}, "setup": ["apt-get update"]}`)
+	write("with-postgres", `{"extends": ["linux-dev"], "env": {"PGHOST": "localhost"}, "setup": ["apt-get install -y postgresql-client"]}`)
+	write("gpu", `{"extends": ["with-postgres"], "image": "nvidia/cuda:12.4.0-devel-ubuntu24.04", "env": {"TERM": "screen"}}`)
+
+	tpl, err := NewTemplates(dir).Resolve("gpu")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	// The deepest overlay wins fields it sets; everything else flows up.
+	if tpl.Image != "nvidia/cuda:12.4.0-devel-ubuntu24.04" {
+		t.Errorf("image = %q, want overlay image", tpl.Image)
+	}
+	if tpl.Env["TERM"] != "screen" || tpl.Env["PGHOST"] != "localhost" {
+		t.Errorf("env = %v, want merged with overlay winning", tpl.Env)
+	}
+	// Base setup runs before overlay setup.
+	if len(tpl.Setup) != 2 || tpl.Setup[0] != "apt-get update" {
+		t.Errorf("setup = %v, want base commands first", tpl.Setup)
+	}
+	if len(tpl.Extends) != 0 {
+		t.Errorf("resolved template still declares extends: %v", tpl.Extends)
+	}
+}
+
+func TestTemplateInheritanceMultipleBases(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("base-a", `{"image": "a:1", "env": {"A": "a", "SHARED": "a"}}`)
+	write("base-b", `{"env": {"B": "b", "SHARED": "b"}}`)
+	write("combined", `{"extends": ["base-a", "base-b"]}`)
+
+	tpl, err := NewTemplates(dir).Resolve("combined")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	// Later bases overlay earlier ones, deterministically.
+	if tpl.Image != "a:1" || tpl.Env["SHARED"] != "b" || tpl.Env["A"] != "a" {
+		t.Errorf("template = %+v, want base-b overlaying base-a", tpl)
+	}
+}
+
+func TestTemplateInheritanceCycle(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"ping": `{"extends": ["pong"]}`,
+		"pong": `{"extends": ["ping"]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := NewTemplates(dir).Resolve("ping"); err == nil {
+		t.Error("expected error for inheritance cycle")
+	}
+}
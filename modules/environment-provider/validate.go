@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ValidationError is one problem Validate found with a config, tied to
+// the field that caused it so clients can point at the right input.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks the config without creating a container: static rules
+// (names, paths, platform), backend capabilities, and host-side inputs
+// (secret sources, build files). It returns every problem found rather
+// than stopping at the first, so users fix a config in one round trip.
+func (p *Provider) Validate(ctx context.Context, cfg Config) []ValidationError {
+	var errs []ValidationError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.Name == "" {
+		add("name", "environment name is required")
+	}
+	image := cfg.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	if strings.ContainsAny(image, " \t") {
+		add("image", "image reference %q contains whitespace", image)
+	} else if err := p.policy.Check(image); err != nil {
+		add("image", "%v", err)
+	}
+	if err := validatePlatform(cfg.Platform); err != nil {
+		add("platform", "%v", err)
+	}
+	if err := cfg.Resources.validate(); err != nil {
+		add("resources", "%v", err)
+	}
+	if cfg.Resources.GPUs > 0 {
+		if gb, ok := p.backend.(GPUBackend); !ok {
+			add("resources", "backend %s cannot attach GPUs", p.backend.Name())
+		} else if err := gb.SupportsGPU(ctx); err != nil {
+			add("resources", "GPUs unavailable: %v", err)
+		}
+	}
+	if cfg.UID < 0 || cfg.GID < 0 {
+		add("uid", "uid and gid must not be negative")
+	}
+	if err := validateCaches(cfg.Caches); err != nil {
+		add("caches", "%v", err)
+	}
+	if err := validateServices(cfg.Services); err != nil {
+		add("services", "%v", err)
+	}
+	if err := validateMounts(cfg.Mounts); err != nil {
+		add("mounts", "%v", err)
+	}
+	if _, ok := p.backend.(LifecycleBackend); ok {
+		for _, m := range cfg.Mounts {
+			if m.mode() == MountModeCopy {
+				add("mounts", "backend %s cannot copy %s into the pod; use ro-mount or rw-mount", p.backend.Name(), m.Source)
+			}
+		}
+	}
+	if err := validatePorts(cfg.Ports); err != nil {
+		add("ports", "%v", err)
+	}
+	if len(cfg.Ports) > 0 {
+		if _, ok := p.backend.(PortBackend); !ok {
+			add("ports", "backend %s cannot expose ports on the host", p.backend.Name())
+		}
+	}
+
+	for name := range cfg.Env {
+		if !validEnvName(name) {
+			add("env", "env var name %q is not legal", name)
+		}
+	}
+	for _, path := range cfg.EnvFiles {
+		if _, err := ParseEnvFile(path); err != nil {
+			add("env_files", "%v", err)
+		}
+	}
+	for _, svc := range cfg.Services {
+		for name := range svc.Env {
+			if !validEnvName(name) {
+				add("services", "service %s: env var name %q is not legal", svc.Name, name)
+			}
+		}
+	}
+
+	// Host-side inputs: the create would fail later and asynchronously;
+	// the dry run catches missing files and unset vars now.
+	for _, auth := range cfg.RegistryAuth {
+		if _, err := auth.password(); err != nil {
+			add("registry_auth", "%v", err)
+		}
+	}
+	for _, secret := range cfg.BuildSecrets {
+		if _, err := secret.uri(); err != nil {
+			add("build_secrets", "%v", err)
+			continue
+		}
+		switch {
+		case secret.Env != "" && os.Getenv(secret.Env) == "":
+			add("build_secrets", "build secret %s: %s is not set", secret.Name, secret.Env)
+		case secret.File != "":
+			if _, err := os.Stat(secret.File); err != nil {
+				add("build_secrets", "build secret %s: %v", secret.Name, err)
+			}
+		}
+	}
+	if cfg.Dockerfile != "" {
+		if _, err := os.Stat(cfg.Dockerfile); err != nil {
+			add("dockerfile", "%v", err)
+		}
+	}
+	if cfg.Proxy.CABundle != "" {
+		if _, err := os.Stat(cfg.Proxy.CABundle); err != nil {
+			add("proxy", "ca bundle: %v", err)
+		}
+	}
+	if cfg.NixFlake != "" && cfg.Dockerfile != "" {
+		add("nix_flake", "nix flake and Dockerfile are mutually exclusive")
+	}
+	if err := cfg.Packages.validate(); err != nil {
+		add("packages", "%v", err)
+	}
+	if err := cfg.Health.validate(); err != nil {
+		add("health", "%v", err)
+	}
+
+	return errs
+}
+
+// validEnvName reports whether the name is a legal POSIX env var name:
+// letters, digits and underscores, not starting with a digit.
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
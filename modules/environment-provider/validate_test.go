@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateCleanConfig(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	errs := p.Validate(context.Background(), Config{
+		Name:   "demo",
+		Image:  "golang:1.22",
+		Caches: map[string]string{"go-mod": "/go/pkg/mod"},
+		Env:    map[string]string{"CGO_ENABLED": "0"},
+	})
+	if len(errs) != 0 {
+		t.Errorf("clean config produced errors: %v", errs)
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	p := NewWithBackend(NewDaggerBackend())
+	errs := p.Validate(context.Background(), Config{
+		Platform:     "amd64",
+		Resources:    Resources{GPUs: 1},
+		Caches:       map[string]string{"npm": "relative"},
+		Env:          map[string]string{"9BAD": "x"},
+		BuildSecrets: []BuildSecret{{Name: "s", File: "/no/such/file"}},
+	})
+
+	fields := make(map[string]bool)
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"name", "platform", "resources", "caches", "env", "build_secrets"} {
+		if !fields[want] {
+			t.Errorf("missing validation error for %s: %v", want, errs)
+		}
+	}
+}
+
+func TestValidEnvName(t *testing.T) {
+	for _, name := range []string{"PATH", "CGO_ENABLED", "_x", "a1"} {
+		if !validEnvName(name) {
+			t.Errorf("valid name %q rejected", name)
+		}
+	}
+	for _, name := range []string{"", "9VAR", "MY-VAR", "A B"} {
+		if validEnvName(name) {
+			t.Errorf("illegal name %q accepted", name)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// eventLimit caps each workspace's history. Old entries fall off the
+// front; a workspace that churns status for a week should not pin its
+// whole past in memory.
+const eventLimit = 500
+
+// Event is one entry in a workspace's audit trail: what changed and
+// when. The registry appends these on every mutation, so "why is this
+// workspace paused" is answerable without digging through gateway logs.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Kind names the mutation: "registered", "status", "updated",
+	// "locked", "unlocked", "imported".
+	Kind string `json:"kind"`
+	// Detail carries the specifics: the new status, the lock holder.
+	Detail string `json:"detail,omitempty"`
+}
+
+// recordEventLocked appends to the workspace's history. Callers hold
+// r.mu. Events live and die with the registry record; they are not
+// persisted through the store.
+func (r *Registry) recordEventLocked(id, kind, detail string) {
+	events := append(r.events[id], Event{
+		Time:   time.Now().UTC(),
+		Kind:   kind,
+		Detail: detail,
+	})
+	if len(events) > eventLimit {
+		events = events[len(events)-eventLimit:]
+	}
+	r.events[id] = events
+}
+
+// History returns the workspace's audit trail, oldest first.
+func (r *Registry) History(id string) ([]Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.workspaces[id]; !exists {
+		return nil, fmt.Errorf("workspace %s: %w", id, errdefs.ErrNotFound)
+	}
+	return append([]Event(nil), r.events[id]...), nil
+}
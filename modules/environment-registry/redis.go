@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRedisKey is where the registry snapshot lives unless the
+// caller picks another key.
+const DefaultRedisKey = "patina:registry"
+
+// redisTimeout bounds every dial and round trip. Snapshots are small;
+// a store that takes longer than this is down, not slow.
+const redisTimeout = 5 * time.Second
+
+// RedisStore persists the registry snapshot in a single Redis key, so
+// multiple gateway replicas behind a load balancer share one view of
+// the fleet. It speaks RESP directly over the stdlib: the store needs
+// exactly three commands (GET, SET, SUBSCRIBE), not a client library.
+type RedisStore struct {
+	// Addr is the Redis host:port.
+	Addr string
+	// Key is the snapshot's key; empty means DefaultRedisKey.
+	Key string
+}
+
+// NewRedisStore creates a store against the given Redis address.
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{Addr: addr}
+}
+
+func (s *RedisStore) key() string {
+	if s.Key == "" {
+		return DefaultRedisKey
+	}
+	return s.Key
+}
+
+// Load implements Store.
+func (s *RedisStore) Load() ([]*Workspace, error) {
+	reply, err := s.do("GET", s.key())
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, nil
+	}
+	var workspaces []*Workspace
+	if err := json.Unmarshal([]byte(reply.(string)), &workspaces); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.key(), err)
+	}
+	return workspaces, nil
+}
+
+// Save implements Store.
+func (s *RedisStore) Save(workspaces []*Workspace) error {
+	data, err := json.Marshal(workspaces)
+	if err != nil {
+		return err
+	}
+	reply, err := s.do("SET", s.key(), string(data))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("redis SET %s: unexpected reply %v", s.key(), reply)
+	}
+	return nil
+}
+
+// Watch emits a tick whenever another instance rewrites the snapshot,
+// via keyspace notifications on the store's key. Replicas reload on
+// ticks to stay consistent without polling. The server must have
+// notify-keyspace-events enabled for generic key events (e.g. "Kg$").
+//
+// The channel closes when stop closes or the connection drops; callers
+// that need to survive a Redis restart re-Watch.
+func (s *RedisStore) Watch(stop <-chan struct{}) (<-chan struct{}, error) {
+	conn, err := net.DialTimeout("tcp", s.Addr, redisTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis watch: %w", err)
+	}
+	channel := "__keyspace@0__:" + s.key()
+	if err := writeCommand(conn, "SUBSCRIBE", channel); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("redis watch: %w", err)
+	}
+
+	ticks := make(chan struct{}, 1)
+	go func() {
+		<-stop
+		conn.Close()
+	}()
+	go func() {
+		defer close(ticks)
+		br := bufio.NewReader(conn)
+		for {
+			reply, err := readReply(br)
+			if err != nil {
+				return
+			}
+			msg, ok := reply.([]interface{})
+			if !ok || len(msg) < 1 || msg[0] != "message" {
+				continue
+			}
+			select {
+			case ticks <- struct{}{}:
+			default: // a tick is already pending; one reload covers both
+			}
+		}
+	}()
+	return ticks, nil
+}
+
+// do runs one command on a fresh connection. Mutations are rare next
+// to the cost of keeping a pooled client correct, so each round trip
+// pays for its own dial.
+func (s *RedisStore) do(args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", s.Addr, redisTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(redisTimeout)) //nolint:errcheck
+
+	if err := writeCommand(conn, args...); err != nil {
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	reply, err := readReply(bufio.NewReader(conn))
+	if err != nil {
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	return reply, nil
+}
+
+// writeCommand encodes one command as a RESP array of bulk strings.
+func writeCommand(conn net.Conn, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := conn.Write([]byte(b.String()))
+	return err
+}
+
+// readReply parses one RESP reply: strings come back as string, nil
+// bulks as nil, integers as int64 and arrays as []interface{}.
+func readReply(br *bufio.Reader) (interface{}, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+	switch payload := line[1:]; line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, fmt.Errorf("server error: %s", payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2) // payload plus trailing CRLF
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(br)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+)
+
+// fakeRedis serves just enough RESP (GET, SET, nil bulks) on a loopback
+// listener for the store to round-trip against, so the tests need no
+// Redis server.
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				for {
+					reply, err := readReply(br)
+					if err != nil {
+						return
+					}
+					args, ok := reply.([]interface{})
+					if !ok || len(args) == 0 {
+						return
+					}
+					mu.Lock()
+					switch args[0] {
+					case "GET":
+						val, ok := data[args[1].(string)]
+						if !ok {
+							fmt.Fprint(conn, "$-1\r\n")
+						} else {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(val), val)
+						}
+					case "SET":
+						data[args[1].(string)] = args[2].(string)
+						fmt.Fprint(conn, "+OK\r\n")
+					default:
+						fmt.Fprintf(conn, "-ERR unknown command %v\r\n", args[0])
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedisStoreRoundTrip(t *testing.T) {
+	addr := fakeRedis(t)
+
+	reg, err := NewWithStore(NewRedisStore(addr))
+	if err != nil {
+		t.Fatalf("open registry: %v", err)
+	}
+	ws := testWorkspace("ws-1")
+	ws.Labels = map[string]string{"team": "payments"}
+	if err := reg.Register(ws); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// A second replica against the same server sees the workspace.
+	replica, err := NewWithStore(NewRedisStore(addr))
+	if err != nil {
+		t.Fatalf("open replica: %v", err)
+	}
+	got, ok := replica.Get("ws-1")
+	if !ok {
+		t.Fatal("workspace missing from replica")
+	}
+	if got.Labels["team"] != "payments" {
+		t.Errorf("labels = %v, want team=payments", got.Labels)
+	}
+
+	// A write on one replica reaches the other via Reload.
+	if err := reg.UpdateStatus("ws-1", StatusReady); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if err := replica.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got, _ := replica.Get("ws-1"); got.Status != StatusReady {
+		t.Errorf("replica status = %q, want %q after reload", got.Status, StatusReady)
+	}
+}
+
+func TestRedisStoreEmptyKeyIsEmpty(t *testing.T) {
+	store := NewRedisStore(fakeRedis(t))
+	workspaces, err := store.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(workspaces) != 0 {
+		t.Errorf("got %d workspaces from an empty key, want 0", len(workspaces))
+	}
+}
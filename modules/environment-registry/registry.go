@@ -0,0 +1,522 @@
+// Package registry tracks workspace environments.
+//
+// The registry owns workspace state: modules register, query and
+// deregister environments here instead of sharing maps between them.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+// Workspace statuses, in rough lifecycle order.
+const (
+	StatusCreating  = "creating"
+	StatusReady     = "ready"
+	StatusUnhealthy = "unhealthy"
+	StatusPaused    = "paused"
+	StatusError     = "error"
+	StatusDeleting  = "deleting"
+)
+
+// Workspace is a tracked environment: a git worktree plus the container
+// configuration used to execute commands against it.
+type Workspace struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Repo     string `json:"repo"`
+	Branch   string `json:"branch"`
+	Worktree string `json:"worktree"`
+	Image    string `json:"image"`
+	Status   string `json:"status"`
+	// Engine is the Dagger engine endpoint the workspace was placed on;
+	// empty means the local engine.
+	Engine    string    `json:"engine,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Version increments on every registry mutation. Update checks it so
+	// concurrent read-modify-write cycles conflict instead of silently
+	// clobbering each other.
+	Version int64 `json:"version"`
+	// Checkpoint is the workspace's auto-commit policy, if any.
+	Checkpoint CheckpointPolicy `json:"checkpoint,omitempty"`
+	// Caches maps named cache volumes to container mount paths, carried
+	// here so execs mount the same caches the environment was created with.
+	Caches map[string]string `json:"caches,omitempty"`
+	// Mounts are the workspace's extra host-directory bindings, carried
+	// here so execs mount the same paths the environment was created with.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Services are the workspace's companion containers (e.g. Postgres),
+	// carried here so execs bind the same services the environment runs.
+	Services []Service `json:"services,omitempty"`
+	// Template is the environment preset the workspace was created from,
+	// if any.
+	Template string `json:"template,omitempty"`
+	// Session labels the development session the workspace belongs to;
+	// recorded as a Patina-Session trailer on workspace commits.
+	Session string `json:"session,omitempty"`
+	// Agent identifies the agent driving the workspace ("Name <email>");
+	// recorded as a Co-authored-by trailer on workspace commits.
+	Agent string `json:"agent,omitempty"`
+	// NixFlake is the flake reference the environment was built from;
+	// execs run inside its dev shell.
+	NixFlake string `json:"nix_flake,omitempty"`
+	// Env sets environment variables in the workspace container.
+	Env map[string]string `json:"env,omitempty"`
+	// EnvFiles are .env-style host files re-read at exec time. Only the
+	// paths are recorded, so secret-style values stay out of registry
+	// JSON.
+	EnvFiles []string `json:"env_files,omitempty"`
+	// Setup are the shell commands run once after environment creation.
+	Setup []string `json:"setup,omitempty"`
+	// User, UID and GID identify the non-root user commands run as.
+	User string `json:"user,omitempty"`
+	UID  int    `json:"uid,omitempty"`
+	GID  int    `json:"gid,omitempty"`
+	// Protected exempts the workspace from pressure-based eviction: it is
+	// never paused to reclaim capacity, no matter how idle it is.
+	Protected bool `json:"protected,omitempty"`
+	// LastActive records the most recent exec, job or attach against the
+	// workspace. Eviction pauses least-recently-active workspaces first.
+	LastActive time.Time `json:"last_active,omitempty"`
+	// Standby replicates the workspace (worktree plus exported image) to
+	// the gateway's standby destination, so a host failure doesn't lose
+	// long-lived workspaces.
+	Standby bool `json:"standby,omitempty"`
+	// BudgetSeconds caps the workspace's cumulative execution time per
+	// day; 0 means unbudgeted.
+	BudgetSeconds int `json:"budget_seconds,omitempty"`
+	// Health is the workspace's periodic health command, carried here so
+	// probing continues after creation.
+	Health HealthProbe `json:"health,omitempty"`
+	// Lock is the workspace's exclusive hold, if any. While live, only
+	// the holder may run commands against or delete the workspace.
+	Lock *Lock `json:"lock,omitempty"`
+	// Labels are caller-defined key/value metadata (team, ticket,
+	// experiment) used to filter listings; the registry gives them no
+	// meaning of its own.
+	Labels map[string]string `json:"labels,omitempty"`
+	// TTLSeconds bounds the workspace's lifetime from creation; past it
+	// the gateway's reaper tears the workspace down. 0 means no expiry.
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// Namespace scopes the workspace to a tenant: listings and gateway
+	// access are isolated per namespace. Names stay globally unique —
+	// worktree paths and container names derive from them — so the
+	// namespace bounds visibility, not naming.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Expired reports whether the workspace's TTL has lapsed at now.
+// Workspaces without a TTL never expire.
+func (w *Workspace) Expired(now time.Time) bool {
+	if w.TTLSeconds <= 0 {
+		return false
+	}
+	return now.After(w.CreatedAt.Add(time.Duration(w.TTLSeconds) * time.Second))
+}
+
+// DefaultLockTTL bounds a lock whose request doesn't set one. Locks
+// always expire: a crashed holder must not wedge its workspace forever.
+const DefaultLockTTL = 15 * time.Minute
+
+// Lock is an exclusive hold on a workspace, taken by an agent or client
+// performing a multi-step operation that must not interleave with other
+// callers.
+type Lock struct {
+	// Holder identifies who took the lock.
+	Holder string `json:"holder"`
+	// ExpiresAt is when the lock lapses on its own.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Live reports whether the lock is still in force. Expired locks are
+// treated as absent everywhere; nobody has to unlock a dead holder.
+func (l *Lock) Live() bool {
+	return l != nil && time.Now().Before(l.ExpiresAt)
+}
+
+// HealthProbe is a periodic command that checks the workspace from the
+// inside; exit 0 means healthy.
+type HealthProbe struct {
+	// Command is the probe argv. Empty disables probing.
+	Command []string `json:"command,omitempty"`
+	// IntervalSeconds between probes.
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	// Retries is how many consecutive failures mark the workspace
+	// unhealthy.
+	Retries int `json:"retries,omitempty"`
+}
+
+// Mount binds one host directory into the workspace container, either
+// live ("ro-mount", "rw-mount") or as an isolated copy ("copy").
+type Mount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Mode   string `json:"mode,omitempty"`
+}
+
+// Service describes a companion container attached to a workspace.
+type Service struct {
+	// Name is the hostname the service is reachable under.
+	Name string `json:"name"`
+	// Image is the service container image.
+	Image string `json:"image"`
+	// Ports are the TCP ports the service exposes.
+	Ports []int `json:"ports"`
+	// Env sets environment variables on the service container.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// CheckpointPolicy configures automatic commits of workspace changes so
+// agent-driven work survives crashes.
+type CheckpointPolicy struct {
+	// Enabled turns the policy on.
+	Enabled bool `json:"enabled,omitempty"`
+	// IntervalSeconds commits on a timer when > 0.
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	// AfterExec commits after each successful exec.
+	AfterExec bool `json:"after_exec,omitempty"`
+	// MessageTemplate renders commit messages; {workspace} and {time}
+	// are substituted. Empty uses the default template.
+	MessageTemplate string `json:"message_template,omitempty"`
+}
+
+// clone returns a copy so callers never share pointers with the registry.
+func (w *Workspace) clone() *Workspace {
+	copied := *w
+	if w.Caches != nil {
+		copied.Caches = make(map[string]string, len(w.Caches))
+		for k, v := range w.Caches {
+			copied.Caches[k] = v
+		}
+	}
+	if w.Env != nil {
+		copied.Env = make(map[string]string, len(w.Env))
+		for k, v := range w.Env {
+			copied.Env[k] = v
+		}
+	}
+	if w.Lock != nil {
+		lock := *w.Lock
+		copied.Lock = &lock
+	}
+	if w.Labels != nil {
+		copied.Labels = make(map[string]string, len(w.Labels))
+		for k, v := range w.Labels {
+			copied.Labels[k] = v
+		}
+	}
+	copied.Setup = append([]string(nil), w.Setup...)
+	copied.EnvFiles = append([]string(nil), w.EnvFiles...)
+	copied.Health.Command = append([]string(nil), w.Health.Command...)
+	copied.Mounts = append([]Mount(nil), w.Mounts...)
+	if w.Services != nil {
+		copied.Services = make([]Service, len(w.Services))
+		for i, svc := range w.Services {
+			copied.Services[i] = svc
+			copied.Services[i].Ports = append([]int(nil), svc.Ports...)
+			if svc.Env != nil {
+				copied.Services[i].Env = make(map[string]string, len(svc.Env))
+				for k, v := range svc.Env {
+					copied.Services[i].Env[k] = v
+				}
+			}
+		}
+	}
+	return &copied
+}
+
+// Registry is the single owner of workspace records. All reads return
+// defensive copies and all mutations go through registry methods, so
+// callers on different goroutines never race on a shared Workspace.
+type Registry struct {
+	mu         sync.RWMutex
+	workspaces map[string]*Workspace
+	// byName indexes workspace IDs by name, since humans and scripts
+	// mostly address workspaces by name rather than generated ID.
+	byName map[string]string
+	// events holds each workspace's audit trail; see History.
+	events map[string][]Event
+	// store, when set, persists every mutation; see NewWithStore.
+	store Store
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		workspaces: make(map[string]*Workspace),
+		byName:     make(map[string]string),
+		events:     make(map[string][]Event),
+	}
+}
+
+// Register adds a workspace. The ID and name must both be unique.
+func (r *Registry) Register(ws *Workspace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ws.ID == "" {
+		return fmt.Errorf("workspace ID is required")
+	}
+	if _, exists := r.workspaces[ws.ID]; exists {
+		return fmt.Errorf("workspace %s already registered: %w", ws.ID, errdefs.ErrConflict)
+	}
+	if id, exists := r.byName[ws.Name]; exists {
+		return fmt.Errorf("workspace name %s already used by %s: %w", ws.Name, id, errdefs.ErrConflict)
+	}
+
+	stored := ws.clone()
+	stored.Version = 1
+	r.workspaces[ws.ID] = stored
+	if ws.Name != "" {
+		r.byName[ws.Name] = ws.ID
+	}
+	r.recordEventLocked(ws.ID, "registered", ws.Status)
+	return r.persistLocked()
+}
+
+// Deregister removes a workspace by ID.
+func (r *Registry) Deregister(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws, exists := r.workspaces[id]
+	if !exists {
+		return fmt.Errorf("workspace %s: %w", id, errdefs.ErrNotFound)
+	}
+
+	delete(r.workspaces, id)
+	delete(r.byName, ws.Name)
+	delete(r.events, id)
+	return r.persistLocked()
+}
+
+// Get returns the workspace with the given ID.
+func (r *Registry) Get(id string) (*Workspace, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws, ok := r.workspaces[id]
+	if !ok {
+		return nil, false
+	}
+	return ws.clone(), true
+}
+
+// GetByName returns the workspace with the given name.
+func (r *Registry) GetByName(name string) (*Workspace, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byName[name]
+	if !ok {
+		return nil, false
+	}
+	return r.workspaces[id].clone(), true
+}
+
+// List sort keys. SortCreatedAt is the default.
+const (
+	SortCreatedAt = "created_at"
+	SortName      = "name"
+	SortStatus    = "status"
+)
+
+// Filter narrows, orders and pages a List call. The zero value matches
+// every workspace, sorted by creation time.
+type Filter struct {
+	// Labels must all be present with equal values on a match.
+	Labels map[string]string
+	// Status matches exactly when set.
+	Status string
+	// NamePrefix matches workspaces whose name starts with it.
+	NamePrefix string
+	// Namespace matches exactly when set, isolating one tenant's
+	// listings from another's.
+	Namespace string
+	// SortBy orders results: SortCreatedAt (the default when empty),
+	// SortName or SortStatus. Unknown keys fall back to creation time.
+	SortBy string
+	// Offset skips that many results after sorting; with Limit it pages
+	// large fleets.
+	Offset int
+	// Limit caps how many results come back; 0 means no cap.
+	Limit int
+}
+
+// matches reports whether the workspace satisfies every set criterion.
+func (f Filter) matches(ws *Workspace) bool {
+	if f.Status != "" && ws.Status != f.Status {
+		return false
+	}
+	if f.NamePrefix != "" && !strings.HasPrefix(ws.Name, f.NamePrefix) {
+		return false
+	}
+	if f.Namespace != "" && ws.Namespace != f.Namespace {
+		return false
+	}
+	for k, v := range f.Labels {
+		if ws.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the workspaces matching the filter, sorted and paged per
+// its SortBy, Offset and Limit. A zero filter returns everything,
+// ordered by creation time.
+func (r *Registry) List(filter Filter) []*Workspace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]*Workspace, 0, len(r.workspaces))
+	for _, ws := range r.workspaces {
+		if !filter.matches(ws) {
+			continue
+		}
+		out = append(out, ws.clone())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		// Ties fall through to creation time so paging over equal keys
+		// stays stable between calls.
+		switch filter.SortBy {
+		case SortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case SortStatus:
+			if a.Status != b.Status {
+				return a.Status < b.Status
+			}
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	if filter.Offset > 0 {
+		if filter.Offset >= len(out) {
+			return nil
+		}
+		out = out[filter.Offset:]
+	}
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out
+}
+
+// Update replaces the stored workspace's record, bumping UpdatedAt and
+// Version. The ID must already be registered, and the name cannot
+// change: names index the registry and callers hold them.
+//
+// expectedVersion is the Version the caller read before modifying; a
+// mismatch means another writer got there first and is a conflict.
+// Passing 0 skips the check for callers that mean "last write wins".
+func (r *Registry) Update(ws *Workspace, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.workspaces[ws.ID]
+	if !exists {
+		return fmt.Errorf("workspace %s: %w", ws.ID, errdefs.ErrNotFound)
+	}
+	if ws.Name != current.Name {
+		return fmt.Errorf("workspace %s: name cannot change: %w", ws.ID, errdefs.ErrConflict)
+	}
+	if expectedVersion != 0 && expectedVersion != current.Version {
+		return fmt.Errorf("workspace %s modified since version %d (now %d): %w",
+			ws.ID, expectedVersion, current.Version, errdefs.ErrConflict)
+	}
+
+	copied := ws.clone()
+	copied.UpdatedAt = time.Now().UTC()
+	copied.Version = current.Version + 1
+	r.workspaces[ws.ID] = copied
+	r.recordEventLocked(ws.ID, "updated", "")
+	return r.persistLocked()
+}
+
+// Lock takes or renews an exclusive hold on the workspace. A live lock
+// held by someone else is a conflict; the same holder re-locking
+// extends the expiry. A ttl <= 0 applies DefaultLockTTL.
+func (r *Registry) Lock(id, holder string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws, exists := r.workspaces[id]
+	if !exists {
+		return fmt.Errorf("workspace %s: %w", id, errdefs.ErrNotFound)
+	}
+	if holder == "" {
+		return fmt.Errorf("lock holder is required")
+	}
+	if ws.Lock.Live() && ws.Lock.Holder != holder {
+		return fmt.Errorf("workspace %s locked by %s until %s: %w",
+			id, ws.Lock.Holder, ws.Lock.ExpiresAt.Format(time.RFC3339), errdefs.ErrConflict)
+	}
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+	ws.Lock = &Lock{Holder: holder, ExpiresAt: time.Now().UTC().Add(ttl)}
+	ws.UpdatedAt = time.Now().UTC()
+	ws.Version++
+	r.recordEventLocked(id, "locked", holder)
+	return r.persistLocked()
+}
+
+// Unlock releases the workspace's lock. Only the holder may release a
+// live lock; releasing an expired or absent lock always succeeds, so
+// cleanup is idempotent.
+func (r *Registry) Unlock(id, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws, exists := r.workspaces[id]
+	if !exists {
+		return fmt.Errorf("workspace %s: %w", id, errdefs.ErrNotFound)
+	}
+	if ws.Lock.Live() && ws.Lock.Holder != holder {
+		return fmt.Errorf("workspace %s locked by %s: %w", id, ws.Lock.Holder, errdefs.ErrForbidden)
+	}
+	ws.Lock = nil
+	ws.UpdatedAt = time.Now().UTC()
+	ws.Version++
+	r.recordEventLocked(id, "unlocked", holder)
+	return r.persistLocked()
+}
+
+// Touch records activity against a workspace. Missing IDs are ignored:
+// activity on a workspace deleted mid-request is not an error worth
+// surfacing.
+func (r *Registry) Touch(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ws, exists := r.workspaces[id]; exists {
+		ws.LastActive = time.Now().UTC()
+		ws.Version++
+		r.persistLocked() //nolint:errcheck // activity tracking is best-effort
+	}
+}
+
+// UpdateStatus sets a workspace's status and bumps UpdatedAt.
+func (r *Registry) UpdateStatus(id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws, exists := r.workspaces[id]
+	if !exists {
+		return fmt.Errorf("workspace %s: %w", id, errdefs.ErrNotFound)
+	}
+
+	ws.Status = status
+	ws.UpdatedAt = time.Now().UTC()
+	ws.Version++
+	r.recordEventLocked(id, "status", status)
+	return r.persistLocked()
+}
@@ -0,0 +1,511 @@
+package registry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NicabarNimble/patina/modules/errdefs"
+)
+
+func testWorkspace(id string) *Workspace {
+	now := time.Now().UTC()
+	return &Workspace{
+		ID:        id,
+		Name:      "test-" + id,
+		Status:    StatusCreating,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func TestRegisterAndGet(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ws, ok := r.Get("ws-1")
+	if !ok {
+		t.Fatal("expected workspace ws-1")
+	}
+	if ws.Name != "test-ws-1" {
+		t.Errorf("name = %q, want test-ws-1", ws.Name)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	err := r.Register(testWorkspace("ws-1"))
+	if err == nil {
+		t.Fatal("expected error registering duplicate ID")
+	}
+	if !errdefs.IsConflict(err) {
+		t.Errorf("err = %v, want errdefs.ErrConflict", err)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ws, ok := r.GetByName("test-ws-1")
+	if !ok {
+		t.Fatal("expected workspace by name test-ws-1")
+	}
+	if ws.ID != "ws-1" {
+		t.Errorf("ID = %q, want ws-1", ws.ID)
+	}
+
+	if _, ok := r.GetByName("missing"); ok {
+		t.Error("lookup of unknown name succeeded")
+	}
+
+	if err := r.Deregister("ws-1"); err != nil {
+		t.Fatalf("deregister: %v", err)
+	}
+	if _, ok := r.GetByName("test-ws-1"); ok {
+		t.Error("name still resolvable after deregister")
+	}
+}
+
+func TestRegisterDuplicateName(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	dup := testWorkspace("ws-2")
+	dup.Name = "test-ws-1"
+	if err := r.Register(dup); err == nil {
+		t.Error("expected error registering duplicate name")
+	}
+}
+
+func TestRegisterEmptyID(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("")); err == nil {
+		t.Error("expected error registering empty ID")
+	}
+}
+
+func TestDeregister(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.Deregister("ws-1"); err != nil {
+		t.Fatalf("deregister: %v", err)
+	}
+	if _, ok := r.Get("ws-1"); ok {
+		t.Error("workspace still present after deregister")
+	}
+	err := r.Deregister("ws-1")
+	if err == nil {
+		t.Fatal("expected error deregistering missing workspace")
+	}
+	if !errdefs.IsNotFound(err) {
+		t.Errorf("err = %v, want errdefs.ErrNotFound", err)
+	}
+}
+
+func TestListOrdered(t *testing.T) {
+	r := New()
+	first := testWorkspace("ws-1")
+	first.CreatedAt = time.Now().UTC().Add(-time.Hour)
+	second := testWorkspace("ws-2")
+
+	// Register out of creation order.
+	if err := r.Register(second); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.Register(first); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	list := r.List(Filter{})
+	if len(list) != 2 {
+		t.Fatalf("len(list) = %d, want 2", len(list))
+	}
+	if list[0].ID != "ws-1" || list[1].ID != "ws-2" {
+		t.Errorf("list order = [%s %s], want [ws-1 ws-2]", list[0].ID, list[1].ID)
+	}
+}
+
+func TestGetReturnsCopy(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ws, _ := r.Get("ws-1")
+	ws.Status = "mangled"
+
+	fresh, _ := r.Get("ws-1")
+	if fresh.Status == "mangled" {
+		t.Error("mutating a Get result leaked into the registry")
+	}
+}
+
+func TestConcurrentStatusUpdates(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := r.UpdateStatus("ws-1", StatusReady); err != nil {
+				t.Errorf("update status: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if ws, ok := r.Get("ws-1"); ok {
+				_ = ws.Status
+			}
+			r.List(Filter{})
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUpdateStatus(t *testing.T) {
+	r := New()
+	ws := testWorkspace("ws-1")
+	before := ws.UpdatedAt
+	if err := r.Register(ws); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := r.UpdateStatus("ws-1", StatusReady); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+
+	got, _ := r.Get("ws-1")
+	if got.Status != StatusReady {
+		t.Errorf("status = %q, want %q", got.Status, StatusReady)
+	}
+	if !got.UpdatedAt.After(before) && !got.UpdatedAt.Equal(before) {
+		t.Error("UpdatedAt not bumped")
+	}
+
+	if err := r.UpdateStatus("missing", StatusReady); err == nil {
+		t.Error("expected error updating missing workspace")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ws, _ := r.Get("ws-1")
+	ws.Image = "golang:1.22"
+	ws.Env = map[string]string{"CGO_ENABLED": "0"}
+	if err := r.Update(ws, ws.Version); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	updated, _ := r.Get("ws-1")
+	if updated.Image != "golang:1.22" || updated.Env["CGO_ENABLED"] != "0" {
+		t.Errorf("update not applied: %+v", updated)
+	}
+	if !updated.UpdatedAt.After(ws.UpdatedAt) && !updated.UpdatedAt.Equal(ws.UpdatedAt) {
+		t.Errorf("UpdatedAt not bumped: %v", updated.UpdatedAt)
+	}
+
+	// Renames would break the name index.
+	ws.Name = "other"
+	if err := r.Update(ws, 0); !errdefs.IsConflict(err) {
+		t.Errorf("rename error = %v, want conflict", err)
+	}
+
+	if err := r.Update(testWorkspace("ws-missing"), 0); !errdefs.IsNotFound(err) {
+		t.Errorf("missing error = %v, want not found", err)
+	}
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// Two writers read the same version; only the first write lands.
+	first, _ := r.Get("ws-1")
+	second, _ := r.Get("ws-1")
+	first.Image = "golang:1.22"
+	if err := r.Update(first, first.Version); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	second.Image = "node:20"
+	if err := r.Update(second, second.Version); !errdefs.IsConflict(err) {
+		t.Errorf("stale update error = %v, want conflict", err)
+	}
+	if got, _ := r.Get("ws-1"); got.Image != "golang:1.22" {
+		t.Errorf("image = %s, stale writer clobbered the first", got.Image)
+	}
+
+	// Version 0 is the escape hatch: last write wins.
+	if err := r.Update(second, 0); err != nil {
+		t.Errorf("unconditional update: %v", err)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	before, _ := r.Get("ws-1")
+	if !before.LastActive.IsZero() {
+		t.Errorf("LastActive = %v before any activity, want zero", before.LastActive)
+	}
+
+	r.Touch("ws-1")
+	after, _ := r.Get("ws-1")
+	if after.LastActive.IsZero() {
+		t.Error("LastActive not set by Touch")
+	}
+
+	// Touching a deleted workspace is a no-op, not a panic or error.
+	r.Touch("ws-missing")
+}
+
+func TestLock(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := r.Lock("ws-1", "agent-a", time.Minute); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	ws, _ := r.Get("ws-1")
+	if !ws.Lock.Live() || ws.Lock.Holder != "agent-a" {
+		t.Errorf("lock = %+v, want live lock held by agent-a", ws.Lock)
+	}
+
+	// Another holder bounces; the holder itself renews.
+	if err := r.Lock("ws-1", "agent-b", time.Minute); !errdefs.IsConflict(err) {
+		t.Errorf("competing lock error = %v, want conflict", err)
+	}
+	if err := r.Lock("ws-1", "agent-a", time.Minute); err != nil {
+		t.Errorf("renew: %v", err)
+	}
+
+	// Only the holder releases a live lock.
+	if err := r.Unlock("ws-1", "agent-b"); !errdefs.IsForbidden(err) {
+		t.Errorf("competing unlock error = %v, want forbidden", err)
+	}
+	if err := r.Unlock("ws-1", "agent-a"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := r.Lock("ws-1", "agent-b", time.Minute); err != nil {
+		t.Errorf("lock after release: %v", err)
+	}
+}
+
+func TestLockExpires(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if err := r.Lock("ws-1", "agent-a", time.Millisecond); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// An expired lock is as good as gone for everyone.
+	if err := r.Lock("ws-1", "agent-b", time.Minute); err != nil {
+		t.Errorf("lock after expiry: %v", err)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	r := New()
+	pay := testWorkspace("ws-pay")
+	pay.Name = "payments-api"
+	pay.Status = StatusReady
+	pay.Labels = map[string]string{"team": "payments", "env": "dev"}
+	web := testWorkspace("ws-web")
+	web.Name = "web-frontend"
+	web.Status = StatusCreating
+	web.Labels = map[string]string{"team": "web"}
+	for _, ws := range []*Workspace{pay, web} {
+		if err := r.Register(ws); err != nil {
+			t.Fatalf("register %s: %v", ws.ID, err)
+		}
+	}
+
+	if got := r.List(Filter{}); len(got) != 2 {
+		t.Errorf("zero filter matched %d workspaces, want 2", len(got))
+	}
+	got := r.List(Filter{Labels: map[string]string{"team": "payments"}})
+	if len(got) != 1 || got[0].ID != "ws-pay" {
+		t.Errorf("label filter = %v, want just ws-pay", got)
+	}
+	if got := r.List(Filter{Labels: map[string]string{"team": "payments", "env": "prod"}}); len(got) != 0 {
+		t.Errorf("mismatched label value matched %d workspaces, want 0", len(got))
+	}
+	got = r.List(Filter{Status: StatusCreating})
+	if len(got) != 1 || got[0].ID != "ws-web" {
+		t.Errorf("status filter = %v, want just ws-web", got)
+	}
+	got = r.List(Filter{NamePrefix: "payments-"})
+	if len(got) != 1 || got[0].ID != "ws-pay" {
+		t.Errorf("name prefix filter = %v, want just ws-pay", got)
+	}
+	if got := r.List(Filter{Status: StatusReady, NamePrefix: "web-"}); len(got) != 0 {
+		t.Errorf("combined filter matched %d workspaces, want 0", len(got))
+	}
+}
+
+func TestWorkspaceExpired(t *testing.T) {
+	ws := testWorkspace("ws-ttl")
+	ws.TTLSeconds = 60
+
+	if ws.Expired(ws.CreatedAt.Add(30 * time.Second)) {
+		t.Error("workspace expired before its TTL lapsed")
+	}
+	if !ws.Expired(ws.CreatedAt.Add(61 * time.Second)) {
+		t.Error("workspace not expired after its TTL lapsed")
+	}
+
+	ws.TTLSeconds = 0
+	if ws.Expired(ws.CreatedAt.Add(24 * time.Hour)) {
+		t.Error("workspace without a TTL should never expire")
+	}
+}
+
+func TestListSortAndPage(t *testing.T) {
+	r := New()
+	base := time.Now().UTC()
+	for i, name := range []string{"charlie", "alpha", "bravo"} {
+		ws := testWorkspace("ws-" + name)
+		ws.Name = name
+		ws.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := r.Register(ws); err != nil {
+			t.Fatalf("register %s: %v", ws.ID, err)
+		}
+	}
+
+	got := r.List(Filter{SortBy: SortName})
+	if len(got) != 3 || got[0].Name != "alpha" || got[2].Name != "charlie" {
+		t.Errorf("sort by name = %v, want alpha..charlie", names(got))
+	}
+	got = r.List(Filter{SortBy: SortName, Offset: 1, Limit: 1})
+	if len(got) != 1 || got[0].Name != "bravo" {
+		t.Errorf("offset 1 limit 1 = %v, want just bravo", names(got))
+	}
+	if got := r.List(Filter{Offset: 5}); len(got) != 0 {
+		t.Errorf("offset past the end matched %d workspaces, want 0", len(got))
+	}
+	got = r.List(Filter{Limit: 2})
+	if len(got) != 2 || got[0].Name != "charlie" {
+		t.Errorf("default sort limit 2 = %v, want charlie first (oldest)", names(got))
+	}
+}
+
+// names flattens a listing for test failure messages.
+func names(workspaces []*Workspace) []string {
+	var out []string
+	for _, ws := range workspaces {
+		out = append(out, ws.Name)
+	}
+	return out
+}
+
+func TestHistory(t *testing.T) {
+	r := New()
+	if err := r.Register(testWorkspace("ws-1")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.UpdateStatus("ws-1", StatusReady); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if err := r.Lock("ws-1", "agent-a", time.Minute); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	events, err := r.History("ws-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Kind != "registered" || events[1].Kind != "status" || events[2].Kind != "locked" {
+		t.Errorf("event kinds = %s, %s, %s", events[0].Kind, events[1].Kind, events[2].Kind)
+	}
+	if events[1].Detail != StatusReady {
+		t.Errorf("status event detail = %q, want %q", events[1].Detail, StatusReady)
+	}
+	if events[2].Detail != "agent-a" {
+		t.Errorf("lock event detail = %q, want agent-a", events[2].Detail)
+	}
+
+	if _, err := r.History("missing"); !errdefs.IsNotFound(err) {
+		t.Errorf("missing history error = %v, want not found", err)
+	}
+}
+
+func TestListNamespace(t *testing.T) {
+	r := New()
+	a := testWorkspace("ws-a")
+	a.Namespace = "team-a"
+	b := testWorkspace("ws-b")
+	b.Namespace = "team-b"
+	for _, ws := range []*Workspace{a, b} {
+		if err := r.Register(ws); err != nil {
+			t.Fatalf("register %s: %v", ws.ID, err)
+		}
+	}
+
+	got := r.List(Filter{Namespace: "team-a"})
+	if len(got) != 1 || got[0].ID != "ws-a" {
+		t.Errorf("namespace filter = %v, want just ws-a", names(got))
+	}
+	if got := r.List(Filter{}); len(got) != 2 {
+		t.Errorf("unscoped list matched %d workspaces, want 2", len(got))
+	}
+}
+
+func TestStats(t *testing.T) {
+	r := New()
+	fresh := testWorkspace("ws-fresh")
+	fresh.Status = StatusReady
+	fresh.Namespace = "team-a"
+	old := testWorkspace("ws-old")
+	old.CreatedAt = time.Now().UTC().Add(-48 * time.Hour)
+	for _, ws := range []*Workspace{fresh, old} {
+		if err := r.Register(ws); err != nil {
+			t.Fatalf("register %s: %v", ws.ID, err)
+		}
+	}
+
+	stats := r.Stats()
+	if stats.Total != 2 {
+		t.Errorf("total = %d, want 2", stats.Total)
+	}
+	if stats.ByStatus[StatusReady] != 1 || stats.ByStatus[StatusCreating] != 1 {
+		t.Errorf("by status = %v", stats.ByStatus)
+	}
+	if stats.ByNamespace["team-a"] != 1 || stats.ByNamespace["default"] != 1 {
+		t.Errorf("by namespace = %v", stats.ByNamespace)
+	}
+	if stats.Ages.UnderHour != 1 || stats.Ages.UnderWeek != 1 {
+		t.Errorf("ages = %+v", stats.Ages)
+	}
+}
@@ -0,0 +1,61 @@
+package registry
+
+import "time"
+
+// Stats is a point-in-time summary of the fleet, for dashboards and
+// capacity questions ("how many ready workspaces does team-a hold, and
+// how stale are they") that would otherwise need a full List and a
+// script.
+type Stats struct {
+	// Total is the number of registered workspaces.
+	Total int `json:"total"`
+	// ByStatus counts workspaces per lifecycle status.
+	ByStatus map[string]int `json:"by_status"`
+	// ByNamespace counts workspaces per tenant namespace; workspaces
+	// without one count under "default".
+	ByNamespace map[string]int `json:"by_namespace"`
+	// Ages histograms workspace age since creation.
+	Ages AgeBuckets `json:"ages"`
+}
+
+// AgeBuckets is a coarse age histogram. The buckets are cumulative
+// lifecycle bands, not precise percentiles: fresh, today, this week,
+// older.
+type AgeBuckets struct {
+	UnderHour int `json:"under_hour"`
+	UnderDay  int `json:"under_day"`
+	UnderWeek int `json:"under_week"`
+	Older     int `json:"older"`
+}
+
+// Stats summarizes the current workspace set.
+func (r *Registry) Stats() Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := Stats{
+		ByStatus:    make(map[string]int),
+		ByNamespace: make(map[string]int),
+	}
+	now := time.Now().UTC()
+	for _, ws := range r.workspaces {
+		stats.Total++
+		stats.ByStatus[ws.Status]++
+		namespace := ws.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		stats.ByNamespace[namespace]++
+		switch age := now.Sub(ws.CreatedAt); {
+		case age < time.Hour:
+			stats.Ages.UnderHour++
+		case age < 24*time.Hour:
+			stats.Ages.UnderDay++
+		case age < 7*24*time.Hour:
+			stats.Ages.UnderWeek++
+		default:
+			stats.Ages.Older++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Store persists registry state across gateway restarts. Implementations
+// only need whole-snapshot semantics: the registry is small (one record
+// per workspace) and mutations are rare next to reads.
+type Store interface {
+	// Load returns all persisted workspaces; an empty store returns nil.
+	Load() ([]*Workspace, error)
+	// Save replaces the persisted state with the given workspace set.
+	Save(workspaces []*Workspace) error
+}
+
+// NewWithStore creates a registry backed by a store: existing records
+// are loaded on startup and every mutation is written back, so
+// workspaces survive a gateway restart. New() remains the in-memory
+// default for callers that don't need durability.
+func NewWithStore(store Store) (*Registry, error) {
+	r := New()
+	r.store = store
+
+	workspaces, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load registry state: %w", err)
+	}
+	for _, ws := range workspaces {
+		r.workspaces[ws.ID] = ws.clone()
+		if ws.Name != "" {
+			r.byName[ws.Name] = ws.ID
+		}
+	}
+	return r, nil
+}
+
+// Export writes the workspace set as indented JSON — the same shape
+// FileStore persists — for backups, host migration and debugging
+// snapshots. An export can seed a -state-file directly or be imported
+// into another gateway.
+func (r *Registry) Export(w io.Writer) error {
+	r.mu.RLock()
+	out := make([]*Workspace, 0, len(r.workspaces))
+	for _, ws := range r.workspaces {
+		out = append(out, ws.clone())
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Import merges workspaces from an Export stream, returning how many
+// were added. Records whose ID or name is already registered are
+// skipped: import restores missing state, it never clobbers live
+// workspaces.
+func (r *Registry) Import(rd io.Reader) (int, error) {
+	var workspaces []*Workspace
+	if err := json.NewDecoder(rd).Decode(&workspaces); err != nil {
+		return 0, fmt.Errorf("parse import: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	added := 0
+	for _, ws := range workspaces {
+		if ws.ID == "" {
+			continue
+		}
+		if _, exists := r.workspaces[ws.ID]; exists {
+			continue
+		}
+		if _, exists := r.byName[ws.Name]; exists {
+			continue
+		}
+		r.workspaces[ws.ID] = ws.clone()
+		if ws.Name != "" {
+			r.byName[ws.Name] = ws.ID
+		}
+		r.recordEventLocked(ws.ID, "imported", "")
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, r.persistLocked()
+}
+
+// Reload replaces in-memory state with the store's current snapshot.
+// Multi-instance deployments call it when the shared store changes
+// (see RedisStore.Watch), so every replica converges on one view.
+func (r *Registry) Reload() error {
+	if r.store == nil {
+		return nil
+	}
+	workspaces, err := r.store.Load()
+	if err != nil {
+		return fmt.Errorf("reload registry state: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workspaces = make(map[string]*Workspace, len(workspaces))
+	r.byName = make(map[string]string, len(workspaces))
+	for _, ws := range workspaces {
+		r.workspaces[ws.ID] = ws.clone()
+		if ws.Name != "" {
+			r.byName[ws.Name] = ws.ID
+		}
+	}
+	return nil
+}
+
+// persistLocked writes the current state through the store, if any.
+// Callers hold the registry lock.
+func (r *Registry) persistLocked() error {
+	if r.store == nil {
+		return nil
+	}
+	out := make([]*Workspace, 0, len(r.workspaces))
+	for _, ws := range r.workspaces {
+		out = append(out, ws.clone())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if err := r.store.Save(out); err != nil {
+		return fmt.Errorf("persist registry state: %w", err)
+	}
+	return nil
+}
+
+// FileStore persists the registry as one JSON file, written atomically
+// through a rename. It is the zero-dependency store; the Store
+// interface leaves room for bbolt or SQLite behind the same registry.
+type FileStore struct {
+	// Path is the JSON file holding the state.
+	Path string
+}
+
+// NewFileStore creates a store writing to the given path. Parent
+// directories are created on first save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the persisted workspaces. A missing file is an empty
+// store, so first runs need no setup.
+func (s *FileStore) Load() ([]*Workspace, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var workspaces []*Workspace
+	if err := json.Unmarshal(data, &workspaces); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.Path, err)
+	}
+	return workspaces, nil
+}
+
+// Save writes the workspace set to a temp file and renames it into
+// place, so a crash mid-write never leaves a truncated state file.
+func (s *FileStore) Save(workspaces []*Workspace) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(workspaces, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "registry.json")
+	reg, err := NewWithStore(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("NewWithStore: %v", err)
+	}
+
+	ws := &Workspace{ID: "ws-1", Name: "demo", Image: "alpine:3.20", Status: StatusReady}
+	if err := reg.Register(ws); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// A fresh registry over the same store sees the record: this is the
+	// restart path.
+	restarted, err := NewWithStore(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, ok := restarted.Get("ws-1")
+	if !ok {
+		t.Fatal("workspace lost across restart")
+	}
+	if got.Name != "demo" || got.Image != "alpine:3.20" || got.Status != StatusReady {
+		t.Errorf("restored workspace = %+v", got)
+	}
+	if _, ok := restarted.GetByName("demo"); !ok {
+		t.Error("name index not rebuilt from store")
+	}
+
+	// Deletions persist too.
+	if err := restarted.Deregister("ws-1"); err != nil {
+		t.Fatalf("deregister: %v", err)
+	}
+	again, err := NewWithStore(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("reopen after delete: %v", err)
+	}
+	if _, ok := again.Get("ws-1"); ok {
+		t.Error("deregistered workspace came back after restart")
+	}
+}
+
+func TestFileStoreMissingFileIsEmpty(t *testing.T) {
+	reg, err := NewWithStore(NewFileStore(filepath.Join(t.TempDir(), "absent.json")))
+	if err != nil {
+		t.Fatalf("NewWithStore: %v", err)
+	}
+	if got := reg.List(Filter{}); len(got) != 0 {
+		t.Errorf("fresh store lists %d workspaces, want 0", len(got))
+	}
+}
+
+func TestFileStoreRejectsCorruptState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWithStore(NewFileStore(path)); err == nil {
+		t.Error("corrupt state file accepted, want error")
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	src := New()
+	ws := testWorkspace("ws-1")
+	ws.Image = "golang:1.22"
+	if err := src.Register(ws); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Export(&buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := New()
+	added, err := dst.Import(&buf)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("imported %d workspaces, want 1", added)
+	}
+	got, ok := dst.Get("ws-1")
+	if !ok || got.Image != "golang:1.22" {
+		t.Fatalf("imported workspace = %+v, want ws-1 with image", got)
+	}
+
+	// Re-importing the same snapshot is a no-op, not a conflict.
+	var again bytes.Buffer
+	if err := src.Export(&again); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if added, err := dst.Import(&again); err != nil || added != 0 {
+		t.Errorf("re-import = (%d, %v), want (0, nil)", added, err)
+	}
+
+	if _, err := dst.Import(bytes.NewBufferString("{broken")); err == nil {
+		t.Error("expected error importing malformed JSON")
+	}
+}
@@ -0,0 +1,24 @@
+// Package errdefs defines the error categories shared by the workspace
+// modules, so callers like the api-gateway can map module errors onto
+// HTTP statuses without matching on error strings.
+package errdefs
+
+import "errors"
+
+// ErrNotFound marks lookups of records that do not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict marks operations rejected because a record already exists.
+var ErrConflict = errors.New("conflict")
+
+// ErrForbidden marks operations rejected by policy.
+var ErrForbidden = errors.New("forbidden")
+
+// IsNotFound reports whether err is (or wraps) ErrNotFound.
+func IsNotFound(err error) bool { return errors.Is(err, ErrNotFound) }
+
+// IsConflict reports whether err is (or wraps) ErrConflict.
+func IsConflict(err error) bool { return errors.Is(err, ErrConflict) }
+
+// IsForbidden reports whether err is (or wraps) ErrForbidden.
+func IsForbidden(err error) bool { return errors.Is(err, ErrForbidden) }
@@ -0,0 +1,79 @@
+package gitmanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileDiff summarizes one changed file. Binary files count zero lines
+// either way.
+type FileDiff struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+}
+
+// DiffResult is what a workspace changed relative to its base: the
+// unified patch for review plus per-file and total line counts for
+// summaries that don't want to parse it.
+type DiffResult struct {
+	// Base is the branch the diff is against.
+	Base string `json:"base"`
+	// Files lists changed files with their line counts.
+	Files []FileDiff `json:"files"`
+	// Insertions and Deletions are the totals across files.
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+	// Patch is the unified diff text.
+	Patch string `json:"patch"`
+}
+
+// Diff reports everything the workspace changed since it diverged from
+// base — committed or not — as a patch plus a structured summary. An
+// empty base means the repository's default branch. The diff runs from
+// the merge base, so commits that landed on base afterwards don't show
+// up as the workspace's work.
+func (m *Manager) Diff(name, base string) (*DiffResult, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if base == "" {
+		detected, err := m.DefaultBranch()
+		if err != nil {
+			return nil, err
+		}
+		base = detected
+	}
+
+	mergeBase, err := m.gitIn(path, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("diff %s against %s: %w", name, base, err)
+	}
+	patch, err := m.gitIn(path, "diff", mergeBase)
+	if err != nil {
+		return nil, fmt.Errorf("diff %s against %s: %w", name, base, err)
+	}
+	numstat, err := m.gitIn(path, "diff", "--numstat", mergeBase)
+	if err != nil {
+		return nil, fmt.Errorf("summarize diff for %s: %w", name, err)
+	}
+
+	result := &DiffResult{Base: base, Patch: patch}
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files report "-" for both counts.
+		insertions, _ := strconv.Atoi(fields[0])
+		deletions, _ := strconv.Atoi(fields[1])
+		result.Files = append(result.Files, FileDiff{
+			Path:       fields[2],
+			Insertions: insertions,
+			Deletions:  deletions,
+		})
+		result.Insertions += insertions
+		result.Deletions += deletions
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	// One committed change plus one uncommitted edit: both are the
+	// workspace's work and both should show.
+	commitFile(t, m, "demo", "a.txt", "committed change")
+	worktree := filepath.Join(m.WorktreeRoot, "demo")
+	if err := os.WriteFile(filepath.Join(worktree, "README.md"), []byte("test\nedited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Diff("demo", "master")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if result.Base != "master" {
+		t.Errorf("base = %q, want master", result.Base)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("files = %+v, want a.txt and README.md", result.Files)
+	}
+	if result.Insertions != 2 || result.Deletions != 0 {
+		t.Errorf("totals = +%d/-%d, want +2/-0", result.Insertions, result.Deletions)
+	}
+	if !strings.Contains(result.Patch, "+committed change") || !strings.Contains(result.Patch, "+edited") {
+		t.Errorf("patch missing changes:\n%s", result.Patch)
+	}
+
+	// Commits landing on base afterwards are not the workspace's work.
+	if err := os.WriteFile(filepath.Join(repo, "base.txt"), []byte("base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("add", "base.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("commit", "-m", "base moves on"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = m.Diff("demo", "master")
+	if err != nil {
+		t.Fatalf("diff after base change: %v", err)
+	}
+	for _, file := range result.Files {
+		if file.Path == "base.txt" {
+			t.Error("diff includes base-side change base.txt")
+		}
+	}
+}
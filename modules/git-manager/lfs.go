@@ -0,0 +1,50 @@
+package gitmanager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// UsesLFS reports whether the repository tracks content with Git LFS,
+// detected from lfs filters in the root .gitattributes. Worktrees of
+// such repos need an LFS pull or their tracked files come up as
+// pointer stubs instead of content.
+func (m *Manager) UsesLFS() bool {
+	data, err := os.ReadFile(filepath.Join(m.RepoPath, ".gitattributes"))
+	return err == nil && strings.Contains(string(data), "filter=lfs")
+}
+
+// initLFS fetches LFS content into a fresh worktree when the repository
+// uses LFS. Like initSubmodules, a failure removes the worktree: a
+// checkout of pointer stubs breaks builds in ways that don't point back
+// here.
+func (m *Manager) initLFS(name string) error {
+	if !m.UsesLFS() {
+		return nil
+	}
+	if err := m.PullLFS(name); err != nil {
+		m.RemoveWorktree(name) //nolint:errcheck
+		return err
+	}
+	return nil
+}
+
+// PullLFS replaces LFS pointer files in the workspace with their
+// content. Requires the git-lfs client on the host; without it the
+// error says so rather than leaving stubs silently in place.
+func (m *Manager) PullLFS(name string) error {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return fmt.Errorf("repository uses git LFS but git-lfs is not installed")
+	}
+	path := filepath.Join(m.WorktreeRoot, name)
+	if _, err := m.gitIn(path, "lfs", "install", "--local"); err != nil {
+		return fmt.Errorf("install LFS hooks for %s: %w", name, err)
+	}
+	if _, err := m.gitIn(path, "lfs", "pull"); err != nil {
+		return fmt.Errorf("pull LFS content for %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package gitmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUsesLFS(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+
+	if m.UsesLFS() {
+		t.Error("UsesLFS = true for repo without .gitattributes")
+	}
+
+	attrs := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte(attrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !m.UsesLFS() {
+		t.Error("UsesLFS = false for repo with lfs filters")
+	}
+}
+
+func TestCreateWorktreeWithoutLFSClient(t *testing.T) {
+	if _, err := exec.LookPath("git-lfs"); err == nil {
+		t.Skip("git-lfs installed; missing-client path not reachable")
+	}
+
+	repo := initTestRepo(t)
+	attrs := "*.bin filter=lfs diff=lfs merge=lfs -text\n"
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte(attrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := New(repo)
+	_, _, err := m.CreateWorktree("demo")
+	if err == nil || !strings.Contains(err.Error(), "git-lfs is not installed") {
+		t.Fatalf("CreateWorktree error = %v, want missing git-lfs", err)
+	}
+	// The failed worktree is cleaned up, not left half-populated.
+	if _, err := os.Stat(filepath.Join(m.WorktreeRoot, "demo")); !os.IsNotExist(err) {
+		t.Errorf("worktree left behind after failed LFS init (err = %v)", err)
+	}
+}
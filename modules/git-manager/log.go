@@ -0,0 +1,82 @@
+package gitmanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is one workspace commit in a form UIs and agents can render
+// without parsing git output themselves.
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	// Files are the paths the commit touched.
+	Files []string `json:"files,omitempty"`
+}
+
+// LogOptions narrows a Log call. The zero value returns the branch's
+// full history.
+type LogOptions struct {
+	// Base limits the log to commits since the branch diverged from
+	// base — the workspace's own work.
+	Base string
+	// Limit caps how many commits come back, newest first; 0 means all.
+	Limit int
+}
+
+// Log returns the workspace branch's commits, newest first.
+func (m *Manager) Log(name string, opts LogOptions) ([]Commit, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	// Records are separated by \x1e and header fields by \x00, so
+	// subjects with newlines or tabs can't break parsing.
+	args := []string{"log", "--format=%x1e%H%x00%an%x00%ae%x00%aI%x00%s", "--name-only"}
+	if opts.Limit > 0 {
+		args = append(args, "-n", strconv.Itoa(opts.Limit))
+	}
+	if opts.Base != "" {
+		args = append(args, opts.Base+"..HEAD")
+	}
+
+	out, err := m.gitIn(path, args...)
+	if err != nil {
+		return nil, fmt.Errorf("log for %s: %w", name, err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("parse commit date %q: %w", fields[3], err)
+		}
+		commit := Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    date,
+			Subject: fields[4],
+		}
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				commit.Files = append(commit.Files, line)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
@@ -0,0 +1,42 @@
+package gitmanager
+
+import "testing"
+
+func TestLog(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "a.txt", "first change")
+	commitFile(t, m, "demo", "b.txt", "second change")
+
+	// Since base: just the workspace's two commits, newest first.
+	commits, err := m.Log("demo", LogOptions{Base: "master"})
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2: %+v", len(commits), commits)
+	}
+	if commits[0].Subject != "second change" || commits[1].Subject != "first change" {
+		t.Errorf("order = [%q %q], want newest first", commits[0].Subject, commits[1].Subject)
+	}
+	if commits[0].Author != "test" || commits[0].Email != "test@test" {
+		t.Errorf("author = %s <%s>, want test <test@test>", commits[0].Author, commits[0].Email)
+	}
+	if commits[0].Date.IsZero() {
+		t.Error("commit date not parsed")
+	}
+	if len(commits[0].Files) != 1 || commits[0].Files[0] != "b.txt" {
+		t.Errorf("files = %v, want [b.txt]", commits[0].Files)
+	}
+
+	// Unscoped log includes the initial commit; limit caps it.
+	commits, err = m.Log("demo", LogOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("limited log: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "second change" {
+		t.Errorf("limited log = %+v, want just the newest commit", commits)
+	}
+}
@@ -0,0 +1,432 @@
+// Package gitmanager handles git operations for workspaces.
+//
+// Each workspace gets its own worktree and branch so agents can work in
+// isolation without touching the main checkout. All operations shell out
+// to the system git, mirroring how the Patina CLI drives git.
+package gitmanager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Manager performs git operations against a single repository.
+type Manager struct {
+	// RepoPath is the path to the source repository.
+	RepoPath string
+	// WorktreeRoot is the directory workspace worktrees are created under.
+	WorktreeRoot string
+	// BaseBranch overrides default-branch detection when set, for repos
+	// whose remote HEAD doesn't point at the branch teams actually base
+	// work on.
+	BaseBranch string
+	// InitSubmodules makes new worktrees populate their submodules.
+	// Off by default: most repos have none, and for those that do the
+	// clone cost belongs to an explicit opt-in. Without it, worktrees
+	// of submodule repos come up with empty directories where builds
+	// expect source.
+	InitSubmodules bool
+}
+
+// New creates a manager for the repository at repoPath. Worktrees are
+// placed in .patina/worktrees inside the repository.
+func New(repoPath string) *Manager {
+	return &Manager{
+		RepoPath:     repoPath,
+		WorktreeRoot: filepath.Join(repoPath, ".patina", "worktrees"),
+	}
+}
+
+// DefaultBranch returns the branch workspace operations use as their
+// base: the configured override if set, otherwise the remote's default
+// branch (origin/HEAD), otherwise the main checkout's current branch.
+// Hard-coding "main" would silently pick the wrong base in master and
+// trunk repositories.
+func (m *Manager) DefaultBranch() (string, error) {
+	if m.BaseBranch != "" {
+		return m.BaseBranch, nil
+	}
+	if out, err := m.git("symbolic-ref", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(out, "refs/remotes/origin/"), nil
+	}
+	// No origin (or its HEAD is unset): the local checkout is all there is.
+	branch, err := m.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("detect default branch: %w", err)
+	}
+	return branch, nil
+}
+
+// CreateWorktree creates a worktree and branch for the named workspace.
+// Returns the worktree path and branch name.
+func (m *Manager) CreateWorktree(name string) (string, string, error) {
+	branch := "workspace/" + name
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	if _, err := m.git("worktree", "add", "-b", branch, path); err != nil {
+		return "", "", fmt.Errorf("create worktree for %s: %w", name, err)
+	}
+	if err := m.initSubmodules(name); err != nil {
+		return "", "", err
+	}
+	if err := m.initLFS(name); err != nil {
+		return "", "", err
+	}
+	return path, branch, nil
+}
+
+// CreateWorktreeAt creates a worktree for the named workspace pinned to
+// ref (a commit SHA or tag), so bug-reproduction workspaces can target
+// exact historical states. By default a new workspace branch starts at
+// that commit; with detach the worktree checks the ref out directly and
+// no branch exists (so the workspace cannot push). An empty ref falls
+// back to CreateWorktree.
+func (m *Manager) CreateWorktreeAt(name, ref string, detach bool) (string, string, error) {
+	if ref == "" {
+		return m.CreateWorktree(name)
+	}
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	if detach {
+		if _, err := m.git("worktree", "add", "--detach", path, ref); err != nil {
+			return "", "", fmt.Errorf("create worktree for %s at %s: %w", name, ref, err)
+		}
+		if err := m.initSubmodules(name); err != nil {
+			return "", "", err
+		}
+		if err := m.initLFS(name); err != nil {
+			return "", "", err
+		}
+		return path, "", nil
+	}
+
+	branch := "workspace/" + name
+	if _, err := m.git("worktree", "add", "-b", branch, path, ref); err != nil {
+		return "", "", fmt.Errorf("create worktree for %s at %s: %w", name, ref, err)
+	}
+	if err := m.initSubmodules(name); err != nil {
+		return "", "", err
+	}
+	if err := m.initLFS(name); err != nil {
+		return "", "", err
+	}
+	return path, branch, nil
+}
+
+// CreateWorktreeSparse creates a worktree for the named workspace
+// containing only the given sparse-checkout directories, so monorepo
+// workspaces check out (and copy into containers) just the paths they
+// need. Empty patterns fall back to a full CreateWorktree.
+func (m *Manager) CreateWorktreeSparse(name string, patterns []string) (string, string, error) {
+	if len(patterns) == 0 {
+		return m.CreateWorktree(name)
+	}
+	branch := "workspace/" + name
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	// --no-checkout defers populating the tree until the sparse
+	// patterns are in place, so the full tree is never materialized.
+	if _, err := m.git("worktree", "add", "--no-checkout", "-b", branch, path); err != nil {
+		return "", "", fmt.Errorf("create worktree for %s: %w", name, err)
+	}
+	if _, err := m.gitIn(path, append([]string{"sparse-checkout", "set"}, patterns...)...); err != nil {
+		m.RemoveWorktree(name) //nolint:errcheck
+		return "", "", fmt.Errorf("set sparse checkout for %s: %w", name, err)
+	}
+	if _, err := m.gitIn(path, "checkout"); err != nil {
+		m.RemoveWorktree(name) //nolint:errcheck
+		return "", "", fmt.Errorf("populate sparse worktree for %s: %w", name, err)
+	}
+	if err := m.initSubmodules(name); err != nil {
+		return "", "", err
+	}
+	if err := m.initLFS(name); err != nil {
+		return "", "", err
+	}
+	return path, branch, nil
+}
+
+// initSubmodules populates a fresh worktree's submodules when the
+// manager is configured for it. A failed init removes the worktree: a
+// half-populated checkout is worse than an error the caller can act on.
+func (m *Manager) initSubmodules(name string) error {
+	if !m.InitSubmodules {
+		return nil
+	}
+	if err := m.UpdateSubmodules(name); err != nil {
+		m.RemoveWorktree(name) //nolint:errcheck
+		return err
+	}
+	return nil
+}
+
+// UpdateSubmodules brings the workspace's submodules in line with the
+// checked-out commit, cloning any that are missing. A no-op for repos
+// without submodules.
+func (m *Manager) UpdateSubmodules(name string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if _, err := m.gitIn(path, "submodule", "update", "--init", "--recursive"); err != nil {
+		return fmt.Errorf("update submodules for %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveWorktree removes the named workspace's worktree and branch.
+func (m *Manager) RemoveWorktree(name string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	if _, err := m.git("worktree", "remove", "--force", path); err != nil {
+		// Fall back to pruning if the directory is already gone.
+		if _, pruneErr := m.git("worktree", "prune"); pruneErr != nil {
+			return fmt.Errorf("remove worktree for %s: %w", name, err)
+		}
+	}
+
+	// Branch deletion is best-effort: the branch may hold unmerged work.
+	m.git("branch", "-D", "workspace/"+name) //nolint:errcheck
+	return nil
+}
+
+// ListWorktrees returns the names of workspace worktrees present on
+// disk, whether or not anything else still knows about them. A missing
+// worktree root means no worktrees, not an error.
+func (m *Manager) ListWorktrees() ([]string, error) {
+	entries, err := os.ReadDir(m.WorktreeRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("list worktrees: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Snapshot captures the main checkout's uncommitted tracked changes as
+// a stash commit without touching the working tree. Returns "" when the
+// checkout is clean. Untracked files are not captured; commit or add
+// them first.
+func (m *Manager) Snapshot() (string, error) {
+	sha, err := m.git("stash", "create")
+	if err != nil {
+		return "", fmt.Errorf("snapshot working copy: %w", err)
+	}
+	return sha, nil
+}
+
+// ApplySnapshot applies a Snapshot commit onto the workspace worktree,
+// handing the main checkout's in-progress state to the workspace.
+func (m *Manager) ApplySnapshot(name, sha string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if _, err := m.gitIn(path, "stash", "apply", sha); err != nil {
+		return fmt.Errorf("apply snapshot to %s: %w", name, err)
+	}
+	return nil
+}
+
+// Status returns porcelain status output for the workspace worktree.
+func (m *Manager) Status(name string) (string, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+	return m.gitIn(path, "status", "--porcelain")
+}
+
+// Trailer is one git commit trailer (e.g. "Workspace-Id: abc123"),
+// appended so provenance of workspace-generated commits survives into
+// the main repository history.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Commit stages all changes in the workspace worktree and commits them,
+// appending any trailers to the message.
+func (m *Manager) Commit(name, message string, trailers ...Trailer) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	if _, err := m.gitIn(path, "add", "-A"); err != nil {
+		return fmt.Errorf("stage changes for %s: %w", name, err)
+	}
+	args := []string{"commit", "-m", message}
+	for _, t := range trailers {
+		args = append(args, "--trailer", t.Key+": "+t.Value)
+	}
+	if _, err := m.gitIn(path, args...); err != nil {
+		return fmt.Errorf("commit in %s: %w", name, err)
+	}
+	return nil
+}
+
+// Checkpoint commits all outstanding changes in the workspace worktree
+// if there are any. Returns true when a commit was created. Used by
+// auto-commit policies so crashed agents lose minutes, not hours.
+func (m *Manager) Checkpoint(name, message string, trailers ...Trailer) (bool, error) {
+	if !m.isDirty(name) {
+		return false, nil
+	}
+	if err := m.Commit(name, message, trailers...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PushResult describes the outcome of pushing a workspace branch, with
+// enough detail for clients to link users to the pushed commits.
+type PushResult struct {
+	// Branch is the local workspace branch that was pushed.
+	Branch string `json:"branch"`
+	// RemoteBranch is the remote tracking ref, e.g. origin/workspace/demo.
+	RemoteBranch string `json:"remote_branch"`
+	// NewBranch is true when the push created the remote branch.
+	NewBranch bool `json:"new_branch"`
+	// CommitRange is the pushed range (old..new), or the head commit for
+	// a new branch.
+	CommitRange string `json:"commit_range"`
+	// RemoteURL is the URL of the origin remote.
+	RemoteURL string `json:"remote_url"`
+	// PRURL is a pull-request creation hint for known forges, if any.
+	PRURL string `json:"pr_url,omitempty"`
+}
+
+// Push pushes the workspace branch to origin and reports what changed
+// on the remote.
+func (m *Manager) Push(name string) (*PushResult, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+	branch := "workspace/" + name
+
+	// Remember the remote ref before pushing so we can report the range.
+	oldHead, err := m.gitIn(path, "rev-parse", "refs/remotes/origin/"+branch)
+	newBranch := err != nil
+
+	if _, err := m.gitIn(path, "push", "-u", "origin", branch); err != nil {
+		return nil, fmt.Errorf("push %s: %w", branch, err)
+	}
+
+	newHead, err := m.gitIn(path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve pushed head: %w", err)
+	}
+
+	commitRange := newHead
+	if !newBranch {
+		commitRange = oldHead + ".." + newHead
+	}
+
+	remoteURL, err := m.gitIn(path, "remote", "get-url", "origin")
+	if err != nil {
+		return nil, fmt.Errorf("resolve remote URL: %w", err)
+	}
+
+	return &PushResult{
+		Branch:       branch,
+		RemoteBranch: "origin/" + branch,
+		NewBranch:    newBranch,
+		CommitRange:  commitRange,
+		RemoteURL:    remoteURL,
+		PRURL:        prHintURL(remoteURL, branch),
+	}, nil
+}
+
+// Fetch updates the workspace's view of a remote without touching its
+// checkout. An empty remote means origin.
+func (m *Manager) Fetch(name, remote string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if remote == "" {
+		remote = "origin"
+	}
+	if _, err := m.gitIn(path, "fetch", remote); err != nil {
+		return fmt.Errorf("fetch %s for %s: %w", remote, name, err)
+	}
+	return nil
+}
+
+// PullResult describes the outcome of updating a workspace branch from
+// its upstream.
+type PullResult struct {
+	// Mode is the pull mode that ran: "ff-only" or "rebase".
+	Mode string `json:"mode"`
+	// OldHead and NewHead are the branch head before and after.
+	OldHead string `json:"old_head"`
+	NewHead string `json:"new_head"`
+	// Updated is false when the branch was already current.
+	Updated bool `json:"updated"`
+}
+
+// Pull brings the workspace branch up to date with its upstream. The
+// default "ff-only" mode refuses to touch local commits; "rebase"
+// replays them on the updated upstream. Workspaces drift from main
+// otherwise, with no supported way back.
+func (m *Manager) Pull(name, mode string) (*PullResult, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	var args []string
+	switch mode {
+	case "", "ff-only":
+		mode = "ff-only"
+		args = []string{"pull", "--ff-only"}
+	case "rebase":
+		args = []string{"pull", "--rebase"}
+	default:
+		return nil, fmt.Errorf("unknown pull mode %q (want ff-only or rebase)", mode)
+	}
+
+	oldHead, err := m.gitIn(path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve head for %s: %w", name, err)
+	}
+	if _, err := m.gitIn(path, args...); err != nil {
+		return nil, fmt.Errorf("pull %s: %w", name, err)
+	}
+	newHead, err := m.gitIn(path, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve pulled head: %w", err)
+	}
+
+	return &PullResult{
+		Mode:    mode,
+		OldHead: oldHead,
+		NewHead: newHead,
+		Updated: oldHead != newHead,
+	}, nil
+}
+
+// prHintURL builds a pull-request creation link for GitHub remotes.
+// Returns "" for remotes we don't recognize.
+func prHintURL(remoteURL, branch string) string {
+	cleaned := strings.TrimSuffix(strings.TrimSpace(remoteURL), ".git")
+	switch {
+	case strings.HasPrefix(cleaned, "git@github.com:"):
+		cleaned = "https://github.com/" + strings.TrimPrefix(cleaned, "git@github.com:")
+	case strings.HasPrefix(cleaned, "https://github.com/"):
+		// Already in web form.
+	default:
+		return ""
+	}
+	return cleaned + "/pull/new/" + branch
+}
+
+// git runs a git command in the main repository.
+func (m *Manager) git(args ...string) (string, error) {
+	return m.gitIn(m.RepoPath, args...)
+}
+
+// gitIn runs a git command in the given directory and returns trimmed
+// stdout. On failure the error carries git's stderr.
+func (m *Manager) gitIn(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,433 @@
+package gitmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initTestRepo creates a git repository with one commit and returns its path.
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@test",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@test",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	run("init", "-b", "master")
+	run("config", "user.name", "test")
+	run("config", "user.email", "test@test")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-m", "initial")
+	return dir
+}
+
+func TestCreateAndRemoveWorktree(t *testing.T) {
+	m := New(initTestRepo(t))
+
+	path, branch, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	if branch != "workspace/demo" {
+		t.Errorf("branch = %q, want workspace/demo", branch)
+	}
+	if _, err := os.Stat(filepath.Join(path, "README.md")); err != nil {
+		t.Errorf("worktree missing checkout: %v", err)
+	}
+
+	if err := m.RemoveWorktree("demo"); err != nil {
+		t.Fatalf("remove worktree: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("worktree directory still exists after removal")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	m := New(initTestRepo(t))
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	// Clean worktree: nothing to commit.
+	committed, err := m.Checkpoint("demo", "checkpoint")
+	if err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if committed {
+		t.Error("checkpoint on clean worktree should not commit")
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err = m.Checkpoint("demo", "checkpoint: demo")
+	if err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if !committed {
+		t.Error("checkpoint on dirty worktree should commit")
+	}
+
+	status, err := m.Status("demo")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status != "" {
+		t.Errorf("worktree not clean after checkpoint: %q", status)
+	}
+}
+
+func TestSnapshotAndApply(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+
+	// Clean checkout: nothing to snapshot.
+	sha, err := m.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if sha != "" {
+		t.Errorf("clean snapshot = %q, want empty", sha)
+	}
+
+	// Dirty the main checkout, then hand the change to a workspace.
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("in progress\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sha, err = m.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if sha == "" {
+		t.Fatal("dirty snapshot returned no commit")
+	}
+
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	if err := m.ApplySnapshot("demo", sha); err != nil {
+		t.Fatalf("apply snapshot: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "in progress\n" {
+		t.Errorf("worktree README = %q, want handed-off change", data)
+	}
+}
+
+func TestPushReportsResult(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+
+	// Bare repository standing in for origin.
+	origin := t.TempDir()
+	if out, err := exec.Command("git", "init", "--bare", origin).CombinedOutput(); err != nil {
+		t.Fatalf("init bare: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", repo, "remote", "add", "origin", origin).CombinedOutput(); err != nil {
+		t.Fatalf("add remote: %v\n%s", err, out)
+	}
+
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	result, err := m.Push("demo")
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if !result.NewBranch {
+		t.Error("first push should report a new branch")
+	}
+	if result.RemoteBranch != "origin/workspace/demo" {
+		t.Errorf("remote branch = %q, want origin/workspace/demo", result.RemoteBranch)
+	}
+	if result.RemoteURL != origin {
+		t.Errorf("remote URL = %q, want %q", result.RemoteURL, origin)
+	}
+	if strings.Contains(result.CommitRange, "..") {
+		t.Errorf("new-branch range = %q, want single head", result.CommitRange)
+	}
+
+	// Second push with a new commit reports the updated range.
+	if err := os.WriteFile(filepath.Join(path, "more.txt"), []byte("more\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Commit("demo", "add more.txt"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	result, err = m.Push("demo")
+	if err != nil {
+		t.Fatalf("second push: %v", err)
+	}
+	if result.NewBranch {
+		t.Error("second push should not report a new branch")
+	}
+	if !strings.Contains(result.CommitRange, "..") {
+		t.Errorf("update range = %q, want old..new", result.CommitRange)
+	}
+}
+
+func TestPRHintURL(t *testing.T) {
+	cases := []struct {
+		remote string
+		want   string
+	}{
+		{"git@github.com:owner/repo.git", "https://github.com/owner/repo/pull/new/workspace/demo"},
+		{"https://github.com/owner/repo.git", "https://github.com/owner/repo/pull/new/workspace/demo"},
+		{"https://github.com/owner/repo", "https://github.com/owner/repo/pull/new/workspace/demo"},
+		{"/tmp/bare-repo", ""},
+	}
+	for _, tc := range cases {
+		if got := prHintURL(tc.remote, "workspace/demo"); got != tc.want {
+			t.Errorf("prHintURL(%q) = %q, want %q", tc.remote, got, tc.want)
+		}
+	}
+}
+
+func TestStatusAndCommit(t *testing.T) {
+	m := New(initTestRepo(t))
+
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	status, err := m.Status("demo")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status != "" {
+		t.Errorf("fresh worktree status = %q, want clean", status)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "new.txt"), []byte("hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = m.Status("demo")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status == "" {
+		t.Error("status should report untracked file")
+	}
+
+	if err := m.Commit("demo", "add new.txt"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	status, err = m.Status("demo")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status != "" {
+		t.Errorf("status after commit = %q, want clean", status)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	m := New(initTestRepo(t))
+
+	// No origin: fall back to the checkout's current branch.
+	branch, err := m.DefaultBranch()
+	if err != nil {
+		t.Fatalf("DefaultBranch: %v", err)
+	}
+	if branch != "master" {
+		t.Errorf("branch = %q, want master", branch)
+	}
+
+	// origin/HEAD set: the remote's default branch wins.
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", m.RepoPath}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("remote", "add", "origin", m.RepoPath)
+	run("update-ref", "refs/remotes/origin/trunk", "HEAD")
+	run("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/trunk")
+	if branch, err = m.DefaultBranch(); err != nil || branch != "trunk" {
+		t.Errorf("branch = %q, %v, want trunk", branch, err)
+	}
+
+	// Explicit override beats detection.
+	m.BaseBranch = "release"
+	if branch, err = m.DefaultBranch(); err != nil || branch != "release" {
+		t.Errorf("branch = %q, %v, want release", branch, err)
+	}
+}
+
+func TestCreateWorktreeAt(t *testing.T) {
+	dir := initTestRepo(t)
+	m := New(dir)
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	first := run("rev-parse", "HEAD")
+	run("tag", "v1.0")
+	if err := os.WriteFile(filepath.Join(dir, "later.txt"), []byte("later\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-m", "later")
+
+	// A tag pin starts a new branch at the tagged commit.
+	path, branch, err := m.CreateWorktreeAt("repro", "v1.0", false)
+	if err != nil {
+		t.Fatalf("create worktree at tag: %v", err)
+	}
+	if branch != "workspace/repro" {
+		t.Errorf("branch = %q, want workspace/repro", branch)
+	}
+	cmd := exec.Command("git", "-C", path, "rev-parse", "HEAD")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("rev-parse in worktree: %v", err)
+	}
+	if head := strings.TrimSpace(string(out)); head != first {
+		t.Errorf("worktree HEAD = %s, want tagged commit %s", head, first)
+	}
+	if _, err := os.Stat(filepath.Join(path, "later.txt")); !os.IsNotExist(err) {
+		t.Error("pinned worktree contains files from a later commit")
+	}
+
+	// A detached pin checks the commit out without a branch.
+	path, branch, err = m.CreateWorktreeAt("repro-detached", first, true)
+	if err != nil {
+		t.Fatalf("create detached worktree: %v", err)
+	}
+	if branch != "" {
+		t.Errorf("detached branch = %q, want empty", branch)
+	}
+	if _, err := os.Stat(filepath.Join(path, "README.md")); err != nil {
+		t.Errorf("detached worktree missing checkout: %v", err)
+	}
+}
+
+func TestCommitTrailers(t *testing.T) {
+	m := New(initTestRepo(t))
+
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "new.txt"), []byte("hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Commit("demo", "add new.txt",
+		Trailer{Key: "Workspace-Id", Value: "ws-abc123"},
+		Trailer{Key: "Co-authored-by", Value: "Agent <agent@patina.dev>"},
+	)
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	body, err := m.gitIn(path, "log", "-1", "--format=%B")
+	if err != nil {
+		t.Fatalf("read commit message: %v", err)
+	}
+	for _, want := range []string{
+		"Workspace-Id: ws-abc123",
+		"Co-authored-by: Agent <agent@patina.dev>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("commit message missing trailer %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestFetchAndPull(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+
+	// Bare repository standing in for origin, seeded from the checkout.
+	origin := t.TempDir()
+	if out, err := exec.Command("git", "init", "--bare", origin).CombinedOutput(); err != nil {
+		t.Fatalf("init bare: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", repo, "remote", "add", "origin", origin).CombinedOutput(); err != nil {
+		t.Fatalf("add remote: %v\n%s", err, out)
+	}
+
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	if _, err := m.Push("demo"); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	// Nothing new upstream: pull reports the branch current.
+	result, err := m.Pull("demo", "")
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if result.Updated || result.Mode != "ff-only" {
+		t.Errorf("no-op pull = %+v, want current ff-only", result)
+	}
+
+	// A commit pushed from elsewhere fast-forwards in.
+	other := New(repo)
+	otherPath, _, err := other.CreateWorktree("other")
+	if err != nil {
+		t.Fatalf("create second worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(otherPath, "upstream.txt"), []byte("up\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Commit("other", "upstream change"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if out, err := exec.Command("git", "-C", otherPath, "push", "origin", "workspace/other:workspace/demo").CombinedOutput(); err != nil {
+		t.Fatalf("push upstream change: %v\n%s", err, out)
+	}
+
+	if err := m.Fetch("demo", ""); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	result, err = m.Pull("demo", "")
+	if err != nil {
+		t.Fatalf("pull after upstream change: %v", err)
+	}
+	if !result.Updated || result.OldHead == result.NewHead {
+		t.Errorf("pull = %+v, want a fast-forward", result)
+	}
+
+	if _, err := m.Pull("demo", "merge"); err == nil {
+		t.Error("expected error for unknown pull mode")
+	}
+}
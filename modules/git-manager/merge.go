@@ -0,0 +1,83 @@
+package gitmanager
+
+import "fmt"
+
+// MergeResult describes a workspace branch landed on its base.
+type MergeResult struct {
+	// Mode is the merge mode that ran: "merge", "no-ff" or "squash".
+	Mode string `json:"mode"`
+	// Base is the branch the work landed on.
+	Base string `json:"base"`
+	// SHA is the base branch's head after the merge.
+	SHA string `json:"sha"`
+}
+
+// MergeInto lands the workspace branch on base in the main checkout.
+// The default "merge" mode fast-forwards when it can; "no-ff" always
+// records a merge commit; "squash" collapses the branch into one
+// commit. An empty base means the repository's default branch.
+//
+// The merge runs where the user can see it, so the main checkout must
+// be clean and on the base branch — merging under someone's
+// uncommitted work is how checkouts get wrecked. On conflict the merge
+// is aborted and the branch is untouched; rebase the workspace first.
+func (m *Manager) MergeInto(name, base, mode string) (*MergeResult, error) {
+	branch := "workspace/" + name
+	if base == "" {
+		detected, err := m.DefaultBranch()
+		if err != nil {
+			return nil, err
+		}
+		base = detected
+	}
+
+	current, err := m.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve checked-out branch: %w", err)
+	}
+	if current != base {
+		return nil, fmt.Errorf("main checkout is on %s, not %s: check out the base branch before merging", current, base)
+	}
+	// Untracked files are ignored: worktrees themselves live under
+	// .patina/ inside the repo, and untracked files can't be clobbered
+	// by a merge anyway.
+	if status, err := m.git("status", "--porcelain", "--untracked-files=no"); err != nil {
+		return nil, fmt.Errorf("check main checkout: %w", err)
+	} else if status != "" {
+		return nil, fmt.Errorf("main checkout has uncommitted changes: commit or stash them before merging")
+	}
+
+	var args []string
+	squash := false
+	switch mode {
+	case "", "merge":
+		mode = "merge"
+		args = []string{"merge", "--no-edit", branch}
+	case "no-ff":
+		args = []string{"merge", "--no-ff", "--no-edit", branch}
+	case "squash":
+		squash = true
+		args = []string{"merge", "--squash", branch}
+	default:
+		return nil, fmt.Errorf("unknown merge mode %q (want merge, no-ff or squash)", mode)
+	}
+
+	if _, err := m.git(args...); err != nil {
+		// Leave the checkout clean: a conflicted merge is backed out,
+		// not left for whoever opens the repo next.
+		m.git("merge", "--abort") //nolint:errcheck
+		m.git("reset", "--merge") //nolint:errcheck // squash conflicts have no MERGE_HEAD
+		return nil, fmt.Errorf("merge %s into %s: %w", branch, base, err)
+	}
+	if squash {
+		if _, err := m.git("commit", "-m", fmt.Sprintf("merge %s (squash)", branch)); err != nil {
+			return nil, fmt.Errorf("commit squash of %s: %w", branch, err)
+		}
+	}
+
+	sha, err := m.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolve merged head: %w", err)
+	}
+	return &MergeResult{Mode: mode, Base: base, SHA: sha}, nil
+}
@@ -0,0 +1,58 @@
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeInto(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "a.txt", "first change")
+	commitFile(t, m, "demo", "b.txt", "second change")
+
+	result, err := m.MergeInto("demo", "master", "squash")
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if result.Base != "master" || result.Mode != "squash" {
+		t.Errorf("result = %+v, want squash into master", result)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "a.txt")); err != nil {
+		t.Errorf("merged file missing from checkout: %v", err)
+	}
+
+	// Squash lands as a single commit, not the branch's two.
+	subject, err := m.git("log", "-1", "--format=%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(subject, "workspace/demo") {
+		t.Errorf("squash commit subject = %q, want the branch named", subject)
+	}
+
+	if _, err := m.MergeInto("demo", "master", "octopus"); err == nil {
+		t.Error("expected error for unknown merge mode")
+	}
+}
+
+func TestMergeIntoRefusesDirtyCheckout(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "a.txt", "change")
+
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.MergeInto("demo", "master", ""); err == nil {
+		t.Error("expected error merging over uncommitted changes")
+	}
+}
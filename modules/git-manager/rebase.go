@@ -0,0 +1,221 @@
+package gitmanager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Rebase plan actions.
+const (
+	ActionPick   = "pick"
+	ActionSquash = "squash"
+	ActionDrop   = "drop"
+	ActionReword = "reword"
+)
+
+// RebaseStep is one entry in a rebase plan, in apply order.
+type RebaseStep struct {
+	// Action is one of pick, squash, drop, reword.
+	Action string `json:"action"`
+	// SHA identifies the commit.
+	SHA string `json:"sha"`
+	// Subject is the commit's subject line (informational in plans).
+	Subject string `json:"subject"`
+	// Message is the replacement commit message for reword.
+	Message string `json:"message,omitempty"`
+}
+
+// PlanRebase lists the commits on the workspace branch since base as an
+// editable rebase plan. Every step starts as a pick.
+func (m *Manager) PlanRebase(name, base string) ([]RebaseStep, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	out, err := m.gitIn(path, "log", "--reverse", "--format=%H%x00%s", base+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("plan rebase onto %s: %w", base, err)
+	}
+
+	var steps []RebaseStep
+	for _, line := range strings.Split(out, "\n") {
+		sha, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		steps = append(steps, RebaseStep{Action: ActionPick, SHA: sha, Subject: subject})
+	}
+	return steps, nil
+}
+
+// ApplyRebase runs an edited rebase plan non-interactively. The plan is
+// injected through GIT_SEQUENCE_EDITOR; rewords become exec steps that
+// amend the commit message. On conflict the rebase is aborted.
+func (m *Manager) ApplyRebase(name, base string, steps []RebaseStep) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	todo, err := renderTodo(steps)
+	if err != nil {
+		return err
+	}
+
+	planFile, err := os.CreateTemp("", "patina-rebase-*.todo")
+	if err != nil {
+		return fmt.Errorf("write rebase plan: %w", err)
+	}
+	defer os.Remove(planFile.Name())
+	if _, err := planFile.WriteString(todo); err != nil {
+		planFile.Close()
+		return fmt.Errorf("write rebase plan: %w", err)
+	}
+	planFile.Close()
+
+	cmd := exec.Command("git", "-C", path, "rebase", "-i", base)
+	cmd.Env = append(os.Environ(),
+		// Replace the todo with our plan instead of opening an editor.
+		"GIT_SEQUENCE_EDITOR=cp "+planFile.Name(),
+		// Accept git's combined message for squashes without prompting.
+		"GIT_EDITOR=true",
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Leave the worktree usable: abort the half-applied rebase.
+		m.gitIn(path, "rebase", "--abort") //nolint:errcheck
+		return fmt.Errorf("apply rebase plan: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// RebaseResult reports a straight rebase onto a new base. When
+// Conflicts is non-empty the rebase is left in progress: resolve and
+// stage the listed paths, then RebaseContinue — or RebaseAbort to back
+// out. This differs from ApplyRebase, which always aborts on conflict,
+// because long-lived agent branches accumulate conflicts that someone
+// has to actually resolve to stay mergeable.
+type RebaseResult struct {
+	// Completed is true when the rebase finished cleanly.
+	Completed bool `json:"completed"`
+	// Conflicts lists the conflicted paths where the rebase stopped.
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// Rebase replays the workspace branch onto the given base ref. An empty
+// base means the repository's default branch.
+func (m *Manager) Rebase(name, onto string) (*RebaseResult, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if onto == "" {
+		base, err := m.DefaultBranch()
+		if err != nil {
+			return nil, err
+		}
+		onto = base
+	}
+
+	if _, err := m.gitIn(path, "rebase", onto); err != nil {
+		conflicts, cerr := m.conflictedPaths(path)
+		if cerr != nil || len(conflicts) == 0 {
+			// Not a conflict stop (bad ref, dirty tree): leave nothing
+			// half-applied and surface the original error.
+			m.gitIn(path, "rebase", "--abort") //nolint:errcheck
+			return nil, fmt.Errorf("rebase %s onto %s: %w", name, onto, err)
+		}
+		return &RebaseResult{Conflicts: conflicts}, nil
+	}
+	return &RebaseResult{Completed: true}, nil
+}
+
+// RebaseContinue resumes a conflicted rebase after resolutions are
+// staged. It may stop again at the next conflicted commit; the result
+// says which.
+func (m *Manager) RebaseContinue(name string) (*RebaseResult, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	cmd := exec.Command("git", "-C", path, "rebase", "--continue")
+	// Accept the replayed commit's message without opening an editor.
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		conflicts, cerr := m.conflictedPaths(path)
+		if cerr != nil || len(conflicts) == 0 {
+			return nil, fmt.Errorf("continue rebase for %s: %s", name, strings.TrimSpace(stderr.String()))
+		}
+		return &RebaseResult{Conflicts: conflicts}, nil
+	}
+	return &RebaseResult{Completed: true}, nil
+}
+
+// RebaseAbort backs out of an in-progress rebase, restoring the branch
+// to its pre-rebase state.
+func (m *Manager) RebaseAbort(name string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if _, err := m.gitIn(path, "rebase", "--abort"); err != nil {
+		return fmt.Errorf("abort rebase for %s: %w", name, err)
+	}
+	return nil
+}
+
+// conflictedPaths lists the worktree's unmerged files.
+func (m *Manager) conflictedPaths(path string) ([]string, error) {
+	out, err := m.gitIn(path, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// renderTodo converts plan steps into a git rebase todo file. The todo
+// is parsed line by line and exec lines run shell commands, so nothing
+// client-controlled may land in it unvalidated: SHAs must be hex object
+// names, reword messages must be single-line, and subjects (purely
+// informational in plans) are not rendered at all.
+func renderTodo(steps []RebaseStep) (string, error) {
+	var b strings.Builder
+	for i, step := range steps {
+		if !isHexSHA(step.SHA) {
+			return "", fmt.Errorf("step %d: invalid commit SHA %q", i, step.SHA)
+		}
+		switch step.Action {
+		case ActionPick, ActionSquash, ActionDrop:
+			fmt.Fprintf(&b, "%s %s\n", step.Action, step.SHA)
+		case ActionReword:
+			if strings.ContainsAny(step.Message, "\r\n") {
+				return "", fmt.Errorf("step %d: reword message must be a single line", i)
+			}
+			// Reword without an editor: pick, then amend the message.
+			fmt.Fprintf(&b, "pick %s\n", step.SHA)
+			fmt.Fprintf(&b, "exec git commit --amend -m %s\n", shellQuote(step.Message))
+		default:
+			return "", fmt.Errorf("step %d: unknown action %q", i, step.Action)
+		}
+	}
+	return b.String(), nil
+}
+
+// isHexSHA reports whether s looks like a (possibly abbreviated) hex
+// object name.
+func isHexSHA(s string) bool {
+	if len(s) < 4 || len(s) > 64 {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// shellQuote single-quotes a string for use in a rebase exec line.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
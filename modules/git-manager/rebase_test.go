@@ -0,0 +1,238 @@
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// commitFile writes a file in the worktree and commits it.
+func commitFile(t *testing.T, m *Manager, name, file, message string) {
+	t.Helper()
+	path := filepath.Join(m.WorktreeRoot, name)
+	if err := os.WriteFile(filepath.Join(path, file), []byte(message+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Commit(name, message); err != nil {
+		t.Fatalf("commit %s: %v", message, err)
+	}
+}
+
+func TestPlanRebase(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	commitFile(t, m, "demo", "a.txt", "first change")
+	commitFile(t, m, "demo", "b.txt", "second change")
+
+	steps, err := m.PlanRebase("demo", "master")
+	if err != nil {
+		t.Fatalf("plan rebase: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("len(steps) = %d, want 2", len(steps))
+	}
+	if steps[0].Subject != "first change" || steps[1].Subject != "second change" {
+		t.Errorf("plan order = [%q %q], want apply order", steps[0].Subject, steps[1].Subject)
+	}
+	for _, step := range steps {
+		if step.Action != ActionPick {
+			t.Errorf("action = %q, want pick", step.Action)
+		}
+	}
+}
+
+func TestApplyRebaseSquashDropReword(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	commitFile(t, m, "demo", "a.txt", "first change")
+	commitFile(t, m, "demo", "b.txt", "second change")
+	commitFile(t, m, "demo", "c.txt", "third change")
+
+	steps, err := m.PlanRebase("demo", "master")
+	if err != nil {
+		t.Fatalf("plan rebase: %v", err)
+	}
+
+	// Squash the second commit into the first, reword the third.
+	steps[1].Action = ActionSquash
+	steps[2].Action = ActionReword
+	steps[2].Message = "third change, reworded"
+
+	if err := m.ApplyRebase("demo", "master", steps); err != nil {
+		t.Fatalf("apply rebase: %v", err)
+	}
+
+	after, err := m.PlanRebase("demo", "master")
+	if err != nil {
+		t.Fatalf("plan after rebase: %v", err)
+	}
+	if len(after) != 2 {
+		t.Fatalf("len(after) = %d, want 2", len(after))
+	}
+	if after[0].Subject != "first change" {
+		t.Errorf("squashed subject = %q, want first change", after[0].Subject)
+	}
+	if after[1].Subject != "third change, reworded" {
+		t.Errorf("reworded subject = %q", after[1].Subject)
+	}
+
+	// Files from all three commits survive.
+	path := filepath.Join(m.WorktreeRoot, "demo")
+	for _, file := range []string{"a.txt", "b.txt", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(path, file)); err != nil {
+			t.Errorf("missing %s after rebase: %v", file, err)
+		}
+	}
+}
+
+func TestApplyRebaseRejectsUnknownAction(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "a.txt", "first change")
+
+	steps := []RebaseStep{{Action: "edit", SHA: "abc123"}}
+	err := m.ApplyRebase("demo", "master", steps)
+	if err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Errorf("err = %v, want unknown action", err)
+	}
+}
+
+func TestApplyRebaseRejectsUnsafeSteps(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "a.txt", "first change")
+
+	// A newline in a rendered field would inject extra todo lines
+	// (including exec commands), so non-hex SHAs and multi-line reword
+	// messages never reach the todo file.
+	bad := []RebaseStep{{Action: ActionPick, SHA: "HEAD\nexec touch pwned"}}
+	err := m.ApplyRebase("demo", "master", bad)
+	if err == nil || !strings.Contains(err.Error(), "invalid commit SHA") {
+		t.Errorf("err = %v, want invalid commit SHA", err)
+	}
+
+	steps, err := m.PlanRebase("demo", "master")
+	if err != nil {
+		t.Fatalf("plan rebase: %v", err)
+	}
+	steps[0].Action = ActionReword
+	steps[0].Message = "ok\nexec touch pwned"
+	err = m.ApplyRebase("demo", "master", steps)
+	if err == nil || !strings.Contains(err.Error(), "single line") {
+		t.Errorf("err = %v, want single-line message error", err)
+	}
+}
+
+func TestRebaseOntoCleanAndConflicted(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "feature.txt", "feature work")
+
+	// Base moves forward on an unrelated file: the rebase is clean.
+	if err := os.WriteFile(filepath.Join(repo, "base.txt"), []byte("base\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("add", "."); err != nil {
+		t.Fatalf("stage base change: %v", err)
+	}
+	if _, err := m.git("commit", "-m", "base change"); err != nil {
+		t.Fatalf("commit base change: %v", err)
+	}
+
+	result, err := m.Rebase("demo", "master")
+	if err != nil {
+		t.Fatalf("rebase: %v", err)
+	}
+	if !result.Completed || len(result.Conflicts) != 0 {
+		t.Fatalf("clean rebase = %+v, want completed", result)
+	}
+
+	// Base rewrites the same file the branch touched: the rebase stops
+	// with the conflicted path reported.
+	if err := os.WriteFile(filepath.Join(repo, "feature.txt"), []byte("base version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("add", "."); err != nil {
+		t.Fatalf("stage conflict: %v", err)
+	}
+	if _, err := m.git("commit", "-m", "conflicting base change"); err != nil {
+		t.Fatalf("commit conflict: %v", err)
+	}
+
+	result, err = m.Rebase("demo", "master")
+	if err != nil {
+		t.Fatalf("conflicted rebase: %v", err)
+	}
+	if result.Completed || len(result.Conflicts) != 1 || result.Conflicts[0] != "feature.txt" {
+		t.Fatalf("conflicted rebase = %+v, want feature.txt conflict", result)
+	}
+
+	// Resolve, stage and continue to completion.
+	worktree := filepath.Join(m.WorktreeRoot, "demo")
+	if err := os.WriteFile(filepath.Join(worktree, "feature.txt"), []byte("merged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.gitIn(worktree, "add", "feature.txt"); err != nil {
+		t.Fatalf("stage resolution: %v", err)
+	}
+	result, err = m.RebaseContinue("demo")
+	if err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if !result.Completed {
+		t.Errorf("continue = %+v, want completed", result)
+	}
+}
+
+func TestRebaseAbortRestoresBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	m := New(repo)
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	commitFile(t, m, "demo", "feature.txt", "feature work")
+	worktree := filepath.Join(m.WorktreeRoot, "demo")
+	before, err := m.gitIn(worktree, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "feature.txt"), []byte("base version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("add", "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.git("commit", "-m", "conflicting base change"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Rebase("demo", "master")
+	if err != nil || result.Completed {
+		t.Fatalf("rebase = (%+v, %v), want a conflict stop", result, err)
+	}
+	if err := m.RebaseAbort("demo"); err != nil {
+		t.Fatalf("abort: %v", err)
+	}
+	after, err := m.gitIn(worktree, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Errorf("head after abort = %s, want %s", after, before)
+	}
+}
@@ -0,0 +1,56 @@
+package gitmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateWorktreeSparse(t *testing.T) {
+	repo := initTestRepo(t)
+	for _, dir := range []string{"services/api", "services/web"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := exec.Command("git", "-C", repo, "add", ".")
+	if out, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("add: %v\n%s", err, out)
+	}
+	run = exec.Command("git", "-C", repo, "commit", "-m", "add services")
+	if out, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("commit: %v\n%s", err, out)
+	}
+
+	m := New(repo)
+	path, branch, err := m.CreateWorktreeSparse("demo", []string{"services/api"})
+	if err != nil {
+		t.Fatalf("create sparse worktree: %v", err)
+	}
+	if branch != "workspace/demo" {
+		t.Errorf("branch = %q, want workspace/demo", branch)
+	}
+	if _, err := os.Stat(filepath.Join(path, "services", "api", "main.go")); err != nil {
+		t.Errorf("requested path not checked out: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "services", "web")); !os.IsNotExist(err) {
+		t.Errorf("unrequested path checked out (err = %v)", err)
+	}
+	// Root files come along in cone mode.
+	if _, err := os.Stat(filepath.Join(path, "README.md")); err != nil {
+		t.Errorf("root file not checked out: %v", err)
+	}
+
+	// Empty patterns mean a plain full worktree.
+	path, _, err = m.CreateWorktreeSparse("full", nil)
+	if err != nil {
+		t.Fatalf("create full worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "services", "web", "main.go")); err != nil {
+		t.Errorf("full worktree missing path: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package gitmanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// StashEntry is one set-aside change set in a workspace.
+type StashEntry struct {
+	// Ref addresses the entry, e.g. stash@{0}.
+	Ref string `json:"ref"`
+	// Message is the entry's description.
+	Message string `json:"message"`
+}
+
+// Stash sets the workspace's uncommitted changes (including untracked
+// files) aside, so a rebase or branch switch gets the clean tree it
+// needs without throwing work away. Returns false when there was
+// nothing to stash.
+func (m *Manager) Stash(name, message string) (bool, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	args := []string{"stash", "push", "--include-untracked"}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+	out, err := m.gitIn(path, args...)
+	if err != nil {
+		return false, fmt.Errorf("stash %s: %w", name, err)
+	}
+	return !strings.HasPrefix(out, "No local changes"), nil
+}
+
+// StashPop restores the most recently stashed changes into the
+// worktree and drops the entry.
+func (m *Manager) StashPop(name string) error {
+	path := filepath.Join(m.WorktreeRoot, name)
+	if _, err := m.gitIn(path, "stash", "pop"); err != nil {
+		return fmt.Errorf("pop stash for %s: %w", name, err)
+	}
+	return nil
+}
+
+// StashList returns the workspace's stash entries, newest first.
+func (m *Manager) StashList(name string) ([]StashEntry, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	out, err := m.gitIn(path, "stash", "list", "--format=%gd%x00%gs")
+	if err != nil {
+		return nil, fmt.Errorf("list stashes for %s: %w", name, err)
+	}
+
+	var entries []StashEntry
+	for _, line := range strings.Split(out, "\n") {
+		ref, message, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		entries = append(entries, StashEntry{Ref: ref, Message: message})
+	}
+	return entries, nil
+}
@@ -0,0 +1,61 @@
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStashRoundTrip(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	worktree := filepath.Join(m.WorktreeRoot, "demo")
+
+	// Nothing to stash on a clean tree.
+	stashed, err := m.Stash("demo", "")
+	if err != nil {
+		t.Fatalf("stash clean tree: %v", err)
+	}
+	if stashed {
+		t.Error("stash on a clean tree reported work set aside")
+	}
+
+	// An untracked file stashes, clears the tree, and pops back.
+	if err := os.WriteFile(filepath.Join(worktree, "wip.txt"), []byte("wip\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stashed, err = m.Stash("demo", "wip before rebase")
+	if err != nil {
+		t.Fatalf("stash: %v", err)
+	}
+	if !stashed {
+		t.Fatal("stash reported nothing to save")
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "wip.txt")); !os.IsNotExist(err) {
+		t.Errorf("stashed file still in worktree (err = %v)", err)
+	}
+
+	entries, err := m.StashList("demo")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Ref != "stash@{0}" {
+		t.Fatalf("entries = %+v, want one stash@{0}", entries)
+	}
+
+	if err := m.StashPop("demo"); err != nil {
+		t.Fatalf("pop: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "wip.txt")); err != nil {
+		t.Errorf("popped file missing: %v", err)
+	}
+	if entries, _ := m.StashList("demo"); len(entries) != 0 {
+		t.Errorf("entries after pop = %+v, want none", entries)
+	}
+
+	if err := m.StashPop("demo"); err == nil {
+		t.Error("expected error popping an empty stash")
+	}
+}
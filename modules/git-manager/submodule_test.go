@@ -0,0 +1,57 @@
+package gitmanager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateWorktreeInitsSubmodules(t *testing.T) {
+	sub := initTestRepo(t)
+	repo := initTestRepo(t)
+
+	// Newer git refuses file-protocol submodule clones by default;
+	// allow it via the environment so the manager's git subprocesses
+	// see it too. Both sides are test fixtures.
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("submodule", "add", sub, "vendor/sub")
+	run("commit", "-m", "add submodule")
+
+	m := New(repo)
+	m.InitSubmodules = true
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "vendor", "sub", "README.md")); err != nil {
+		t.Errorf("submodule not populated in worktree: %v", err)
+	}
+
+	// Without the opt-in the submodule directory stays empty.
+	m.InitSubmodules = false
+	path, _, err = m.CreateWorktree("plain")
+	if err != nil {
+		t.Fatalf("create plain worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "vendor", "sub", "README.md")); !os.IsNotExist(err) {
+		t.Errorf("submodule populated without opt-in (err = %v)", err)
+	}
+
+	// UpdateSubmodules populates it after the fact.
+	if err := m.UpdateSubmodules("plain"); err != nil {
+		t.Fatalf("update submodules: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "vendor", "sub", "README.md")); err != nil {
+		t.Errorf("submodule missing after update: %v", err)
+	}
+}
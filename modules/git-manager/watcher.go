@@ -0,0 +1,63 @@
+package gitmanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Worktree event types.
+const (
+	// EventFilesChanged reports a batch of changed paths.
+	EventFilesChanged = "files_changed"
+	// EventDirty fires once when a clean worktree becomes dirty.
+	EventDirty = "dirty"
+)
+
+// Event is a worktree change notification.
+type Event struct {
+	Type string `json:"type"`
+	// Paths are worktree-relative files that changed (files_changed only).
+	Paths []string  `json:"paths,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Watch emits events when files in the named workspace's worktree
+// change, so UIs and auto-commit policies can react without polling git
+// status. A dirty event fires on the clean-to-dirty transition; git
+// status is only consulted when the filesystem actually changed.
+//
+// The stream closes when stop is closed or the worktree disappears.
+func (m *Manager) Watch(name string, stop <-chan struct{}) (<-chan Event, error) {
+	path := filepath.Join(m.WorktreeRoot, name)
+
+	raw, err := watchTree(path, stop)
+	if err != nil {
+		return nil, fmt.Errorf("watch worktree for %s: %w", name, err)
+	}
+
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		wasDirty := m.isDirty(name)
+
+		for paths := range raw {
+			now := time.Now().UTC()
+
+			dirty := m.isDirty(name)
+			if dirty && !wasDirty {
+				events <- Event{Type: EventDirty, Time: now}
+			}
+			wasDirty = dirty
+
+			events <- Event{Type: EventFilesChanged, Paths: paths, Time: now}
+		}
+	}()
+	return events, nil
+}
+
+// isDirty reports whether the worktree has uncommitted changes.
+func (m *Manager) isDirty(name string) bool {
+	status, err := m.Status(name)
+	return err == nil && status != ""
+}
@@ -0,0 +1,136 @@
+//go:build linux
+
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// watchMask covers everything that can make a worktree dirty.
+const watchMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE |
+	syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM
+
+// debounceWindow batches rapid-fire events (editors write several times
+// per save) into one notification.
+const debounceWindow = 200 * time.Millisecond
+
+// watchTree watches root recursively via inotify and sends batches of
+// changed worktree-relative paths. The .git directory is ignored.
+func watchTree(root string, stop <-chan struct{}) (<-chan []string, error) {
+	fd, err := syscall.InotifyInit1(0)
+	if err != nil {
+		return nil, err
+	}
+
+	watches := map[int]string{} // watch descriptor -> directory
+	addWatch := func(dir string) {
+		wd, err := syscall.InotifyAddWatch(fd, dir, watchMask)
+		if err == nil {
+			watches[wd] = dir
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // skip unreadable entries
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			addWatch(path)
+		}
+		return nil
+	})
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	// Closing the fd unblocks the reader goroutine when stop fires.
+	go func() {
+		<-stop
+		syscall.Close(fd)
+	}()
+
+	out := make(chan []string, 16)
+	go func() {
+		defer close(out)
+
+		var pending []string
+		var timer <-chan time.Time
+		raw := make(chan string, 64)
+
+		// Reader: parse inotify events into worktree-relative paths.
+		go func() {
+			defer close(raw)
+			buf := make([]byte, 64*1024)
+			for {
+				n, err := syscall.Read(fd, buf)
+				if err != nil || n <= 0 {
+					return
+				}
+				for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+					ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+					name := ""
+					if ev.Len > 0 {
+						nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(ev.Len)]
+						name = strings.TrimRight(string(nameBytes), "\x00")
+					}
+					offset += syscall.SizeofInotifyEvent + int(ev.Len)
+
+					dir, ok := watches[int(ev.Wd)]
+					if !ok || name == "" || strings.HasPrefix(name, ".git") {
+						continue
+					}
+					full := filepath.Join(dir, name)
+
+					// Watch directories as they appear.
+					if ev.Mask&syscall.IN_CREATE != 0 || ev.Mask&syscall.IN_MOVED_TO != 0 {
+						if info, err := os.Stat(full); err == nil && info.IsDir() {
+							addWatch(full)
+						}
+					}
+
+					if rel, err := filepath.Rel(root, full); err == nil {
+						raw <- rel
+					}
+				}
+			}
+		}()
+
+		for {
+			select {
+			case rel, ok := <-raw:
+				if !ok {
+					return
+				}
+				if !contains(pending, rel) {
+					pending = append(pending, rel)
+				}
+				if timer == nil {
+					timer = time.After(debounceWindow)
+				}
+			case <-timer:
+				out <- pending
+				pending = nil
+				timer = nil
+			}
+		}
+	}()
+	return out, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+//go:build !linux
+
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollInterval is how often non-Linux hosts scan the worktree. inotify
+// isn't available, so fall back to comparing modification times.
+const pollInterval = 500 * time.Millisecond
+
+// watchTree polls root and sends batches of changed worktree-relative
+// paths. The .git directory is ignored.
+func watchTree(root string, stop <-chan struct{}) (<-chan []string, error) {
+	previous, err := snapshot(root)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan []string, 16)
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current, err := snapshot(root)
+				if err != nil {
+					// Worktree removed: end the stream.
+					return
+				}
+				if changed := diff(previous, current); len(changed) > 0 {
+					out <- changed
+				}
+				previous = current
+			}
+		}
+	}()
+	return out, nil
+}
+
+// snapshot records the modification time of every file under root.
+func snapshot(root string) (map[string]time.Time, error) {
+	files := map[string]time.Time{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // skip unreadable entries
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			if rel, err := filepath.Rel(root, path); err == nil {
+				files[rel] = info.ModTime()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// diff returns paths added, removed or modified between snapshots.
+func diff(before, after map[string]time.Time) []string {
+	var changed []string
+	for path, mtime := range after {
+		if prev, ok := before[path]; !ok || !prev.Equal(mtime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
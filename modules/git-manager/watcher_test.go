@@ -0,0 +1,92 @@
+package gitmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// collectEvents drains events until one of the wanted type arrives or
+// the timeout expires.
+func collectEvents(t *testing.T, events <-chan Event, wantType string) Event {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("event stream closed before %s event", wantType)
+			}
+			if event.Type == wantType {
+				return event
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s event", wantType)
+		}
+	}
+}
+
+func TestWatchEmitsDirtyAndChanges(t *testing.T) {
+	m := New(initTestRepo(t))
+	path, _, err := m.CreateWorktree("demo")
+	if err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	events, err := m.Watch("demo", stop)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	// Give the watcher a moment to establish watches.
+	time.Sleep(100 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(path, "scratch.txt"), []byte("hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirty := collectEvents(t, events, EventDirty)
+	if dirty.Type != EventDirty {
+		t.Errorf("event type = %q, want %q", dirty.Type, EventDirty)
+	}
+
+	changed := collectEvents(t, events, EventFilesChanged)
+	found := false
+	for _, p := range changed.Paths {
+		if p == "scratch.txt" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("changed paths = %v, want scratch.txt", changed.Paths)
+	}
+}
+
+func TestWatchStop(t *testing.T) {
+	m := New(initTestRepo(t))
+	if _, _, err := m.CreateWorktree("demo"); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+
+	stop := make(chan struct{})
+	events, err := m.Watch("demo", stop)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	close(stop)
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			// Drain any buffered event; the channel must close soon.
+			for range events {
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream did not close after stop")
+	}
+}
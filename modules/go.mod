@@ -0,0 +1,3 @@
+module github.com/NicabarNimble/patina/modules
+
+go 1.21
@@ -0,0 +1,264 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Client talks to a running gateway and asserts on its responses. All
+// methods take the test so failures point at the calling test, not the
+// harness internals. The response types mirror the gateway's JSON
+// rather than importing the other modules, keeping the harness usable
+// against any gateway build.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// Workspace is the subset of the workspace record lifecycle tests
+// assert on.
+type Workspace struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Branch   string `json:"branch"`
+	Worktree string `json:"worktree"`
+	Status   string `json:"status"`
+}
+
+// ExecResult mirrors the executor's result JSON.
+type ExecResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// PushResult mirrors the git manager's push report.
+type PushResult struct {
+	Branch       string `json:"branch"`
+	RemoteBranch string `json:"remote_branch"`
+	NewBranch    bool   `json:"new_branch"`
+}
+
+// CreateWorkspace creates a workspace and returns its record. The body
+// is the create request as loose JSON, so tests can exercise any field
+// without the harness tracking the gateway's request schema.
+func (c *Client) CreateWorkspace(t *testing.T, body map[string]any) *Workspace {
+	t.Helper()
+	var ws Workspace
+	c.do(t, http.MethodPost, "/workspaces", body, http.StatusCreated, &ws)
+	return &ws
+}
+
+// WaitReady polls the workspace until it reports ready, failing on
+// error status or timeout. Creation is asynchronous; every lifecycle
+// test needs this between create and first exec.
+func (c *Client) WaitReady(t *testing.T, id string) *Workspace {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		ws := c.Workspace(t, id)
+		switch ws.Status {
+		case "ready":
+			return ws
+		case "error":
+			t.Fatalf("workspace %s failed to create", id)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("workspace %s not ready within 30s", id)
+	return nil
+}
+
+// Workspace fetches the workspace record.
+func (c *Client) Workspace(t *testing.T, id string) *Workspace {
+	t.Helper()
+	var ws Workspace
+	c.do(t, http.MethodGet, "/workspaces/"+id, nil, http.StatusOK, &ws)
+	return &ws
+}
+
+// Exec runs a command in the workspace and returns its result.
+func (c *Client) Exec(t *testing.T, id string, command ...string) *ExecResult {
+	t.Helper()
+	var result ExecResult
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/exec",
+		map[string]any{"command": command}, http.StatusOK, &result)
+	return &result
+}
+
+// Push pushes the workspace branch to origin.
+func (c *Client) Push(t *testing.T, id string) *PushResult {
+	t.Helper()
+	var result PushResult
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/push", nil, http.StatusOK, &result)
+	return &result
+}
+
+// ExecStdin runs a command in the workspace with inline stdin text and
+// expects success.
+func (c *Client) ExecStdin(t *testing.T, id, stdin string, command ...string) *ExecResult {
+	t.Helper()
+	var result ExecResult
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/exec",
+		map[string]any{"command": command, "stdin_text": stdin}, http.StatusOK, &result)
+	return &result
+}
+
+// UploadFile puts raw bytes at a worktree-relative path in the
+// workspace and expects success.
+func (c *Client) UploadFile(t *testing.T, id, path string, data []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut,
+		c.BaseURL+"/workspaces/"+id+"/files/"+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		t.Fatalf("PUT files/%s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PUT files/%s: status %d: %s", path, resp.StatusCode, body)
+	}
+}
+
+// DownloadFile gets a worktree-relative file from the workspace and
+// returns its raw bytes.
+func (c *Client) DownloadFile(t *testing.T, id, path string) []byte {
+	t.Helper()
+	resp, err := c.HTTP.Get(c.BaseURL + "/workspaces/" + id + "/files/" + path)
+	if err != nil {
+		t.Fatalf("GET files/%s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("GET files/%s: read body: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET files/%s: status %d: %s", path, resp.StatusCode, body)
+	}
+	return body
+}
+
+// Pause tears down the workspace's environment while keeping its
+// record and worktree.
+func (c *Client) Pause(t *testing.T, id string) *Workspace {
+	t.Helper()
+	var ws Workspace
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/pause", nil, http.StatusOK, &ws)
+	return &ws
+}
+
+// Resume recreates a paused workspace's environment. Like create, the
+// rebuild is asynchronous; follow with WaitReady.
+func (c *Client) Resume(t *testing.T, id string) *Workspace {
+	t.Helper()
+	var ws Workspace
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/resume", nil, http.StatusOK, &ws)
+	return &ws
+}
+
+// Lock takes the workspace's exclusive lock as holder.
+func (c *Client) Lock(t *testing.T, id, holder string) *Workspace {
+	t.Helper()
+	var ws Workspace
+	c.do(t, http.MethodPost, "/workspaces/"+id+"/lock",
+		map[string]any{"holder": holder}, http.StatusOK, &ws)
+	return &ws
+}
+
+// Unlock releases the workspace's lock as holder.
+func (c *Client) Unlock(t *testing.T, id, holder string) {
+	t.Helper()
+	c.do(t, http.MethodDelete, "/workspaces/"+id+"/lock?holder="+holder, nil, http.StatusOK, nil)
+}
+
+// ExecInfo is one in-flight execution as reported by the gateway.
+type ExecInfo struct {
+	ID        string    `json:"id"`
+	Command   []string  `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Execs lists the workspace's in-flight executions.
+func (c *Client) Execs(t *testing.T, id string) []ExecInfo {
+	t.Helper()
+	var execs []ExecInfo
+	c.do(t, http.MethodGet, "/workspaces/"+id+"/exec", nil, http.StatusOK, &execs)
+	return execs
+}
+
+// CancelExec cancels an in-flight execution, killing its process.
+func (c *Client) CancelExec(t *testing.T, id, execID string) {
+	t.Helper()
+	c.do(t, http.MethodDelete, "/workspaces/"+id+"/exec/"+execID, nil, http.StatusOK, nil)
+}
+
+// ExecutionRecord is one entry of the workspace's exec audit log.
+type ExecutionRecord struct {
+	ID         string   `json:"id"`
+	Command    []string `json:"command"`
+	User       string   `json:"user"`
+	ExitCode   int      `json:"exit_code"`
+	Error      string   `json:"error"`
+	Truncated  bool     `json:"truncated"`
+	OutputHash string   `json:"output_hash"`
+}
+
+// Executions lists the workspace's execution audit log, oldest first.
+func (c *Client) Executions(t *testing.T, id string) []ExecutionRecord {
+	t.Helper()
+	var records []ExecutionRecord
+	c.do(t, http.MethodGet, "/workspaces/"+id+"/executions", nil, http.StatusOK, &records)
+	return records
+}
+
+// Delete removes the workspace, its worktree and its environment.
+func (c *Client) Delete(t *testing.T, id string) {
+	t.Helper()
+	c.do(t, http.MethodDelete, "/workspaces/"+id, nil, http.StatusOK, nil)
+}
+
+// do sends one request, asserts the status code and decodes the
+// response into out when non-nil.
+func (c *Client) do(t *testing.T, method, path string, body any, wantStatus int, out any) {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("%s %s: encode body: %v", method, path, err)
+		}
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, &buf)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != wantStatus {
+		var msg bytes.Buffer
+		fmt.Fprintf(&msg, "%s %s: status %d, want %d", method, path, resp.StatusCode, wantStatus)
+		var body map[string]any
+		if json.NewDecoder(resp.Body).Decode(&body) == nil {
+			fmt.Fprintf(&msg, ": %v", body)
+		}
+		t.Fatal(msg.String())
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+}
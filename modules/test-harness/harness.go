@@ -0,0 +1,191 @@
+// Package harness spins up the full workspace stack for integration
+// tests: a temp git repository with a bare origin, a stand-in dagger CLI
+// (or the real one), and the api-gateway binary listening on a free
+// port. Each module tests in isolation; this package covers the
+// coordination paths between them (create → exec → commit → push →
+// delete) end to end.
+package harness
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDagger is a shell stand-in for the dagger CLI. It runs the
+// pipeline's with-exec command directly in the first mounted directory
+// (the worktree), so exec tests produce real file changes without a
+// container engine. Pipelines without a with-exec step (image warming)
+// succeed silently.
+const fakeDagger = `#!/bin/sh
+dir=""
+args=""
+for a in "$@"; do
+	case "$a" in
+	--directory=*) [ -z "$dir" ] && dir="${a#--directory=}" ;;
+	--args=*) args="${a#--args=}" ;;
+	esac
+done
+[ -z "$args" ] && exit 0
+[ -n "$dir" ] && cd "$dir"
+oldifs=$IFS
+IFS=','
+set -- $args
+IFS=$oldifs
+exec "$@"
+`
+
+// Harness is one running instance of the stack. All paths are temp
+// directories cleaned up when the test ends.
+type Harness struct {
+	// RepoDir is the main checkout the gateway manages.
+	RepoDir string
+	// OriginDir is the bare repository the workspace branches push to.
+	OriginDir string
+	// Client talks to the running gateway.
+	Client *Client
+}
+
+// New builds the gateway, prepares a seeded git repository with an
+// origin remote, installs the fake dagger CLI (set
+// PATINA_HARNESS_REAL_DAGGER=1 to use the real one from PATH) and
+// starts the gateway. It skips the test when git or the go toolchain
+// is unavailable.
+func New(t *testing.T) *Harness {
+	t.Helper()
+	for _, bin := range []string{"git", "go"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not available: %v", bin, err)
+		}
+	}
+
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "origin.git")
+	repo := filepath.Join(dir, "repo")
+
+	git(t, dir, "init", "--bare", origin)
+	git(t, dir, "init", repo)
+	git(t, repo, "config", "user.name", "harness")
+	git(t, repo, "config", "user.email", "harness@test")
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("seed\n"), 0o644); err != nil {
+		t.Fatalf("seed repo: %v", err)
+	}
+	git(t, repo, "add", "README.md")
+	git(t, repo, "commit", "-m", "seed")
+	git(t, repo, "remote", "add", "origin", origin)
+	git(t, repo, "push", "-u", "origin", "HEAD")
+
+	path := os.Getenv("PATH")
+	if os.Getenv("PATINA_HARNESS_REAL_DAGGER") == "" {
+		binDir := filepath.Join(dir, "bin")
+		if err := os.Mkdir(binDir, 0o755); err != nil {
+			t.Fatalf("create bin dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(binDir, "dagger"), []byte(fakeDagger), 0o755); err != nil {
+			t.Fatalf("install fake dagger: %v", err)
+		}
+		path = binDir + string(os.PathListSeparator) + path
+	}
+
+	gateway := buildGateway(t, dir)
+	port := freePort(t)
+
+	cmd := exec.Command(gateway, "-repo", repo, "-port", strconv.Itoa(port))
+	cmd.Env = append(os.Environ(), "PATH="+path)
+	var logs strings.Builder
+	cmd.Stdout = &logs
+	cmd.Stderr = &logs
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start gateway: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill() //nolint:errcheck
+		cmd.Wait()         //nolint:errcheck
+		if t.Failed() {
+			t.Logf("gateway logs:\n%s", logs.String())
+		}
+	})
+
+	h := &Harness{
+		RepoDir:   repo,
+		OriginDir: origin,
+		Client: &Client{
+			BaseURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+			HTTP:    &http.Client{Timeout: 30 * time.Second},
+		},
+	}
+	h.waitHealthy(t)
+	return h
+}
+
+// WorktreePath returns the host path of the named workspace's worktree.
+func (h *Harness) WorktreePath(name string) string {
+	return filepath.Join(h.RepoDir, ".patina", "worktrees", name)
+}
+
+// Git runs a git command in the given directory and returns trimmed
+// stdout, failing the test on error. Use it to assert repository state
+// the HTTP API doesn't expose (commit counts, remote refs).
+func (h *Harness) Git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	return git(t, dir, args...)
+}
+
+// waitHealthy polls the gateway's health endpoint until it answers.
+func (h *Harness) waitHealthy(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := h.Client.HTTP.Get(h.Client.BaseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("gateway did not become healthy within 10s")
+}
+
+// buildGateway compiles the api-gateway binary into the harness dir.
+func buildGateway(t *testing.T, dir string) string {
+	t.Helper()
+	bin := filepath.Join(dir, "gateway")
+	cmd := exec.Command("go", "build", "-o", bin, "github.com/NicabarNimble/patina/modules/api-gateway")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("build gateway: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// freePort asks the kernel for an unused TCP port.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("allocate port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// git runs a git command and returns trimmed stdout, failing the test
+// on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
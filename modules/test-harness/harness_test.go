@@ -0,0 +1,415 @@
+package harness
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWorkspaceLifecycle walks the full coordination path the modules
+// never see individually: create a workspace, exec a command that
+// changes the worktree, auto-checkpoint the change, push the branch to
+// origin and delete the workspace.
+func TestWorkspaceLifecycle(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":       "demo",
+		"image":      "alpine:3.20",
+		"checkpoint": map[string]any{"enabled": true, "after_exec": true},
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	if ws.Branch != "workspace/demo" {
+		t.Errorf("branch = %q, want workspace/demo", ws.Branch)
+	}
+	if _, err := os.Stat(ws.Worktree); err != nil {
+		t.Fatalf("worktree missing: %v", err)
+	}
+
+	// The fake dagger runs the command in the mounted worktree, so the
+	// file change is real and the after-exec checkpoint commits it.
+	result := h.Client.Exec(t, ws.ID, "sh", "-c", "echo hello > greeting.txt")
+	if result.ExitCode != 0 {
+		t.Fatalf("exec exit code = %d, stderr: %s", result.ExitCode, result.Stderr)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Worktree, "greeting.txt")); err != nil {
+		t.Fatalf("exec did not write to worktree: %v", err)
+	}
+	subject := h.Git(t, ws.Worktree, "log", "-1", "--format=%s")
+	if !strings.HasPrefix(subject, "checkpoint:") {
+		t.Errorf("head commit = %q, want a checkpoint commit", subject)
+	}
+
+	push := h.Client.Push(t, ws.ID)
+	if !push.NewBranch || push.RemoteBranch != "origin/workspace/demo" {
+		t.Errorf("push = %+v, want new origin/workspace/demo", push)
+	}
+	h.Git(t, h.OriginDir, "rev-parse", "refs/heads/workspace/demo")
+
+	h.Client.Delete(t, ws.ID)
+	if _, err := os.Stat(ws.Worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree still present after delete (err = %v)", err)
+	}
+}
+
+// TestExecOutputRoundTrip asserts stdout flows back through the
+// gateway unmodified.
+func TestExecOutputRoundTrip(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "echo",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	result := h.Client.Exec(t, ws.ID, "echo", "round-trip")
+	if strings.TrimSpace(result.Stdout) != "round-trip" {
+		t.Errorf("stdout = %q, want round-trip", result.Stdout)
+	}
+}
+
+func TestExecStdinRoundTrip(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "stdin",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	result := h.Client.ExecStdin(t, ws.ID, "hello from stdin\n", "cat")
+	if result.Stdout != "hello from stdin\n" {
+		t.Errorf("stdout = %q, want stdin piped through", result.Stdout)
+	}
+}
+
+func TestFileUpload(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "upload",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	data := []byte("payload\x00with\x00nuls")
+	h.Client.UploadFile(t, ws.ID, "data/seed.bin", data)
+
+	got, err := os.ReadFile(filepath.Join(h.WorktreePath("upload"), "data", "seed.bin"))
+	if err != nil {
+		t.Fatalf("uploaded file missing from worktree: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("content = %q, want %q", got, data)
+	}
+
+	// And back out again through the download endpoint.
+	if round := h.Client.DownloadFile(t, ws.ID, "data/seed.bin"); !bytes.Equal(round, data) {
+		t.Errorf("downloaded content = %q, want %q", round, data)
+	}
+}
+
+// TestPauseResume walks the eviction lifecycle by hand: a paused
+// workspace refuses execs but keeps its record and worktree, and
+// resuming brings it back to ready.
+func TestPauseResume(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "parked",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	paused := h.Client.Pause(t, ws.ID)
+	if paused.Status != "paused" {
+		t.Fatalf("status after pause = %q, want paused", paused.Status)
+	}
+	if _, err := os.Stat(ws.Worktree); err != nil {
+		t.Errorf("worktree missing while paused: %v", err)
+	}
+
+	// Paused workspaces reject execs with a conflict, not a silent run.
+	resp, err := http.Post(h.Client.BaseURL+"/workspaces/"+ws.ID+"/exec",
+		"application/json", strings.NewReader(`{"command":["true"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("exec while paused: status %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	h.Client.Resume(t, ws.ID)
+	ws = h.Client.WaitReady(t, ws.ID)
+	if result := h.Client.Exec(t, ws.ID, "echo", "back"); strings.TrimSpace(result.Stdout) != "back" {
+		t.Errorf("exec after resume: stdout = %q, want back", result.Stdout)
+	}
+}
+
+// TestWorkspaceLock asserts a held lock fences out other callers'
+// execs until the holder releases it.
+func TestWorkspaceLock(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "fenced",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	h.Client.Lock(t, ws.ID, "agent-a")
+
+	// An exec without the holder's identity bounces with 403.
+	resp, err := http.Post(h.Client.BaseURL+"/workspaces/"+ws.ID+"/exec",
+		"application/json", strings.NewReader(`{"command":["true"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("exec while locked: status %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	// The holder keeps working.
+	var result ExecResult
+	h.Client.do(t, http.MethodPost, "/workspaces/"+ws.ID+"/exec",
+		map[string]any{"command": []string{"echo", "mine"}, "holder": "agent-a"},
+		http.StatusOK, &result)
+	if strings.TrimSpace(result.Stdout) != "mine" {
+		t.Errorf("holder exec stdout = %q, want mine", result.Stdout)
+	}
+
+	h.Client.Unlock(t, ws.ID, "agent-a")
+	if after := h.Client.Exec(t, ws.ID, "echo", "free"); strings.TrimSpace(after.Stdout) != "free" {
+		t.Errorf("exec after unlock: stdout = %q, want free", after.Stdout)
+	}
+}
+
+// TestExecCancel asserts a long-running exec shows up in the
+// workspace's exec list and dies when cancelled, without the original
+// caller hanging for the full command duration.
+func TestExecCancel(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "stuck",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	// The exec blocks until cancelled, so it runs off the test goroutine.
+	done := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(h.Client.BaseURL+"/workspaces/"+ws.ID+"/exec",
+			"application/json", strings.NewReader(`{"command":["sleep","30"]}`))
+		if err != nil {
+			done <- -1
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	var execs []ExecInfo
+	deadline := time.Now().Add(5 * time.Second)
+	for len(execs) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("exec never appeared in the in-flight list")
+		}
+		time.Sleep(10 * time.Millisecond)
+		execs = h.Client.Execs(t, ws.ID)
+	}
+	if got := strings.Join(execs[0].Command, " "); got != "sleep 30" {
+		t.Errorf("in-flight command = %q, want sleep 30", got)
+	}
+
+	h.Client.CancelExec(t, ws.ID, execs[0].ID)
+
+	select {
+	case status := <-done:
+		// 499 (client closed request): the exec died because its caller
+		// asked for that, not because the server broke.
+		if status != 499 {
+			t.Errorf("cancelled exec: status %d, want 499", status)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("exec still running after cancel")
+	}
+
+	if left := h.Client.Execs(t, ws.ID); len(left) != 0 {
+		t.Errorf("exec list after cancel = %d entries, want 0", len(left))
+	}
+}
+
+// TestExecutionHistory asserts every exec lands in the workspace's
+// audit log with its outcome, whether or not it succeeded.
+func TestExecutionHistory(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "audited",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	h.Client.Exec(t, ws.ID, "echo", "ok")
+	if failed := h.Client.Exec(t, ws.ID, "false"); failed.ExitCode != 1 {
+		t.Fatalf("false exit code = %d, want 1", failed.ExitCode)
+	}
+
+	records := h.Client.Executions(t, ws.ID)
+	if len(records) != 2 {
+		t.Fatalf("history has %d records, want 2", len(records))
+	}
+	first, second := records[0], records[1]
+	if strings.Join(first.Command, " ") != "echo ok" || first.ExitCode != 0 {
+		t.Errorf("first record = %+v, want echo ok with exit 0", first)
+	}
+	if !strings.HasPrefix(first.OutputHash, "sha256:") {
+		t.Errorf("first record output hash = %q, want sha256 fingerprint", first.OutputHash)
+	}
+	if second.ExitCode != 1 {
+		t.Errorf("second record exit code = %d, want 1", second.ExitCode)
+	}
+
+	// ?limit tails the log.
+	var limited []ExecutionRecord
+	h.Client.do(t, http.MethodGet, "/workspaces/"+ws.ID+"/executions?limit=1", nil, http.StatusOK, &limited)
+	if len(limited) != 1 || limited[0].ExitCode != 1 {
+		t.Errorf("limit=1 tail = %+v, want just the failing exec", limited)
+	}
+}
+
+// TestExecNDJSONStreaming asserts Accept: application/x-ndjson turns
+// an exec into a line-delimited JSON stream ending with the exit code.
+func TestExecNDJSONStreaming(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{
+		"name":  "ndjson",
+		"image": "alpine:3.20",
+	})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	req, err := http.NewRequest(http.MethodPost, h.Client.BaseURL+"/workspaces/"+ws.ID+"/exec",
+		strings.NewReader(`{"command":["echo","streamed"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	resp, err := h.Client.HTTP.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("content type = %q, want application/x-ndjson", ct)
+	}
+
+	var sawOutput bool
+	var last map[string]any
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("bad NDJSON line %q: %v", scanner.Text(), err)
+		}
+		if record["stream"] == "stdout" && strings.Contains(record["data"].(string), "streamed") {
+			sawOutput = true
+		}
+		last = record
+	}
+	if !sawOutput {
+		t.Error("no stdout record carried the command output")
+	}
+	if code, ok := last["exit_code"].(float64); !ok || code != 0 {
+		t.Errorf("final record = %v, want exit_code 0", last)
+	}
+}
+
+// TestResponseCompression asserts the gateway gzips JSON for clients
+// that accept it. The request sets Accept-Encoding by hand so the HTTP
+// client doesn't transparently decompress and hide the header.
+func TestResponseCompression(t *testing.T) {
+	h := New(t)
+
+	req, err := http.NewRequest(http.MethodGet, h.Client.BaseURL+"/workspaces", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := h.Client.HTTP.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", got)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("response is not valid gzip: %v", err)
+	}
+	var list []Workspace
+	if err := json.NewDecoder(gz).Decode(&list); err != nil {
+		t.Errorf("decode compressed list: %v", err)
+	}
+}
+
+// TestReconcile drives the drift detector against real drift: a
+// worktree deleted behind the gateway's back (ghost) and a stray
+// directory under the worktree root (orphan).
+func TestReconcile(t *testing.T) {
+	h := New(t)
+
+	ws := h.Client.CreateWorkspace(t, map[string]any{"name": "drift", "image": "alpine:3.20"})
+	ws = h.Client.WaitReady(t, ws.ID)
+
+	if err := os.RemoveAll(ws.Worktree); err != nil {
+		t.Fatalf("remove worktree: %v", err)
+	}
+	orphanDir := filepath.Join(filepath.Dir(ws.Worktree), "stray")
+	if err := os.MkdirAll(orphanDir, 0o755); err != nil {
+		t.Fatalf("create orphan: %v", err)
+	}
+
+	var report struct {
+		Ghosts       []string `json:"ghosts"`
+		Orphans      []string `json:"orphans"`
+		Deregistered int      `json:"deregistered"`
+		Adopted      int      `json:"adopted"`
+	}
+	h.Client.do(t, http.MethodGet, "/admin/reconcile", nil, http.StatusOK, &report)
+	if len(report.Ghosts) != 1 || report.Ghosts[0] != ws.ID {
+		t.Errorf("ghosts = %v, want [%s]", report.Ghosts, ws.ID)
+	}
+	if len(report.Orphans) != 1 || report.Orphans[0] != "stray" {
+		t.Errorf("orphans = %v, want [stray]", report.Orphans)
+	}
+	if report.Deregistered != 0 || report.Adopted != 0 {
+		t.Errorf("report-only run repaired: %+v", report)
+	}
+
+	h.Client.do(t, http.MethodPost, "/admin/reconcile", nil, http.StatusOK, &report)
+	if report.Deregistered != 1 || report.Adopted != 1 {
+		t.Fatalf("repair = %+v, want 1 deregistered and 1 adopted", report)
+	}
+
+	// The ghost is gone and the orphan is a paused workspace now.
+	var list []Workspace
+	h.Client.do(t, http.MethodGet, "/workspaces", nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].Name != "stray" || list[0].Status != "paused" {
+		t.Errorf("workspaces after repair = %+v, want just paused stray", list)
+	}
+}
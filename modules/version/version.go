@@ -0,0 +1,17 @@
+// Package version carries build-time identity for patina binaries.
+//
+// Release builds inject the values via ldflags:
+//
+//	go build -ldflags "\
+//	  -X github.com/NicabarNimble/patina/modules/version.Version=$(git describe --tags) \
+//	  -X github.com/NicabarNimble/patina/modules/version.Commit=$(git rev-parse --short HEAD)"
+//
+// Untagged development builds keep the defaults.
+package version
+
+var (
+	// Version is the release version, e.g. "v0.3.0".
+	Version = "dev"
+	// Commit is the git commit the binary was built from.
+	Commit = "unknown"
+)